@@ -0,0 +1,93 @@
+// Package broker defines a broker-agnostic publish/subscribe abstraction
+// implemented by the engine message broker clients.
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Broker is the common contract for message brokers.
+type Broker interface {
+	// Publish sends a JSON-encoded message to the topic.
+	Publish(ctx context.Context, topic string, data any) error
+	// Subscribe consumes messages of the topic with the handler.
+	Subscribe(topic string, handler Handler) error
+	// Request sends a message and waits for the reply, decoding it into resp.
+	Request(ctx context.Context, topic string, req any, resp any) error
+	// Close gracefully shuts down the broker connection.
+	Close() error
+}
+
+// Handler processes a consumed message. Returning an error signals
+// the broker to redeliver the message when it supports redelivery.
+type Handler func(ctx context.Context, msg *Message) error
+
+var (
+	// ErrNoReply is returned by Message.Respond when the message does not expect a reply.
+	ErrNoReply = errors.New("broker: message has no reply address")
+
+	// ErrRequestNotSupported is returned by Request on brokers without request/reply semantics.
+	ErrRequestNotSupported = errors.New("broker: request/reply is not supported by this broker")
+)
+
+// Message is a broker-agnostic consumed message.
+type Message struct {
+	Topic   string
+	Body    []byte
+	Headers map[string]string
+
+	respond func(data any) error
+}
+
+// NewMessage creates a Message; respond may be nil when replies are not supported.
+func NewMessage(topic string, body []byte, headers map[string]string, respond func(data any) error) *Message {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	return &Message{
+		Topic:   topic,
+		Body:    body,
+		Headers: headers,
+		respond: respond,
+	}
+}
+
+// Bind decodes the JSON message body into v.
+func (m *Message) Bind(v any) error {
+	return json.Unmarshal(m.Body, v)
+}
+
+// Respond replies to a message sent with Request.
+func (m *Message) Respond(data any) error {
+	if m.respond == nil {
+		return ErrNoReply
+	}
+
+	return m.respond(data)
+}
+
+// DeadLetter is a message parked in a dead-letter queue.
+type DeadLetter struct {
+	ID      string            `json:"id"`
+	Topic   string            `json:"topic"`  // original topic / routing key
+	Source  string            `json:"source"` // original exchange or queue
+	Queue   string            `json:"queue"`  // queue the message was rejected from
+	Reason  string            `json:"reason"`
+	Count   int64             `json:"count"`
+	DeadAt  time.Time         `json:"dead_at"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// DeadLetterManager is implemented by brokers supporting dead-letter inspection.
+type DeadLetterManager interface {
+	// DeadLetters lists up to limit messages of the dead-letter queue without consuming them.
+	DeadLetters(ctx context.Context, queue string, limit int) ([]DeadLetter, error)
+	// Requeue republishes the selected messages to their original topic and
+	// removes them from the dead-letter queue, returning how many were requeued.
+	Requeue(ctx context.Context, queue string, ids ...string) (int, error)
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/logistics-id/engine/broker"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+func init() {
+	broker.Register("kafka", func(cfg *broker.Config, logger *zap.Logger) (broker.Broker, error) {
+		c, err := NewClient(ConfigDefault(cfg.Prefix), logger.With(zap.String("component", "broker.kafka")))
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Broker(), nil
+	})
+}
+
+// Broker exposes the client through the broker-agnostic broker.Broker interface.
+func (c *Client) Broker() broker.Broker {
+	return &brokerAdapter{client: c}
+}
+
+type brokerAdapter struct {
+	client *Client
+}
+
+func (b *brokerAdapter) Publish(ctx context.Context, topic string, data any) error {
+	return b.client.Publish(ctx, topic, data)
+}
+
+func (b *brokerAdapter) Subscribe(topic string, handler broker.Handler) error {
+	return b.client.Subscribe(topic, func(ctx context.Context, record *kgo.Record) error {
+		headers := make(map[string]string, len(record.Headers))
+		for _, h := range record.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+
+		return handler(ctx, broker.NewMessage(record.Topic, record.Value, headers, nil))
+	})
+}
+
+// Request is not supported, Kafka has no request/reply semantics.
+func (b *brokerAdapter) Request(ctx context.Context, topic string, req any, resp any) error {
+	return broker.ErrRequestNotSupported
+}
+
+func (b *brokerAdapter) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,365 @@
+// Package kafka provides a Kafka client for publishing and consuming messages
+// with idempotent batched producing and consumer groups.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"go.uber.org/zap"
+)
+
+// Config defines Kafka connection, producer and consumer settings
+type Config struct {
+	Brokers    []string
+	Username   string
+	Password   string
+	Prefix     string        // service namespace, used as consumer group prefix
+	Linger     time.Duration // how long the producer waits to fill a batch
+	BatchBytes int32         // max bytes of a produced batch, 0 uses the client default
+	DeadLetter string        // optional topic receiving records whose handler failed
+}
+
+// Handler processes a consumed record.
+type Handler func(ctx context.Context, record *kgo.Record) error
+
+// Typed wraps a handler receiving the JSON-decoded record value.
+//
+//	client.Subscribe("order.created", kafka.Typed(func(ctx context.Context, o Order, r *kgo.Record) error {
+//	    return nil
+//	}))
+func Typed[T any](fn func(ctx context.Context, msg T, record *kgo.Record) error) Handler {
+	return func(ctx context.Context, record *kgo.Record) error {
+		var msg T
+		if err := json.Unmarshal(record.Value, &msg); err != nil {
+			return fmt.Errorf("KFK/SUB: json unmarshal failed %w", err)
+		}
+
+		return fn(ctx, msg, record)
+	}
+}
+
+// Client wraps the Kafka producer and the consumer group members
+type Client struct {
+	producer  *kgo.Client
+	consumers []*kgo.Client
+	config    *Config
+	logger    *zap.Logger
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewClient initializes the producer and checks the cluster is reachable
+func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
+	logger = logger.With(zap.Strings("brokers", cfg.Brokers))
+
+	producer, err := kgo.NewClient(cfg.options(
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.ProducerLinger(cfg.Linger),
+	)...)
+	if err != nil {
+		logger.Error("KFK/CONN FAILED", zap.Error(err))
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := producer.Ping(ctx); err != nil {
+		producer.Close()
+		logger.Error("KFK/CONN FAILED", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("KFK/CONN CONNECTED")
+
+	c := &Client{
+		producer: producer,
+		config:   cfg,
+		logger:   logger,
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	return c, nil
+}
+
+// options builds the shared client options with any extra ones appended.
+func (cfg *Config) options(extra ...kgo.Opt) []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ClientID(cfg.Prefix),
+	}
+
+	if cfg.Username != "" {
+		opts = append(opts, kgo.SASL(plain.Auth{User: cfg.Username, Pass: cfg.Password}.AsMechanism()))
+	}
+
+	if cfg.BatchBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(cfg.BatchBytes))
+	}
+
+	return append(opts, extra...)
+}
+
+// Publish sends a JSON-encoded message to the topic and waits for it to be acknowledged
+func (c *Client) Publish(ctx context.Context, topic string, data any) error {
+	return c.PublishWithKey(ctx, topic, "", data)
+}
+
+// PublishWithKey sends a JSON-encoded message with a partition key, keeping
+// records of the same key ordered
+func (c *Client) PublishWithKey(ctx context.Context, topic string, key string, data any) error {
+	record, err := c.newRecord(ctx, topic, key, data)
+	if err != nil {
+		return err
+	}
+
+	return c.produce(ctx, topic, record)
+}
+
+// PublishBatch sends all messages to the topic in a single produce call
+func (c *Client) PublishBatch(ctx context.Context, topic string, data ...any) error {
+	records := make([]*kgo.Record, 0, len(data))
+	for _, d := range data {
+		record, err := c.newRecord(ctx, topic, "", d)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+
+	return c.produce(ctx, topic, records...)
+}
+
+func (c *Client) newRecord(ctx context.Context, topic string, key string, data any) (*kgo.Record, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("KFK/PUB: marshal error %w", err)
+	}
+
+	record := &kgo.Record{Topic: topic, Value: body}
+	if key != "" {
+		record.Key = []byte(key)
+	}
+
+	if requestID := common.GetContextRequestID(ctx); requestID != "" {
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   string(common.ContextRequestIDKey),
+			Value: []byte(requestID),
+		})
+	}
+
+	return record, nil
+}
+
+func (c *Client) produce(ctx context.Context, topic string, records ...*kgo.Record) error {
+	start := time.Now()
+	err := c.producer.ProduceSync(ctx, records...).FirstErr()
+
+	logger := c.logger.With(
+		zap.String("action", "publish"),
+		zap.String("topic", topic),
+		zap.String("request_id", common.GetContextRequestID(ctx)),
+		zap.Int("records", len(records)),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if err != nil {
+		logger.Error("KFK/PUB FAILED", zap.Error(err))
+		return err
+	}
+
+	logger.Info("KFK/PUB SUCCEED")
+	return nil
+}
+
+// Subscribe joins the consumer group "<prefix>.<topic>" and runs the handler for each record.
+// Offsets are committed only for records whose handler succeeded or that were
+// forwarded to Config.DeadLetter when set. Otherwise the partition is rewound to the
+// failed record, which is redelivered after a pause, the records after it waiting.
+func (c *Client) Subscribe(topic string, handler Handler) error {
+	group := fmt.Sprintf("%s.%s", c.config.Prefix, topic)
+
+	consumer, err := kgo.NewClient(c.config.options(
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.AutoCommitMarks(),
+	)...)
+	if err != nil {
+		c.logger.Error("KFK/SUB FAILED", zap.String("topic", topic), zap.Error(err))
+		return err
+	}
+
+	c.mu.Lock()
+	c.consumers = append(c.consumers, consumer)
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go c.runConsumer(consumer, topic, group, handler)
+
+	return nil
+}
+
+func (c *Client) runConsumer(consumer *kgo.Client, topic, group string, handler Handler) {
+	defer c.wg.Done()
+
+	logger := c.logger.With(
+		zap.String("action", "subscribe"),
+		zap.String("topic", topic),
+		zap.String("group", group),
+	)
+	logger.Info("KFK/SUBS STARTED")
+
+	for {
+		fetches := consumer.PollFetches(c.ctx)
+		if fetches.IsClientClosed() || c.ctx.Err() != nil {
+			logger.Debug("KFK/SUB: shutting down consumer")
+			return
+		}
+
+		fetches.EachError(func(t string, p int32, err error) {
+			logger.Error("KFK/SUB: fetch failed", zap.Int32("partition", p), zap.Error(err))
+		})
+
+		rewind := map[string]map[int32]kgo.EpochOffset{}
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			failed := c.handleRecords(p.Records, handler, consumer.MarkCommitRecords, logger)
+			if failed == nil {
+				return
+			}
+
+			if rewind[p.Topic] == nil {
+				rewind[p.Topic] = map[int32]kgo.EpochOffset{}
+			}
+			rewind[p.Topic][p.Partition] = kgo.EpochOffset{Epoch: failed.LeaderEpoch, Offset: failed.Offset}
+		})
+
+		if len(rewind) > 0 {
+			// refetch the failed records after a pause rather than hammering a failing handler
+			consumer.SetOffsets(rewind)
+			select {
+			case <-c.ctx.Done():
+			case <-time.After(redeliveryDelay):
+			}
+		}
+	}
+}
+
+// redeliveryDelay is how long a consumer waits before refetching a record whose
+// handler failed and that could not be dead-lettered.
+const redeliveryDelay = time.Second
+
+// handleRecords runs handler on the records of one partition in order, marking
+// each record that may be committed: its handler succeeded or it was forwarded to
+// the dead-letter topic. It stops at the first record that may not be committed,
+// whose offset would otherwise be passed by those after it, and returns it for the
+// partition to be rewound to; nil when every record was marked.
+func (c *Client) handleRecords(records []*kgo.Record, handler Handler, mark func(...*kgo.Record), logger *zap.Logger) *kgo.Record {
+	for _, record := range records {
+		start := time.Now()
+
+		ctx := c.ctx
+		requestID := recordHeader(record, string(common.ContextRequestIDKey))
+		if requestID != "" {
+			ctx = context.WithValue(ctx, common.ContextRequestIDKey, requestID)
+		}
+
+		log := logger.With(
+			zap.String("request_id", requestID),
+			zap.Int32("partition", record.Partition),
+			zap.Int64("offset", record.Offset),
+			zap.Any("payload", json.RawMessage(record.Value)),
+		)
+
+		if err := handler(ctx, record); err != nil {
+			log.Error("KFK/SUB: handler returned error", zap.Duration("duration", time.Since(start)), zap.Error(err))
+			if !c.deadLetter(ctx, record, err) {
+				log.Warn("KFK/SUB: record left uncommitted for redelivery")
+				return record
+			}
+		} else {
+			log.Info("KFK/SUB SUCCEED", zap.Duration("duration", time.Since(start)))
+		}
+
+		mark(record)
+	}
+	return nil
+}
+
+// deadLetter forwards a failed record to the dead-letter topic with a copy of its
+// headers. It reports whether the record was forwarded, false when no topic is
+// configured.
+func (c *Client) deadLetter(ctx context.Context, record *kgo.Record, cause error) bool {
+	if c.config.DeadLetter == "" {
+		return false
+	}
+
+	dl := &kgo.Record{
+		Topic:   c.config.DeadLetter,
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: append(slices.Clone(record.Headers), kgo.RecordHeader{Key: "x-error", Value: []byte(cause.Error())}),
+	}
+
+	if err := c.producer.ProduceSync(ctx, dl).FirstErr(); err != nil {
+		c.logger.Error("KFK/DLQ FAILED", zap.String("topic", record.Topic), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// Close stops the consumers, commits marked offsets and flushes the producer
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var errs []error
+
+	c.mu.Lock()
+	for _, consumer := range c.consumers {
+		if err := consumer.CommitMarkedOffsets(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		consumer.Close()
+	}
+	c.consumers = nil
+	c.mu.Unlock()
+
+	if err := c.producer.Flush(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	c.producer.Close()
+
+	c.logger.Debug("KFK/CLOSED")
+	return errors.Join(errs...)
+}
+
+// GetProducer returns the underlying franz-go client used for producing.
+func (c *Client) GetProducer() *kgo.Client {
+	return c.producer
+}
+
+func recordHeader(record *kgo.Record, key string) string {
+	for _, h := range record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+func offsets(records []*kgo.Record) []int64 {
+	var out []int64
+	for _, r := range records {
+		out = append(out, r.Offset)
+	}
+	return out
+}
+
+func TestClient_HandleRecordsFailedWithoutDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{config: &Config{}, logger: zap.NewNop(), ctx: context.Background()}
+	records := []*kgo.Record{{Offset: 10}, {Offset: 11}, {Offset: 12}}
+
+	var handled, marked []*kgo.Record
+	failed := c.handleRecords(records, func(ctx context.Context, r *kgo.Record) error {
+		handled = append(handled, r)
+		if r.Offset == 11 {
+			return errors.New("boom")
+		}
+		return nil
+	}, func(rs ...*kgo.Record) {
+		marked = append(marked, rs...)
+	}, zap.NewNop())
+
+	if assert.NotNil(t, failed) {
+		assert.Equal(t, int64(11), failed.Offset, "the failed record is redelivered")
+	}
+	assert.Equal(t, []int64{10}, offsets(marked), "only the records before it are committed")
+	assert.Equal(t, []int64{10, 11}, offsets(handled), "the records after it wait")
+}
+
+func TestClient_HandleRecordsSucceeded(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{config: &Config{}, logger: zap.NewNop(), ctx: context.Background()}
+	records := []*kgo.Record{{Offset: 1}, {Offset: 2}}
+
+	var marked []*kgo.Record
+	failed := c.handleRecords(records, func(context.Context, *kgo.Record) error {
+		return nil
+	}, func(rs ...*kgo.Record) {
+		marked = append(marked, rs...)
+	}, zap.NewNop())
+
+	assert.Nil(t, failed)
+	assert.Equal(t, []int64{1, 2}, offsets(marked))
+}
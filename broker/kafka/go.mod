@@ -0,0 +1,23 @@
+module github.com/logistics-id/engine/broker/kafka
+
+go 1.24.3
+
+require (
+	github.com/logistics-id/engine/broker v0.0.20-dev
+	github.com/logistics-id/engine/common v0.0.20-dev
+	github.com/stretchr/testify v1.10.0
+	github.com/twmb/franz-go v1.19.5
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/twmb/franz-go/pkg/kmsg v1.11.2 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// This file provides a high-level wrapper around a default Kafka client,
+// mirroring the rabbitmq and nats packages.
+//
+// Environment Variables Required:
+//   - KAFKA_BROKERS: comma separated list of seed brokers
+//   - KAFKA_AUTH_USERNAME: SASL/PLAIN username (optional)
+//   - KAFKA_AUTH_PASSWORD: SASL/PLAIN password (optional)
+
+var (
+	defaultClient           *Client
+	ErrClientNotInitialized = errors.New("kafka client not initialized; call NewConnection first")
+)
+
+// ConfigDefault creates a Config struct using environment variables.
+// Make sure to load the environment variables before calling this function.
+// The prefix parameter is used as the consumer group namespace.
+func ConfigDefault(prefix string) *Config {
+	var brokers []string
+	for _, b := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+
+	return &Config{
+		Brokers:  brokers,
+		Username: os.Getenv("KAFKA_AUTH_USERNAME"),
+		Password: os.Getenv("KAFKA_AUTH_PASSWORD"),
+		Prefix:   prefix,
+		Linger:   5 * time.Millisecond,
+	}
+}
+
+// NewConnection initializes the default Kafka client using the provided config and logger.
+// It must be called before using Publish or Subscribe.
+func NewConnection(cfg *Config, logger *zap.Logger) error {
+	c, err := NewClient(cfg, logger.With(zap.String("component", "broker.kafka")))
+	if err == nil {
+		defaultClient = c
+	}
+
+	return err
+}
+
+// Publish sends data to the topic using the default client.
+func Publish(ctx context.Context, topic string, data any) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Publish(ctx, topic, data)
+}
+
+// PublishWithKey sends keyed data to the topic using the default client.
+func PublishWithKey(ctx context.Context, topic string, key string, data any) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.PublishWithKey(ctx, topic, key, data)
+}
+
+// Subscribe registers a handler for the topic using the default client.
+func Subscribe(topic string, handler Handler) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Subscribe(topic, handler)
+}
+
+// CloseConnection gracefully closes the default Kafka client.
+func CloseConnection() error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Close()
+}
+
+func GetClient() *Client {
+	return defaultClient
+}
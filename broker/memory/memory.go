@@ -0,0 +1,199 @@
+// Package memory provides an in-process broker.Broker for unit tests.
+// Published messages are recorded so tests can assert what was emitted
+// without running a real broker.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/logistics-id/engine/broker"
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+)
+
+// ErrNoSubscriber is returned by Request when no handler is subscribed to the topic.
+var ErrNoSubscriber = errors.New("memory: no subscriber for topic")
+
+func init() {
+	broker.Register("memory", func(cfg *broker.Config, logger *zap.Logger) (broker.Broker, error) {
+		return New(), nil
+	})
+}
+
+// Broker is an in-memory broker.Broker implementation.
+type Broker struct {
+	async     bool
+	handlers  map[string][]broker.Handler
+	published []*broker.Message
+	errs      []error
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+// New creates a broker delivering messages synchronously: Publish returns
+// after every subscribed handler has run.
+func New() *Broker {
+	return &Broker{handlers: map[string][]broker.Handler{}}
+}
+
+// NewAsync creates a broker delivering each message on its own goroutine.
+// Use Wait to block until in-flight deliveries are done.
+func NewAsync() *Broker {
+	b := New()
+	b.async = true
+
+	return b
+}
+
+// Publish records the message and delivers it to the topic subscribers.
+// Handler errors do not fail the publish; they are available through Errors.
+func (b *Broker) Publish(ctx context.Context, topic string, data any) error {
+	msg, err := newMessage(ctx, topic, data, nil)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.published = append(b.published, msg)
+	handlers := append([]broker.Handler(nil), b.handlers[topic]...)
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		if b.async {
+			b.wg.Add(1)
+			go func(h broker.Handler) {
+				defer b.wg.Done()
+				b.deliver(h, msg)
+			}(h)
+			continue
+		}
+
+		b.deliver(h, msg)
+	}
+
+	return nil
+}
+
+func (b *Broker) deliver(h broker.Handler, msg *broker.Message) {
+	ctx := context.Background()
+	if reqID := msg.Headers[string(common.ContextRequestIDKey)]; reqID != "" {
+		ctx = context.WithValue(ctx, common.ContextRequestIDKey, reqID)
+	}
+
+	if err := h(ctx, msg); err != nil {
+		b.mu.Lock()
+		b.errs = append(b.errs, err)
+		b.mu.Unlock()
+	}
+}
+
+// Subscribe registers the handler for the topic.
+func (b *Broker) Subscribe(topic string, handler broker.Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[topic] = append(b.handlers[topic], handler)
+	return nil
+}
+
+// Request calls the first subscriber of the topic synchronously and decodes its reply into resp.
+func (b *Broker) Request(ctx context.Context, topic string, req any, resp any) error {
+	b.mu.Lock()
+	handlers := b.handlers[topic]
+	b.mu.Unlock()
+
+	if len(handlers) == 0 {
+		return ErrNoSubscriber
+	}
+
+	var reply []byte
+	msg, err := newMessage(ctx, topic, req, func(data any) (err error) {
+		reply, err = json.Marshal(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.published = append(b.published, msg)
+	b.mu.Unlock()
+
+	if err := handlers[0](ctx, msg); err != nil {
+		return err
+	}
+	if reply == nil {
+		return broker.ErrNoReply
+	}
+
+	return json.Unmarshal(reply, resp)
+}
+
+// Close waits for in-flight asynchronous deliveries.
+func (b *Broker) Close() error {
+	b.Wait()
+	return nil
+}
+
+// Wait blocks until all asynchronous deliveries have finished.
+func (b *Broker) Wait() {
+	b.wg.Wait()
+}
+
+// Published returns every message published so far, in publish order.
+func (b *Broker) Published() []*broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*broker.Message(nil), b.published...)
+}
+
+// PublishedTo returns the messages published to the topic, in publish order.
+func (b *Broker) PublishedTo(topic string) []*broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var msgs []*broker.Message
+	for _, m := range b.published {
+		if m.Topic == topic {
+			msgs = append(msgs, m)
+		}
+	}
+
+	return msgs
+}
+
+// Errors returns the errors returned by subscriber handlers.
+func (b *Broker) Errors() []error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]error(nil), b.errs...)
+}
+
+// Reset clears the recorded messages and errors, keeping subscriptions.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.published = nil
+	b.errs = nil
+}
+
+func newMessage(ctx context.Context, topic string, data any, respond func(any) error) (*broker.Message, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	if reqID := common.GetContextRequestID(ctx); reqID != "" {
+		headers[string(common.ContextRequestIDKey)] = reqID
+	}
+
+	return broker.NewMessage(topic, body, headers, respond), nil
+}
@@ -0,0 +1,95 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/logistics-id/engine/broker"
+	"github.com/logistics-id/engine/broker/memory"
+	"github.com/logistics-id/engine/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	ID string `json:"id"`
+}
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	b := memory.New()
+
+	var got []event
+	var reqID string
+	assert.NoError(t, b.Subscribe("user.created", func(ctx context.Context, msg *broker.Message) error {
+		var e event
+		if err := msg.Bind(&e); err != nil {
+			return err
+		}
+		got = append(got, e)
+		reqID = common.GetContextRequestID(ctx)
+		return nil
+	}))
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDKey, "req-1")
+	assert.NoError(t, b.Publish(ctx, "user.created", event{ID: "u-1"}))
+	assert.NoError(t, b.Publish(ctx, "user.deleted", event{ID: "u-2"}))
+
+	assert.Equal(t, []event{{ID: "u-1"}}, got)
+	assert.Equal(t, "req-1", reqID)
+	assert.Len(t, b.Published(), 2)
+	assert.Len(t, b.PublishedTo("user.created"), 1)
+	assert.JSONEq(t, `{"id":"u-2"}`, string(b.PublishedTo("user.deleted")[0].Body))
+
+	b.Reset()
+	assert.Empty(t, b.Published())
+}
+
+func TestBroker_Async(t *testing.T) {
+	t.Parallel()
+
+	b := memory.NewAsync()
+
+	done := make(chan struct{}, 3)
+	assert.NoError(t, b.Subscribe("tick", func(ctx context.Context, msg *broker.Message) error {
+		done <- struct{}{}
+		return errors.New("boom")
+	}))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Publish(context.Background(), "tick", i))
+	}
+	b.Wait()
+
+	assert.Len(t, done, 3)
+	assert.Len(t, b.Errors(), 3)
+}
+
+func TestBroker_Request(t *testing.T) {
+	t.Parallel()
+
+	b := memory.New()
+
+	var resp event
+	assert.ErrorIs(t, b.Request(context.Background(), "user.get", event{ID: "u-1"}, &resp), memory.ErrNoSubscriber)
+
+	assert.NoError(t, b.Subscribe("user.get", func(ctx context.Context, msg *broker.Message) error {
+		var req event
+		if err := msg.Bind(&req); err != nil {
+			return err
+		}
+		return msg.Respond(event{ID: req.ID + "-reply"})
+	}))
+
+	assert.NoError(t, b.Request(context.Background(), "user.get", event{ID: "u-1"}, &resp))
+	assert.Equal(t, "u-1-reply", resp.ID)
+}
+
+func TestBroker_Driver(t *testing.T) {
+	t.Parallel()
+
+	b, err := broker.Open(&broker.Config{Driver: "memory"}, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &memory.Broker{}, b)
+}
@@ -0,0 +1,128 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/logistics-id/engine/broker"
+	"github.com/logistics-id/engine/common"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+func init() {
+	broker.Register("nats", func(cfg *broker.Config, logger *zap.Logger) (broker.Broker, error) {
+		c, err := NewClient(ConfigDefault(cfg.Prefix).compile(), logger.With(zap.String("component", "broker.nats")))
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Broker(), nil
+	})
+}
+
+// Broker exposes the client through the broker-agnostic broker.Broker interface.
+func (c *Client) Broker() broker.Broker {
+	return &brokerAdapter{client: c}
+}
+
+type brokerAdapter struct {
+	client *Client
+}
+
+// Publish sends the message with the request id propagated as a header.
+func (b *brokerAdapter) Publish(ctx context.Context, topic string, data any) error {
+	msg, err := b.client.newMsg(ctx, topic, data)
+	if err != nil {
+		return err
+	}
+
+	if err := b.client.conn.PublishMsg(msg); err != nil {
+		b.client.logger.Error("Failed to publish message", zap.String("subject", msg.Subject), zap.Error(err))
+		return err
+	}
+
+	b.client.logger.Debug("Published message", zap.String("subject", msg.Subject))
+	return nil
+}
+
+// Subscribe sets up a queue subscriber on the prefixed subject.
+// NATS core has no redelivery, so handler errors are only logged.
+func (b *brokerAdapter) Subscribe(topic string, handler broker.Handler) error {
+	c := b.client
+	fullSubject := fmt.Sprintf("%s.%s", c.config.Prefix, topic)
+
+	_, err := c.conn.QueueSubscribe(fullSubject, c.config.Prefix, func(msg *nats.Msg) {
+		headers := map[string]string{}
+		for k := range msg.Header {
+			headers[k] = msg.Header.Get(k)
+		}
+
+		ctx := context.Background()
+		if reqID := headers[string(common.ContextRequestIDKey)]; reqID != "" {
+			ctx = context.WithValue(ctx, common.ContextRequestIDKey, reqID)
+		}
+
+		var respond func(any) error
+		if msg.Reply != "" {
+			respond = func(data any) error {
+				body, err := json.Marshal(data)
+				if err != nil {
+					return err
+				}
+
+				return msg.Respond(body)
+			}
+		}
+
+		if err := handler(ctx, broker.NewMessage(msg.Subject, msg.Data, headers, respond)); err != nil {
+			c.logger.Error("Handler failed",
+				zap.String("subject", msg.Subject),
+				zap.String("request_id", common.GetContextRequestID(ctx)),
+				zap.Error(err),
+			)
+		}
+	})
+	if err != nil {
+		c.logger.Error("Failed to subscribe to subject", zap.String("subject", fullSubject), zap.Error(err))
+	}
+
+	return err
+}
+
+// Request sends a request bounded by the context deadline and decodes the reply into resp.
+func (b *brokerAdapter) Request(ctx context.Context, topic string, req any, resp any) error {
+	msg, err := b.client.newMsg(ctx, topic, req)
+	if err != nil {
+		return err
+	}
+
+	reply, err := b.client.conn.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		b.client.logger.Error("NATS request failed", zap.String("subject", msg.Subject), zap.Error(err))
+		return err
+	}
+
+	return json.Unmarshal(reply.Data, resp)
+}
+
+func (b *brokerAdapter) Close() error {
+	return b.client.Close()
+}
+
+// newMsg builds a JSON message for the prefixed subject carrying the request id header.
+func (c *Client) newMsg(ctx context.Context, subject string, payload any) (*nats.Msg, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(fmt.Sprintf("%s.%s", c.config.Prefix, subject))
+	msg.Data = data
+	if reqID := common.GetContextRequestID(ctx); reqID != "" {
+		msg.Header.Set(string(common.ContextRequestIDKey), reqID)
+	}
+
+	return msg, nil
+}
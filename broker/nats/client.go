@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -15,6 +16,10 @@ type Client struct {
 	conn   *nats.Conn
 	logger *zap.Logger
 	config *Config
+
+	js    nats.JetStreamContext
+	pulls map[string]*nats.Subscription
+	mu    sync.Mutex
 }
 
 // NewClient initializes a NATS client with the given config and logger.
@@ -32,6 +37,7 @@ func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
 		conn:   nc,
 		logger: logger,
 		config: cfg,
+		pulls:  map[string]*nats.Subscription{},
 	}, nil
 }
 
@@ -3,15 +3,17 @@ module github.com/logistics-id/engine/broker/nats
 go 1.24.3
 
 require (
+	github.com/logistics-id/engine/broker v0.0.20-dev
+	github.com/logistics-id/engine/common v0.0.20-dev
 	github.com/nats-io/nats.go v1.43.0
 	go.uber.org/zap v1.27.0
 )
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
 	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/nats-io/nkeys v0.4.11 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
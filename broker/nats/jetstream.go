@@ -0,0 +1,113 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// BatchHandler processes a single message pulled from JetStream.
+// The handler may call msg.Ack, msg.Nak, msg.Term or msg.InProgress explicitly.
+// If it does not, the message is acked when the handler returns nil
+// and nak'ed (redelivered) when it returns an error.
+type BatchHandler func(ctx context.Context, msg *nats.Msg) error
+
+// jetStream lazily creates the JetStream context for the connection.
+func (c *Client) jetStream() (nats.JetStreamContext, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.js != nil {
+		return c.js, nil
+	}
+
+	js, err := c.conn.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	c.js = js
+	return js, nil
+}
+
+// pullSubscription returns the durable pull subscription for the subject,
+// creating it on first use. The subject must be covered by an existing stream.
+func (c *Client) pullSubscription(fullSubject string) (*nats.Subscription, error) {
+	js, err := c.jetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if sub, ok := c.pulls[fullSubject]; ok && sub.IsValid() {
+		return sub, nil
+	}
+
+	sub, err := js.PullSubscribe(fullSubject, durableName(fullSubject), nats.ManualAck())
+	if err != nil {
+		return nil, err
+	}
+
+	c.pulls[fullSubject] = sub
+	return sub, nil
+}
+
+// FetchBatch pulls up to n messages for the subject from JetStream and runs
+// the handler for each of them sequentially. It waits at most Config.FetchTimeout
+// for messages to arrive; an empty batch is not an error.
+func (c *Client) FetchBatch(subject string, n int, handler BatchHandler) error {
+	fullSubject := fmt.Sprintf("%s.%s", c.config.Prefix, subject)
+
+	logger := c.logger.With(
+		zap.String("action", "fetch"),
+		zap.String("subject", fullSubject),
+		zap.Int("batch", n),
+	)
+
+	sub, err := c.pullSubscription(fullSubject)
+	if err != nil {
+		logger.Error("NATS/FETCH SUBSCRIBE FAILED", zap.Error(err))
+		return err
+	}
+
+	msgs, err := sub.Fetch(n, nats.MaxWait(c.config.fetchTimeout()))
+	if err != nil && !errors.Is(err, nats.ErrTimeout) {
+		logger.Error("NATS/FETCH FAILED", zap.Error(err))
+		return err
+	}
+
+	for _, msg := range msgs {
+		start := time.Now()
+		ctx := context.Background()
+
+		log := logger.With(zap.ByteString("payload", msg.Data))
+
+		if err := handler(ctx, msg); err != nil {
+			if nerr := msg.Nak(); nerr != nil && !errors.Is(nerr, nats.ErrMsgAlreadyAckd) {
+				log.Warn("NATS/FETCH NAK FAILED", zap.Error(nerr))
+			}
+			log.Error("NATS/FETCH HANDLER FAILED", zap.Duration("duration", time.Since(start)), zap.Error(err))
+			continue
+		}
+
+		if aerr := msg.Ack(); aerr != nil && !errors.Is(aerr, nats.ErrMsgAlreadyAckd) {
+			log.Warn("NATS/FETCH ACK FAILED", zap.Error(aerr))
+		}
+		log.Info("NATS/FETCH SUCCEED", zap.Duration("duration", time.Since(start)))
+	}
+
+	return nil
+}
+
+// durableName derives a consumer name from the subject; JetStream does not
+// allow '.', '*' or '>' in durable names.
+func durableName(subject string) string {
+	return strings.NewReplacer(".", "_", "*", "any", ">", "all").Replace(subject)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -17,11 +18,12 @@ var (
 
 // Config contains the NATS connection parameters.
 type Config struct {
-	Server     string
-	Username   string
-	Password   string
-	Prefix     string
-	datasource string
+	Server       string
+	Username     string
+	Password     string
+	Prefix       string
+	FetchTimeout time.Duration // max wait for a pull batch, defaults to 5s
+	datasource   string
 }
 
 func (c *Config) compile() *Config {
@@ -30,6 +32,14 @@ func (c *Config) compile() *Config {
 	return c
 }
 
+func (c *Config) fetchTimeout() time.Duration {
+	if c.FetchTimeout <= 0 {
+		return 5 * time.Second
+	}
+
+	return c.FetchTimeout
+}
+
 // NewConnection creates the default singleton NATS client for package-level functions.
 // Must be called before using Publish/Subscribe functions.
 // example
@@ -78,6 +88,15 @@ func Subscribe(subject string, handler func(ctx context.Context, msg any)) error
 	return defaultClient.Subscribe(subject, handler)
 }
 
+// FetchBatch pulls a batch of JetStream messages using the default client.
+func FetchBatch(subject string, n int, handler BatchHandler) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.FetchBatch(subject, n, handler)
+}
+
 // CloseConnection closes the default client connection.
 func CloseConnection() error {
 	if defaultClient == nil {
@@ -86,3 +105,8 @@ func CloseConnection() error {
 
 	return defaultClient.Close()
 }
+
+// GetClient returns the default client instance.
+func GetClient() *Client {
+	return defaultClient
+}
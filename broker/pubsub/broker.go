@@ -0,0 +1,48 @@
+package pubsub
+
+import (
+	"context"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/logistics-id/engine/broker"
+	"go.uber.org/zap"
+)
+
+func init() {
+	broker.Register("pubsub", func(cfg *broker.Config, logger *zap.Logger) (broker.Broker, error) {
+		c, err := NewClient(ConfigDefault(cfg.Prefix), logger.With(zap.String("component", "broker.pubsub")))
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Broker(), nil
+	})
+}
+
+// Broker exposes the client through the broker-agnostic broker.Broker interface.
+func (c *Client) Broker() broker.Broker {
+	return &brokerAdapter{client: c}
+}
+
+type brokerAdapter struct {
+	client *Client
+}
+
+func (b *brokerAdapter) Publish(ctx context.Context, topic string, data any) error {
+	return b.client.Publish(ctx, topic, data)
+}
+
+func (b *brokerAdapter) Subscribe(topic string, handler broker.Handler) error {
+	return b.client.Subscribe(topic, func(ctx context.Context, msg *pubsub.Message) error {
+		return handler(ctx, broker.NewMessage(topic, msg.Data, msg.Attributes, nil))
+	})
+}
+
+// Request is not supported, Pub/Sub has no request/reply semantics.
+func (b *brokerAdapter) Request(ctx context.Context, topic string, req any, resp any) error {
+	return broker.ErrRequestNotSupported
+}
+
+func (b *brokerAdapter) Close() error {
+	return b.client.Close()
+}
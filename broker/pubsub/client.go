@@ -0,0 +1,224 @@
+// Package pubsub provides a Google Cloud Pub/Sub client for publishing and
+// subscribing to messages with ordering keys and exactly-once delivery.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+)
+
+// Config defines Pub/Sub project and subscriber settings
+type Config struct {
+	ProjectID      string
+	Prefix         string // service namespace, subscriptions are named "<prefix>.<topic>"
+	ExactlyOnce    bool   // wait for ack confirmation, for subscriptions with exactly-once delivery enabled
+	MaxOutstanding int    // max unacked messages per subscription, 0 uses the client default
+}
+
+// Handler processes a received message. Returning nil acks the message,
+// returning an error nacks it for redelivery.
+type Handler func(ctx context.Context, msg *pubsub.Message) error
+
+// Client wraps the Pub/Sub client and its topics
+type Client struct {
+	client *pubsub.Client
+	config *Config
+	logger *zap.Logger
+
+	topics map[string]*pubsub.Topic
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewClient initializes the Pub/Sub client. The emulator is used when
+// PUBSUB_EMULATOR_HOST is set.
+func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
+	logger = logger.With(zap.String("project", cfg.ProjectID))
+
+	client, err := pubsub.NewClient(context.Background(), cfg.ProjectID)
+	if err != nil {
+		logger.Error("PUBSUB/CONN FAILED", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("PUBSUB/CONN CONNECTED")
+
+	c := &Client{
+		client: client,
+		config: cfg,
+		logger: logger,
+		topics: map[string]*pubsub.Topic{},
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	return c, nil
+}
+
+// topic returns the cached topic handle with message ordering enabled.
+func (c *Client) topic(name string) *pubsub.Topic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.topics[name]; ok {
+		return t
+	}
+
+	t := c.client.Topic(name)
+	t.EnableMessageOrdering = true
+	c.topics[name] = t
+
+	return t
+}
+
+// Publish sends a JSON-encoded message to the topic and waits for the server ack
+func (c *Client) Publish(ctx context.Context, topic string, data any) error {
+	return c.PublishWithOrderingKey(ctx, topic, "", data)
+}
+
+// PublishWithOrderingKey sends a JSON-encoded message; messages sharing the
+// ordering key are delivered in publish order to ordered subscriptions.
+func (c *Client) PublishWithOrderingKey(ctx context.Context, topic string, key string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("PUBSUB/PUB: marshal error %w", err)
+	}
+
+	start := time.Now()
+	requestID := common.GetContextRequestID(ctx)
+
+	msg := &pubsub.Message{
+		Data:        body,
+		OrderingKey: key,
+		Attributes:  map[string]string{},
+	}
+	if requestID != "" {
+		msg.Attributes[string(common.ContextRequestIDKey)] = requestID
+	}
+
+	t := c.topic(topic)
+	_, err = t.Publish(ctx, msg).Get(ctx)
+
+	logger := c.logger.With(
+		zap.String("action", "publish"),
+		zap.String("topic", topic),
+		zap.String("ordering_key", key),
+		zap.String("request_id", requestID),
+		zap.Any("payload", json.RawMessage(body)),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if err != nil {
+		// publishing for a key is paused after a failure to keep ordering
+		if key != "" {
+			t.ResumePublish(key)
+		}
+
+		logger.Error("PUBSUB/PUB FAILED", zap.Error(err))
+		return err
+	}
+
+	logger.Info("PUBSUB/PUB SUCCEED")
+	return nil
+}
+
+// Subscribe receives messages from the "<prefix>.<topic>" subscription until the client is closed.
+func (c *Client) Subscribe(topic string, handler Handler) error {
+	name := fmt.Sprintf("%s.%s", c.config.Prefix, topic)
+
+	sub := c.client.Subscription(name)
+	if c.config.MaxOutstanding != 0 {
+		sub.ReceiveSettings.MaxOutstandingMessages = c.config.MaxOutstanding
+	}
+
+	logger := c.logger.With(
+		zap.String("action", "subscribe"),
+		zap.String("topic", topic),
+		zap.String("subscription", name),
+	)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		logger.Info("PUBSUB/SUBS STARTED")
+		err := sub.Receive(c.ctx, func(ctx context.Context, msg *pubsub.Message) {
+			c.process(ctx, msg, handler, logger)
+		})
+		if err != nil {
+			logger.Error("PUBSUB/SUB: receive stopped", zap.Error(err))
+			return
+		}
+
+		logger.Debug("PUBSUB/SUB: shutting down subscriber")
+	}()
+
+	return nil
+}
+
+func (c *Client) process(ctx context.Context, msg *pubsub.Message, handler Handler, logger *zap.Logger) {
+	start := time.Now()
+
+	requestID := msg.Attributes[string(common.ContextRequestIDKey)]
+	if requestID != "" {
+		ctx = context.WithValue(ctx, common.ContextRequestIDKey, requestID)
+	}
+
+	log := logger.With(
+		zap.String("message_id", msg.ID),
+		zap.String("request_id", requestID),
+		zap.Any("payload", json.RawMessage(msg.Data)),
+	)
+
+	err := handler(ctx, msg)
+	log = log.With(zap.Duration("duration", time.Since(start)))
+
+	if err != nil {
+		log.Error("PUBSUB/SUB: handler returned error", zap.Error(err))
+		msg.Nack()
+		return
+	}
+
+	if !c.config.ExactlyOnce {
+		msg.Ack()
+		log.Info("PUBSUB/SUB SUCCEED")
+		return
+	}
+
+	// with exactly-once delivery the ack may fail, in which case the message is redelivered
+	if _, err := msg.AckWithResult().Get(ctx); err != nil {
+		log.Warn("PUBSUB/SUB: ack not confirmed", zap.Error(err))
+		return
+	}
+
+	log.Info("PUBSUB/SUB SUCCEED")
+}
+
+// Close stops the subscribers, flushes pending publishes and closes the client
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+
+	c.mu.Lock()
+	for _, t := range c.topics {
+		t.Stop()
+	}
+	c.mu.Unlock()
+
+	c.logger.Debug("PUBSUB/CLOSED")
+	return c.client.Close()
+}
+
+// GetClient returns the underlying Pub/Sub client.
+func (c *Client) GetClient() *pubsub.Client {
+	return c.client
+}
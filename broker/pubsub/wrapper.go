@@ -0,0 +1,85 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// This file provides a high-level wrapper around a default Pub/Sub client,
+// mirroring the rabbitmq and nats packages. Credentials are resolved through
+// Application Default Credentials.
+//
+// Environment Variables:
+//   - PUBSUB_PROJECT_ID: Google Cloud project id
+//   - PUBSUB_EXACTLY_ONCE: "true" when subscriptions use exactly-once delivery
+//   - PUBSUB_EMULATOR_HOST: optional emulator address
+
+var (
+	defaultClient           *Client
+	ErrClientNotInitialized = errors.New("pubsub client not initialized; call NewConnection first")
+)
+
+// ConfigDefault creates a Config struct using environment variables.
+// Make sure to load the environment variables before calling this function.
+// The prefix parameter is used to name the subscriptions.
+func ConfigDefault(prefix string) *Config {
+	return &Config{
+		ProjectID:   os.Getenv("PUBSUB_PROJECT_ID"),
+		Prefix:      prefix,
+		ExactlyOnce: os.Getenv("PUBSUB_EXACTLY_ONCE") == "true",
+	}
+}
+
+// NewConnection initializes the default client using the provided config and logger.
+// It must be called before using Publish or Subscribe.
+func NewConnection(cfg *Config, logger *zap.Logger) error {
+	c, err := NewClient(cfg, logger.With(zap.String("component", "broker.pubsub")))
+	if err == nil {
+		defaultClient = c
+	}
+
+	return err
+}
+
+// Publish sends data to the topic using the default client.
+func Publish(ctx context.Context, topic string, data any) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Publish(ctx, topic, data)
+}
+
+// PublishWithOrderingKey sends ordered data to the topic using the default client.
+func PublishWithOrderingKey(ctx context.Context, topic string, key string, data any) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.PublishWithOrderingKey(ctx, topic, key, data)
+}
+
+// Subscribe receives the topic subscription using the default client.
+func Subscribe(topic string, handler Handler) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Subscribe(topic, handler)
+}
+
+// CloseConnection gracefully closes the default client.
+func CloseConnection() error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Close()
+}
+
+func GetClient() *Client {
+	return defaultClient
+}
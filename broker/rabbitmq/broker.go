@@ -0,0 +1,179 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/logistics-id/engine/broker"
+	"github.com/logistics-id/engine/common"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// directReplyTo is the RabbitMQ pseudo-queue used for request/reply without declaring queues.
+const directReplyTo = "amq.rabbitmq.reply-to"
+
+func init() {
+	broker.Register("rabbitmq", func(cfg *broker.Config, logger *zap.Logger) (broker.Broker, error) {
+		c, err := NewClient(ConfigDefault(cfg.Prefix), logger.With(zap.String("component", "broker.rabbitmq")))
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Broker(), nil
+	})
+}
+
+// Broker exposes the client through the broker-agnostic broker.Broker interface.
+func (c *Client) Broker() broker.Broker {
+	return &brokerAdapter{client: c}
+}
+
+type brokerAdapter struct {
+	client *Client
+}
+
+func (b *brokerAdapter) Publish(ctx context.Context, topic string, data any) error {
+	return b.client.Publish(ctx, topic, data)
+}
+
+// Subscribe binds the prefixed queue to the topic; messages are acked when the
+// handler succeeds and requeued when it returns an error.
+func (b *brokerAdapter) Subscribe(topic string, handler broker.Handler) error {
+	queue := fmt.Sprintf("%s.%s", b.client.config.Prefix, topic)
+
+	return b.client.Subscribe(queue, topic, func(body json.RawMessage, d amqp.Delivery) error {
+		headers := map[string]string{}
+		for k, v := range d.Headers {
+			headers[k] = fmt.Sprint(v)
+		}
+
+		ctx := context.Background()
+		if reqID := headers[string(common.ContextRequestIDKey)]; reqID != "" {
+			ctx = context.WithValue(ctx, common.ContextRequestIDKey, reqID)
+		}
+
+		var respond func(any) error
+		if d.ReplyTo != "" {
+			respond = func(data any) error {
+				return b.client.reply(ctx, d, data)
+			}
+		}
+
+		if err := handler(ctx, broker.NewMessage(d.RoutingKey, body, headers, respond)); err != nil {
+			return err
+		}
+
+		return d.Ack(false)
+	})
+}
+
+// Request publishes the request with a direct reply-to address and waits for the reply.
+func (b *brokerAdapter) Request(ctx context.Context, topic string, req any, resp any) error {
+	return b.client.Request(ctx, topic, req, resp)
+}
+
+func (b *brokerAdapter) Close() error {
+	return b.client.Close()
+}
+
+// Request sends a request to a topic and waits for the reply using RabbitMQ direct reply-to.
+// The context deadline bounds how long to wait for the reply.
+func (c *Client) Request(ctx context.Context, topic string, req any, resp any) error {
+	logger := c.logger.With(
+		zap.String("action", "request"),
+		zap.String("exchange", c.exchange),
+		zap.String("topic", topic),
+	)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("RMQ/REQ: marshal error %w", err)
+	}
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil || conn.IsClosed() {
+		return amqp.ErrClosed
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		logger.Error("RMQ/REQ CHANNEL FAILED", zap.Error(err))
+		return err
+	}
+	defer ch.Close()
+
+	replies, err := ch.Consume(directReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		logger.Error("RMQ/REQ CONSUME FAILED", zap.Error(err))
+		return err
+	}
+
+	correlationID := common.RandomCode(16, common.RandomCodeAlphaNumeric)
+	requestID := common.GetContextRequestID(ctx)
+	headers := amqp.Table{}
+	if requestID != "" {
+		headers[string(common.ContextRequestIDKey)] = requestID
+	}
+
+	err = ch.PublishWithContext(ctx, c.exchange, topic, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: correlationID,
+		ReplyTo:       directReplyTo,
+		Body:          body,
+		Headers:       headers,
+	})
+	if err != nil {
+		logger.Error("RMQ/REQ FAILED", zap.Error(err))
+		return err
+	}
+
+	for {
+		select {
+		case d, ok := <-replies:
+			if !ok {
+				return amqp.ErrClosed
+			}
+			if d.CorrelationId != correlationID {
+				continue
+			}
+
+			return json.Unmarshal(d.Body, resp)
+		case <-ctx.Done():
+			logger.Warn("RMQ/REQ TIMEOUT", zap.Error(ctx.Err()))
+			return ctx.Err()
+		}
+	}
+}
+
+// reply publishes the response of a request to its reply-to address.
+func (c *Client) reply(ctx context.Context, d amqp.Delivery, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("RMQ/REPLY: marshal error %w", err)
+	}
+
+	c.mu.Lock()
+	ch := c.channel
+	c.mu.Unlock()
+
+	return ch.PublishWithContext(ctx, "", d.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Body:          body,
+	})
+}
+
+// DeadLetters implements broker.DeadLetterManager.
+func (b *brokerAdapter) DeadLetters(ctx context.Context, queue string, limit int) ([]broker.DeadLetter, error) {
+	return b.client.DeadLetters(ctx, queue, limit)
+}
+
+// Requeue implements broker.DeadLetterManager.
+func (b *brokerAdapter) Requeue(ctx context.Context, queue string, ids ...string) (int, error) {
+	return b.client.Requeue(ctx, queue, ids...)
+}
@@ -27,6 +27,10 @@ type Config struct {
 	Durable      bool
 	QueueTTL     time.Duration
 	DeadLetter   string
+
+	// DeadLetterQueue, when set, is declared and bound to the DeadLetter
+	// exchange so rejected messages are parked for inspection and requeue.
+	DeadLetterQueue string
 }
 
 // Client wraps RabbitMQ connection, channel, and subscriber management
@@ -108,6 +112,13 @@ func (c *Client) connect() error {
 		return err
 	}
 
+	if err = c.declareDeadLetter(ch); err != nil {
+		ch.Close()
+		conn.Close()
+		logger.Error("RMQ/CONN DEAD LETTER DECLARE FAILED", zap.Error(err))
+		return err
+	}
+
 	c.mu.Lock()
 	c.conn = conn
 	c.channel = ch
@@ -203,6 +214,8 @@ func (c *Client) Publish(ctx context.Context, topic string, data any) error {
 		false,
 		amqp.Publishing{
 			ContentType: "application/json",
+			MessageId:   common.RandomCode(20, common.RandomCodeAlphaNumeric),
+			Timestamp:   start,
 			Body:        body,
 			Headers:     headers,
 		},
@@ -350,7 +363,9 @@ func (c *Client) runSubscriber(queue string, routingKey string, handler any) {
 				if len(results) == 1 {
 					if err, ok := results[0].Interface().(error); ok && err != nil {
 						log.Error("RMQ/SUB: handler returned error", zap.Error(err))
-						d.Nack(false, true) // requeue on handler error
+						// with a dead-letter exchange the message is parked there instead
+						// of being redelivered forever
+						d.Nack(false, c.config.DeadLetter == "")
 						continue
 					} else {
 						log.Info("RMQ/SUB SUCCEED")
@@ -413,6 +428,17 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// Ping reports whether the connection and publishing channel are open, e.g. for readiness probes.
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil || c.conn.IsClosed() || c.channel == nil || c.channel.IsClosed() {
+		return amqp.ErrClosed
+	}
+	return nil
+}
+
 func (c *Client) GetChannel() *amqp.Channel {
 	return c.channel
 }
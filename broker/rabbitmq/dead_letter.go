@@ -0,0 +1,239 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/logistics-id/engine/broker"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// defaultDeadLetterLimit caps how many messages DeadLetters returns when no limit is given.
+const defaultDeadLetterLimit = 100
+
+// ErrNoDeadLetterQueue is returned when no queue is given and Config.DeadLetterQueue is empty.
+var ErrNoDeadLetterQueue = errors.New("rabbitmq: dead letter queue is not configured")
+
+// declareDeadLetter declares the dead-letter exchange and binds the configured
+// dead-letter queue to every routing key.
+func (c *Client) declareDeadLetter(ch *amqp.Channel) error {
+	if c.config.DeadLetter == "" || c.config.DeadLetterQueue == "" {
+		return nil
+	}
+
+	if err := ch.ExchangeDeclare(c.config.DeadLetter, "topic", c.config.Durable, false, false, false, nil); err != nil {
+		return err
+	}
+
+	if _, err := ch.QueueDeclare(c.config.DeadLetterQueue, c.config.Durable, false, false, false, nil); err != nil {
+		return err
+	}
+
+	return ch.QueueBind(c.config.DeadLetterQueue, "#", c.config.DeadLetter, false, nil)
+}
+
+// DeadLetters peeks up to limit messages parked in the dead-letter queue.
+// Messages are fetched unacknowledged and returned to the queue when the
+// inspection channel closes, so the queue is left untouched.
+func (c *Client) DeadLetters(ctx context.Context, queue string, limit int) ([]broker.DeadLetter, error) {
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+
+	ch, queue, err := c.deadLetterChannel(queue)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	letters := []broker.DeadLetter{}
+	for len(letters) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return nil, fmt.Errorf("RMQ/DLQ: get failed %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		letters = append(letters, deadLetter(d))
+	}
+
+	return letters, nil
+}
+
+// Requeue republishes the selected dead letters to their original exchange and
+// routing key, then removes them from the dead-letter queue. Messages that are
+// not selected are returned to the queue untouched.
+func (c *Client) Requeue(ctx context.Context, queue string, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	ch, queue, err := c.deadLetterChannel(queue)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("RMQ/DLQ: confirm mode failed %w", err)
+	}
+
+	pending := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		pending[id] = true
+	}
+
+	logger := c.logger.With(
+		zap.String("action", "requeue"),
+		zap.String("queue", queue),
+	)
+
+	requeued := 0
+	for len(pending) > 0 {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return requeued, fmt.Errorf("RMQ/DLQ: get failed %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		letter := deadLetter(d)
+		if !pending[letter.ID] {
+			continue
+		}
+
+		if err := republish(ctx, ch, letter, d); err != nil {
+			logger.Error("RMQ/DLQ REQUEUE FAILED", zap.String("message_id", letter.ID), zap.Error(err))
+			return requeued, err
+		}
+
+		if err := d.Ack(false); err != nil {
+			return requeued, fmt.Errorf("RMQ/DLQ: ack failed %w", err)
+		}
+
+		delete(pending, letter.ID)
+		requeued++
+
+		logger.Info("RMQ/DLQ REQUEUED",
+			zap.String("message_id", letter.ID),
+			zap.String("exchange", letter.Source),
+			zap.String("routing_key", letter.Topic),
+		)
+	}
+
+	return requeued, nil
+}
+
+// deadLetterChannel opens a dedicated channel so unacked deliveries are
+// requeued on close without affecting the publishing channel.
+func (c *Client) deadLetterChannel(queue string) (*amqp.Channel, string, error) {
+	if queue == "" {
+		queue = c.config.DeadLetterQueue
+	}
+	if queue == "" {
+		return nil, "", ErrNoDeadLetterQueue
+	}
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil || conn.IsClosed() {
+		return nil, "", amqp.ErrClosed
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, "", fmt.Errorf("RMQ/DLQ: channel failed %w", err)
+	}
+
+	return ch, queue, nil
+}
+
+// republish publishes the delivery back to its original exchange and routing key
+// and waits for the broker confirmation.
+func republish(ctx context.Context, ch *amqp.Channel, letter broker.DeadLetter, d amqp.Delivery) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		if k == "x-death" || strings.HasPrefix(k, "x-first-death-") || strings.HasPrefix(k, "x-last-death-") {
+			continue
+		}
+		headers[k] = v
+	}
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, letter.Source, letter.Topic, false, false, amqp.Publishing{
+		ContentType:   d.ContentType,
+		MessageId:     d.MessageId,
+		CorrelationId: d.CorrelationId,
+		Timestamp:     d.Timestamp,
+		Headers:       headers,
+		Body:          d.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	ok, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("RMQ/DLQ: publish to %s/%s not confirmed", letter.Source, letter.Topic)
+	}
+
+	return nil
+}
+
+// deadLetter converts a delivery into a broker.DeadLetter using its x-death header.
+func deadLetter(d amqp.Delivery) broker.DeadLetter {
+	letter := broker.DeadLetter{
+		ID:      d.MessageId,
+		Topic:   d.RoutingKey,
+		Source:  d.Exchange,
+		Headers: map[string]string{},
+		Body:    json.RawMessage(d.Body),
+	}
+
+	if letter.ID == "" {
+		sum := sha1.Sum(d.Body)
+		letter.ID = hex.EncodeToString(sum[:8])
+	}
+
+	// x-death is ordered by most recent; the first entry describes the last rejection.
+	if deaths, ok := d.Headers["x-death"].([]any); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			letter.Source, _ = death["exchange"].(string)
+			letter.Queue, _ = death["queue"].(string)
+			letter.Reason, _ = death["reason"].(string)
+			letter.Count, _ = death["count"].(int64)
+			letter.DeadAt, _ = death["time"].(time.Time)
+
+			if keys, ok := death["routing-keys"].([]any); ok && len(keys) > 0 {
+				letter.Topic, _ = keys[0].(string)
+			}
+		}
+	}
+
+	for k, v := range d.Headers {
+		if k == "x-death" {
+			continue
+		}
+		letter.Headers[k] = fmt.Sprint(v)
+	}
+
+	return letter
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/logistics-id/engine/broker"
 	"go.uber.org/zap"
 )
 
@@ -25,6 +26,7 @@ import (
 //   - RABBIT_SERVER: RabbitMQ server address
 //   - RABBIT_AUTH_USERNAME: RabbitMQ username
 //   - RABBIT_AUTH_PASSWORD: RabbitMQ password
+//   - RABBIT_DEAD_LETTER_QUEUE: optional queue collecting dead-lettered messages
 //
 // Example:
 //   cfg := rabbitmq.ConfigDefault("myPrefix")
@@ -50,6 +52,7 @@ func ConfigDefault(prefix string) *Config {
 		ExchangeType: "topic",
 		Durable:      true,
 		QueueTTL:     30 * time.Second, DeadLetter: "engine.service.dlx",
+		DeadLetterQueue: os.Getenv("RABBIT_DEAD_LETTER_QUEUE"),
 	}
 	c.Datasource = fmt.Sprintf("amqp://%s:%s@%s/", c.Username, c.Password, c.Server)
 	return c
@@ -78,6 +81,21 @@ func Publish(ctx context.Context, topic string, data any) error {
 	return defaultClient.Publish(ctx, topic, data)
 }
 
+// DeadLetters peeks messages parked in the dead-letter queue using the default client.
+func DeadLetters(ctx context.Context, queue string, limit int) ([]broker.DeadLetter, error) {
+	return defaultClient.DeadLetters(ctx, queue, limit)
+}
+
+// Requeue moves the selected dead letters back to their original topic using the default client.
+func Requeue(ctx context.Context, queue string, ids ...string) (int, error) {
+	return defaultClient.Requeue(ctx, queue, ids...)
+}
+
+// Ping reports whether the default client is connected.
+func Ping(ctx context.Context) error {
+	return defaultClient.Ping(ctx)
+}
+
 // CloseConnection gracefully closes the default RabbitMQ client connection.
 func CloseConnection() error {
 	return defaultClient.Close()
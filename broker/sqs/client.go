@@ -0,0 +1,376 @@
+// Package sqs provides an AWS SNS/SQS client: messages are published to SNS
+// topics and consumed from SQS queues subscribed to them.
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+)
+
+// Config defines AWS and consumer settings
+type Config struct {
+	Region            string
+	AccountID         string        // used to build SNS topic ARNs
+	Endpoint          string        // optional endpoint override, e.g. LocalStack
+	Prefix            string        // service namespace, queues are named "<prefix>-<topic>"
+	WaitTime          time.Duration // long polling wait, max 20s
+	VisibilityTimeout time.Duration // extended periodically while a handler runs
+	MaxMessages       int32         // messages per receive, max 10
+	DeadLetterSuffix  string        // dead-letter queue is named "<queue><suffix>"
+}
+
+// Handler processes a received message body.
+type Handler func(ctx context.Context, msg types.Message, body []byte) error
+
+// Client wraps the SNS publisher and the SQS consumers
+type Client struct {
+	sns    *sns.Client
+	sqs    *sqs.Client
+	config *Config
+	logger *zap.Logger
+
+	queueURLs sync.Map
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// snsEnvelope is the SNS notification wrapper when raw message delivery is disabled.
+type snsEnvelope struct {
+	Type              string `json:"Type"`
+	Message           string `json:"Message"`
+	MessageAttributes map[string]struct {
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// setDefault fills in defaults if not explicitly provided.
+func (c *Config) setDefault() {
+	if c.WaitTime <= 0 || c.WaitTime > 20*time.Second {
+		c.WaitTime = 20 * time.Second
+	}
+	if c.VisibilityTimeout < 2*time.Second {
+		c.VisibilityTimeout = 30 * time.Second
+	}
+	if c.MaxMessages <= 0 || c.MaxMessages > 10 {
+		c.MaxMessages = 10
+	}
+	if c.DeadLetterSuffix == "" {
+		c.DeadLetterSuffix = "-dlq"
+	}
+}
+
+// NewClient loads the AWS configuration and creates the SNS and SQS clients
+func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
+	cfg.setDefault()
+
+	logger = logger.With(zap.String("region", cfg.Region))
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		logger.Error("SQS/CONN FAILED", zap.Error(err))
+		return nil, err
+	}
+
+	c := &Client{
+		sns: sns.NewFromConfig(awsCfg, func(o *sns.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		}),
+		sqs: sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		}),
+		config: cfg,
+		logger: logger,
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	logger.Info("SQS/CONN CONNECTED")
+	return c, nil
+}
+
+// topicARN builds the SNS topic ARN; dots are not allowed in topic names.
+func (c *Client) topicARN(topic string) string {
+	return fmt.Sprintf("arn:aws:sns:%s:%s:%s", c.config.Region, c.config.AccountID, resourceName(topic))
+}
+
+// queueName returns the SQS queue name consumed by this service for the topic.
+func (c *Client) queueName(topic string) string {
+	return resourceName(fmt.Sprintf("%s-%s", c.config.Prefix, topic))
+}
+
+func (c *Client) queueURL(ctx context.Context, name string) (string, error) {
+	if url, ok := c.queueURLs.Load(name); ok {
+		return url.(string), nil
+	}
+
+	out, err := c.sqs.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	if err != nil {
+		return "", err
+	}
+
+	c.queueURLs.Store(name, *out.QueueUrl)
+	return *out.QueueUrl, nil
+}
+
+// Publish sends a JSON-encoded message to the SNS topic
+func (c *Client) Publish(ctx context.Context, topic string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("SQS/PUB: marshal error %w", err)
+	}
+
+	start := time.Now()
+	requestID := common.GetContextRequestID(ctx)
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(c.topicARN(topic)),
+		Message:  aws.String(string(body)),
+	}
+	if requestID != "" {
+		input.MessageAttributes = map[string]snstypes.MessageAttributeValue{
+			string(common.ContextRequestIDKey): {DataType: aws.String("String"), StringValue: aws.String(requestID)},
+		}
+	}
+
+	_, err = c.sns.Publish(ctx, input)
+
+	logger := c.logger.With(
+		zap.String("action", "publish"),
+		zap.String("topic", topic),
+		zap.String("request_id", requestID),
+		zap.Any("payload", json.RawMessage(body)),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if err != nil {
+		logger.Error("SQS/PUB FAILED", zap.Error(err))
+		return err
+	}
+
+	logger.Info("SQS/PUB SUCCEED")
+	return nil
+}
+
+// Send sends a JSON-encoded message directly to an SQS queue, bypassing SNS
+func (c *Client) Send(ctx context.Context, queue string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("SQS/SEND: marshal error %w", err)
+	}
+
+	url, err := c.queueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+
+	input := &sqs.SendMessageInput{QueueUrl: aws.String(url), MessageBody: aws.String(string(body))}
+	if requestID := common.GetContextRequestID(ctx); requestID != "" {
+		input.MessageAttributes = map[string]types.MessageAttributeValue{
+			string(common.ContextRequestIDKey): {DataType: aws.String("String"), StringValue: aws.String(requestID)},
+		}
+	}
+
+	if _, err = c.sqs.SendMessage(ctx, input); err != nil {
+		c.logger.Error("SQS/SEND FAILED", zap.String("queue", queue), zap.Error(err))
+	}
+
+	return err
+}
+
+// Subscribe long-polls the "<prefix>-<topic>" queue, which must already be subscribed
+// to the SNS topic. Messages are deleted when the handler succeeds; failed messages
+// become visible again and move to the dead-letter queue per the queue redrive policy.
+func (c *Client) Subscribe(topic string, handler Handler) error {
+	name := c.queueName(topic)
+
+	url, err := c.queueURL(c.ctx, name)
+	if err != nil {
+		c.logger.Error("SQS/SUB QUEUE NOT FOUND", zap.String("queue", name), zap.Error(err))
+		return err
+	}
+
+	c.wg.Add(1)
+	go c.runConsumer(url, name, handler)
+
+	return nil
+}
+
+func (c *Client) runConsumer(url, name string, handler Handler) {
+	defer c.wg.Done()
+
+	logger := c.logger.With(zap.String("action", "subscribe"), zap.String("queue", name))
+	logger.Info("SQS/SUBS STARTED")
+
+	for {
+		out, err := c.sqs.ReceiveMessage(c.ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(url),
+			MaxNumberOfMessages:   c.config.MaxMessages,
+			WaitTimeSeconds:       int32(c.config.WaitTime.Seconds()),
+			VisibilityTimeout:     int32(c.config.VisibilityTimeout.Seconds()),
+			MessageAttributeNames: []string{"All"},
+		})
+		if c.ctx.Err() != nil {
+			logger.Debug("SQS/SUB: shutting down consumer")
+			return
+		}
+		if err != nil {
+			logger.Error("SQS/SUB: receive failed", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, msg := range out.Messages {
+			c.process(url, msg, handler, logger)
+		}
+	}
+}
+
+func (c *Client) process(url string, msg types.Message, handler Handler, logger *zap.Logger) {
+	start := time.Now()
+	body, requestID := unwrap(msg)
+
+	ctx := c.ctx
+	if requestID != "" {
+		ctx = context.WithValue(ctx, common.ContextRequestIDKey, requestID)
+	}
+
+	log := logger.With(
+		zap.String("message_id", aws.ToString(msg.MessageId)),
+		zap.String("request_id", requestID),
+		zap.Any("payload", json.RawMessage(body)),
+	)
+
+	stop := c.extendVisibility(url, msg, log)
+	err := handler(ctx, msg, body)
+	stop()
+
+	log = log.With(zap.Duration("duration", time.Since(start)))
+	if err != nil {
+		log.Error("SQS/SUB: handler returned error", zap.Error(err))
+		return
+	}
+
+	if _, err := c.sqs.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(url),
+		ReceiptHandle: msg.ReceiptHandle,
+	}); err != nil {
+		log.Error("SQS/SUB: delete failed", zap.Error(err))
+		return
+	}
+
+	log.Info("SQS/SUB SUCCEED")
+}
+
+// extendVisibility keeps the message invisible while a long handler runs,
+// extending the timeout every half period. The returned func stops it.
+func (c *Client) extendVisibility(url string, msg types.Message, logger *zap.Logger) func() {
+	timeout := c.config.VisibilityTimeout
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(timeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_, err := c.sqs.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          aws.String(url),
+					ReceiptHandle:     msg.ReceiptHandle,
+					VisibilityTimeout: int32(timeout.Seconds()),
+				})
+				if err != nil {
+					logger.Warn("SQS/SUB: visibility extension failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// Redrive moves the messages of the topic's dead-letter queue back to the
+// source queue and returns the move task handle.
+func (c *Client) Redrive(ctx context.Context, topic string) (string, error) {
+	dlq := c.queueName(topic) + c.config.DeadLetterSuffix
+
+	url, err := c.queueURL(ctx, dlq)
+	if err != nil {
+		return "", err
+	}
+
+	attrs, err := c.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(url),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := c.sqs.StartMessageMoveTask(ctx, &sqs.StartMessageMoveTaskInput{
+		SourceArn: aws.String(attrs.Attributes[string(types.QueueAttributeNameQueueArn)]),
+	})
+	if err != nil {
+		c.logger.Error("SQS/REDRIVE FAILED", zap.String("queue", dlq), zap.Error(err))
+		return "", err
+	}
+
+	c.logger.Info("SQS/REDRIVE STARTED", zap.String("queue", dlq))
+	return aws.ToString(out.TaskHandle), nil
+}
+
+// Close stops the consumers and waits for in-flight handlers
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+
+	c.logger.Debug("SQS/CLOSED")
+	return nil
+}
+
+// unwrap returns the message payload and request id, unwrapping SNS notifications
+// when raw message delivery is disabled on the subscription.
+func unwrap(msg types.Message) ([]byte, string) {
+	body := []byte(aws.ToString(msg.Body))
+	key := string(common.ContextRequestIDKey)
+
+	requestID := ""
+	if attr, ok := msg.MessageAttributes[key]; ok {
+		requestID = aws.ToString(attr.StringValue)
+	}
+
+	var env snsEnvelope
+	if err := json.Unmarshal(body, &env); err == nil && env.Type == "Notification" {
+		body = []byte(env.Message)
+		if attr, ok := env.MessageAttributes[key]; ok {
+			requestID = attr.Value
+		}
+	}
+
+	return body, requestID
+}
+
+// resourceName converts a dotted topic into a valid SNS/SQS resource name.
+func resourceName(name string) string {
+	return strings.ReplaceAll(name, ".", "-")
+}
@@ -0,0 +1,86 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// This file provides a high-level wrapper around a default SNS/SQS client,
+// mirroring the rabbitmq and nats packages. Credentials are resolved by the
+// AWS SDK default chain (environment, shared config, IAM role).
+//
+// Environment Variables:
+//   - AWS_REGION: AWS region
+//   - AWS_ACCOUNT_ID: account owning the SNS topics
+//   - AWS_ENDPOINT_URL: optional endpoint override (e.g. LocalStack)
+
+var (
+	defaultClient           *Client
+	ErrClientNotInitialized = errors.New("sqs client not initialized; call NewConnection first")
+)
+
+// ConfigDefault creates a Config struct using environment variables.
+// Make sure to load the environment variables before calling this function.
+// The prefix parameter is used to name the consumed queues.
+func ConfigDefault(prefix string) *Config {
+	return &Config{
+		Region:    os.Getenv("AWS_REGION"),
+		AccountID: os.Getenv("AWS_ACCOUNT_ID"),
+		Endpoint:  os.Getenv("AWS_ENDPOINT_URL"),
+		Prefix:    prefix,
+	}
+}
+
+// NewConnection initializes the default client using the provided config and logger.
+// It must be called before using Publish or Subscribe.
+func NewConnection(cfg *Config, logger *zap.Logger) error {
+	c, err := NewClient(cfg, logger.With(zap.String("component", "broker.sqs")))
+	if err == nil {
+		defaultClient = c
+	}
+
+	return err
+}
+
+// Publish sends data to the SNS topic using the default client.
+func Publish(ctx context.Context, topic string, data any) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Publish(ctx, topic, data)
+}
+
+// Subscribe consumes the topic queue using the default client.
+func Subscribe(topic string, handler Handler) error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Subscribe(topic, handler)
+}
+
+// Redrive moves dead-lettered messages of the topic back using the default client.
+func Redrive(ctx context.Context, topic string) (string, error) {
+	if defaultClient == nil {
+		return "", ErrClientNotInitialized
+	}
+
+	return defaultClient.Redrive(ctx, topic)
+}
+
+// CloseConnection gracefully closes the default client.
+func CloseConnection() error {
+	if defaultClient == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultClient.Close()
+}
+
+func GetClient() *Client {
+	return defaultClient
+}
@@ -0,0 +1,134 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Factory opens a Broker for the given config, registered by each driver package.
+type Factory func(cfg *Config, logger *zap.Logger) (Broker, error)
+
+// Config selects the broker driver and the topic namespace.
+type Config struct {
+	Driver string // registered driver name, e.g. "rabbitmq" or "nats"
+	Prefix string // topic namespace, usually the service name
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Factory{}
+
+	defaultBroker           Broker
+	ErrClientNotInitialized = errors.New("broker not initialized; call NewConnection first")
+)
+
+// Register makes a broker driver available by name.
+// It is intended to be called from the init function of driver packages.
+func Register(name string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if factory == nil {
+		panic("broker: Register factory is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("broker: Register called twice for driver " + name)
+	}
+	drivers[name] = factory
+}
+
+// Drivers returns a sorted list of the registered driver names.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Open creates a Broker using the driver named in the config.
+// The driver package must be imported, e.g.
+//
+//	import _ "github.com/logistics-id/engine/broker/rabbitmq"
+func Open(cfg *Config, logger *zap.Logger) (Broker, error) {
+	driversMu.RLock()
+	factory, ok := drivers[cfg.Driver]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("broker: unknown driver %q (forgotten import?)", cfg.Driver)
+	}
+
+	return factory(cfg, logger)
+}
+
+// ConfigDefault creating an config readed from .env file
+// make sure you load the env file in your init app
+func ConfigDefault(prefix string) *Config {
+	return &Config{
+		Driver: os.Getenv("BROKER_DRIVER"),
+		Prefix: prefix,
+	}
+}
+
+// NewConnection opens the default Broker used by the package-level functions.
+func NewConnection(cfg *Config, logger *zap.Logger) error {
+	b, err := Open(cfg, logger)
+	if err == nil {
+		defaultBroker = b
+	}
+
+	return err
+}
+
+// Publish sends a message using the default broker.
+func Publish(ctx context.Context, topic string, data any) error {
+	if defaultBroker == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultBroker.Publish(ctx, topic, data)
+}
+
+// Subscribe consumes a topic using the default broker.
+func Subscribe(topic string, handler Handler) error {
+	if defaultBroker == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultBroker.Subscribe(topic, handler)
+}
+
+// Request sends a request and waits for the reply using the default broker.
+func Request(ctx context.Context, topic string, req any, resp any) error {
+	if defaultBroker == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultBroker.Request(ctx, topic, req, resp)
+}
+
+// CloseConnection closes the default broker.
+func CloseConnection() error {
+	if defaultBroker == nil {
+		return ErrClientNotInitialized
+	}
+
+	return defaultBroker.Close()
+}
+
+// GetBroker returns the default broker instance.
+func GetBroker() Broker {
+	return defaultBroker
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Operator compares a Condition field to its value.
+type Operator string
+
+const (
+	OpEq     Operator = "eq"
+	OpNe     Operator = "ne"
+	OpGt     Operator = "gt"
+	OpGte    Operator = "gte"
+	OpLt     Operator = "lt"
+	OpLte    Operator = "lte"
+	OpIn     Operator = "in"   // Value is a slice
+	OpNotIn  Operator = "nin"  // Value is a slice
+	OpLike   Operator = "like" // case-insensitive substring match, Value is a string
+	OpIsNull Operator = "null" // Value true matches null fields, false non-null ones
+)
+
+// ErrInvalidCondition is returned when translating a malformed Condition.
+var ErrInvalidCondition = errors.New("invalid condition")
+
+// Condition is a structured filter for QueryOption.Conditions that repositories
+// translate to parameterized queries, unlike raw string conditions. It is either a
+// comparison of Field to Value, or a group of conditions joined by And or Or.
+//
+// Field is a column or document field name; it is quoted or used as a key, never
+// interpolated, but repositories do not restrict which fields may be filtered, so
+// build conditions from whitelisted fields when they come from user input.
+type Condition struct {
+	Field    string   `json:"field,omitempty"`
+	Operator Operator `json:"op,omitempty"`
+	Value    any      `json:"value,omitempty"`
+
+	And []Condition `json:"and,omitempty"`
+	Or  []Condition `json:"or,omitempty"`
+}
+
+// Where returns a condition comparing field to value.
+func Where(field string, op Operator, value any) Condition {
+	return Condition{Field: field, Operator: op, Value: value}
+}
+
+// And returns a condition matching when all of conds match.
+func And(conds ...Condition) Condition {
+	return Condition{And: conds}
+}
+
+// Or returns a condition matching when any of conds matches.
+func Or(conds ...Condition) Condition {
+	return Condition{Or: conds}
+}
+
+// IsGroup reports whether c joins other conditions rather than comparing a field.
+func (c Condition) IsGroup() bool {
+	return c.Field == ""
+}
+
+// Validate checks c and its nested conditions: a comparison needs a known operator
+// and a value of the type it expects, a group exactly one non-empty And or Or.
+func (c Condition) Validate() error {
+	if c.IsGroup() {
+		if (len(c.And) == 0) == (len(c.Or) == 0) {
+			return fmt.Errorf("%w: a group needs either and or or conditions", ErrInvalidCondition)
+		}
+		for _, sub := range append(c.And, c.Or...) {
+			if err := sub.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(c.And) > 0 || len(c.Or) > 0 {
+		return fmt.Errorf("%w: %s: a comparison cannot have and or or conditions", ErrInvalidCondition, c.Field)
+	}
+
+	switch c.Operator {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		if c.Value == nil {
+			return fmt.Errorf("%w: %s: nil value, use %s", ErrInvalidCondition, c.Field, OpIsNull)
+		}
+	case OpIn, OpNotIn:
+		if v := reflect.ValueOf(c.Value); !v.IsValid() || v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Len() == 0 {
+			return fmt.Errorf("%w: %s: %s needs a non-empty slice", ErrInvalidCondition, c.Field, c.Operator)
+		}
+	case OpLike:
+		if _, ok := c.Value.(string); !ok {
+			return fmt.Errorf("%w: %s: %s needs a string", ErrInvalidCondition, c.Field, c.Operator)
+		}
+	case OpIsNull:
+		if _, ok := c.Value.(bool); !ok {
+			return fmt.Errorf("%w: %s: %s needs a bool", ErrInvalidCondition, c.Field, c.Operator)
+		}
+	default:
+		return fmt.Errorf("%w: %s: unknown operator %q", ErrInvalidCondition, c.Field, c.Operator)
+	}
+	return nil
+}
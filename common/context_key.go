@@ -16,6 +16,7 @@ const (
 	ContextRequestStartTimeKey ContextKey = "request_start_time"
 	ContextTraceIDKey          ContextKey = "trace_id"
 	ContextSpanIDKey           ContextKey = "span_id"
+	ContextTenantKey           ContextKey = "tenant"
 )
 
 func GetContextRequestID(ctx context.Context) string {
@@ -25,6 +26,15 @@ func GetContextRequestID(ctx context.Context) string {
 	return ""
 }
 
+// GetContextSessionToken returns the raw JWT the session was decoded from, set by the
+// REST and gRPC auth middlewares so outgoing calls can forward it.
+func GetContextSessionToken(ctx context.Context) string {
+	if v, ok := ctx.Value(ContextSessionKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 func GetContextSession(ctx context.Context) *SessionClaims {
 	if v, ok := ctx.Value(ContextUserKey).(*SessionClaims); ok {
 		return v
@@ -33,6 +43,15 @@ func GetContextSession(ctx context.Context) *SessionClaims {
 	return nil
 }
 
+// GetContextUserID returns the UserID of the session in ctx, including custom claims
+// embedding SessionClaims, empty when the context is not authenticated.
+func GetContextUserID(ctx context.Context) string {
+	if sess, err := getSession(ctx); err == nil {
+		return sess.UserID
+	}
+	return ""
+}
+
 func GetContextSessionGeneric[T any](ctx context.Context) *T {
 	if v, ok := ctx.Value(ContextUserKey).(*T); ok {
 		return v
@@ -40,3 +59,17 @@ func GetContextSessionGeneric[T any](ctx context.Context) *T {
 
 	return nil
 }
+
+// WithContextTenant returns a copy of ctx carrying tenant, the white-label deployment
+// the request belongs to, e.g. set by an auth middleware from the session.
+func WithContextTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, ContextTenantKey, tenant)
+}
+
+// GetContextTenant returns the tenant set by WithContextTenant, empty when none.
+func GetContextTenant(ctx context.Context) string {
+	if v, ok := ctx.Value(ContextTenantKey).(string); ok {
+		return v
+	}
+	return ""
+}
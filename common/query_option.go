@@ -10,6 +10,11 @@ type QueryOption struct {
 	Offset     int64    `query:"-"`
 	Orders     []string `query:"-"`
 	Conditions []any    `query:"-"`
+
+	// Filters holds bracketed query params (?filter[status]=done) keyed by field.
+	// Repositories do not read it: the REST BindQueryOption adds the whitelisted
+	// ones to Conditions.
+	Filters map[string]string `query:"filter"`
 }
 
 func (r *QueryOption) GetLimit() int64 {
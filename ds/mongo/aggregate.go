@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/logistics-id/engine/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AggregatePage runs pipeline and decodes one page of its results into out, a
+// pointer to a slice, returning the total number of results. The page and the total
+// come from a single $facet stage appended to pipeline, after a $sort on the orders
+// of opts when opts.OrderBy is set; otherwise sort in pipeline for stable pages. A
+// page and its metadata must fit in a 16MB document, like any $facet output.
+func (c *Collection) AggregatePage(ctx context.Context, opts *common.QueryOption, pipeline mongo.Pipeline, out any) (int64, error) {
+	if opts == nil {
+		opts = &common.QueryOption{}
+	}
+
+	stages := append(mongo.Pipeline{}, pipeline...)
+	if opts.OrderBy != "" {
+		stages = append(stages, bson.D{{Key: "$sort", Value: RequestSort(opts.GetOrders())}})
+	}
+	stages = append(stages, bson.D{{Key: "$facet", Value: bson.M{
+		"items": bson.A{
+			bson.M{"$skip": opts.GetOffset()},
+			bson.M{"$limit": opts.GetLimit()},
+		},
+		"total": bson.A{
+			bson.M{"$count": "count"},
+		},
+	}}})
+
+	cursor, err := c.Aggregate(ctx, stages)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var page []struct {
+		Items bson.RawValue `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &page); err != nil {
+		return 0, err
+	}
+
+	// $count emits nothing, not 0, when there are no results
+	if len(page) == 0 || len(page[0].Total) == 0 {
+		return 0, nil
+	}
+
+	if err := page[0].Items.Unmarshal(out); err != nil {
+		return 0, err
+	}
+	return page[0].Total[0].Count, nil
+}
+
+// Aggregate runs pipeline on the repository's collection and decodes one page of
+// its results into out, returning their total, see Collection.AggregatePage. The
+// soft delete flag, the search and the structured conditions of opts are matched
+// before pipeline, as in FindAll.
+func (r *BaseRepository[T]) Aggregate(opts *common.QueryOption, pipeline mongo.Pipeline, out any) (int64, error) {
+	if opts == nil {
+		opts = &common.QueryOption{}
+	}
+
+	filter, err := r.filter(opts, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	stages := pipeline
+	if len(filter) > 0 {
+		stages = append(mongo.Pipeline{{{Key: "$match", Value: filter}}}, pipeline...)
+	}
+
+	return r.Collection.AggregatePage(r.Context, opts, stages, out)
+}
@@ -20,22 +20,36 @@ type BaseRepository[T any] struct {
 	Context          context.Context
 	searchFields     []string
 	enableSoftDelete bool
+	softDelete       softDeleteFields
+	withTrashed      bool
 }
 
+// NewBaseRepository creates a repository of T stored in col. With enableSoftDelete,
+// SoftDelete sets deleted_at when T has that field and is_deleted otherwise, and
+// reads skip the soft-deleted documents.
 func NewBaseRepository[T any](col *Collection, searchFields []string, enableSoftDelete bool) *BaseRepository[T] {
 	return &BaseRepository[T]{
 		Collection:       col,
 		searchFields:     searchFields,
 		enableSoftDelete: enableSoftDelete,
+		softDelete:       softDeleteFieldsOf[T](),
 	}
 }
 
 func (r *BaseRepository[T]) WithContext(ctx context.Context) common.BaseRepositoryInterface[T] {
+	return r.WithCtx(ctx)
+}
+
+// WithCtx returns a new BaseRepository instance with the given context, as the
+// concrete type for chaining mongo-specific methods.
+func (r *BaseRepository[T]) WithCtx(ctx context.Context) *BaseRepository[T] {
 	return &BaseRepository[T]{
 		Collection:       r.Collection,
 		Context:          ctx,
 		searchFields:     r.searchFields,
 		enableSoftDelete: r.enableSoftDelete,
+		softDelete:       r.softDelete,
+		withTrashed:      r.withTrashed,
 	}
 }
 
@@ -44,6 +58,30 @@ func (r *BaseRepository[T]) Insert(entity *T) error {
 	return err
 }
 
+// Upsert replaces the document matching filter with entity, or inserts entity when
+// there is none, so ingesting the same external event twice stores it once. The
+// stored _id is kept on replace, so T's _id field needs omitempty when filter
+// matches on other fields, and those fields need a unique index for concurrent
+// upserts not to insert twice. The _id of an inserted document is set on entity.
+func (r *BaseRepository[T]) Upsert(filter CustomQueryFn, entity *T) error {
+	if entity == nil {
+		return errors.New("entity is nil")
+	}
+	if filter == nil {
+		return errors.New("upsert requires a filter")
+	}
+
+	res, err := r.Collection.ReplaceOne(r.Context, filter(bson.M{}), entity, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	if res.UpsertedID != nil {
+		setEntityID(entity, res.UpsertedID)
+	}
+	return nil
+}
+
 func (r *BaseRepository[T]) FindByID(id any) (*T, error) {
 	idStr, ok := id.(string)
 	if !ok {
@@ -57,9 +95,7 @@ func (r *BaseRepository[T]) FindByID(id any) (*T, error) {
 
 	var result T
 	filter := bson.M{"_id": mid}
-	if r.enableSoftDelete {
-		filter["is_deleted"] = false
-	}
+	r.excludeTrashed(filter)
 	err = r.Collection.FindOne(r.Context, filter).Decode(&result)
 	if err != nil {
 		return nil, err
@@ -77,51 +113,22 @@ func (r *BaseRepository[T]) Update(entity *T, fields ...string) error {
 		return fmt.Errorf("failed to extract ID: %w", err)
 	}
 
-	update := bson.M{}
-	val := reflect.ValueOf(entity).Elem()
-	typ := val.Type()
-
-	for _, field := range fields {
-		var found bool
-
-		// cari field berdasarkan bson tag
-		for i := 0; i < typ.NumField(); i++ {
-			structField := typ.Field(i)
-			bsonTag := strings.Split(structField.Tag.Get("bson"), ",")[0]
-
-			if bsonTag == field {
-				update[bsonTag] = val.Field(i).Interface()
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			return fmt.Errorf("field %s not found on struct", field)
-		}
+	update, err := fieldValues(entity, fields)
+	if err != nil {
+		return err
 	}
 
 	_, err = r.Collection.UpdateByID(r.Context, id, bson.M{"$set": update})
 	return err
 }
 
-func (r *BaseRepository[T]) SoftDelete(id any) error {
-	if !r.enableSoftDelete {
-		return nil
-	}
-	_, err := r.Collection.UpdateByID(r.Context, id, bson.M{"$set": bson.M{"is_deleted": true}})
-	return err
-}
-
 func (r *BaseRepository[T]) FindOne(customQuery CustomQueryFn) (*T, error) {
 	var result T
 	filter := bson.M{}
 	if customQuery != nil {
 		filter = customQuery(filter)
 	}
-	if r.enableSoftDelete {
-		filter["is_deleted"] = false
-	}
+	r.excludeTrashed(filter)
 	err := r.Collection.FindOne(r.Context, filter).Decode(&result)
 	if err != nil {
 		return nil, err
@@ -129,13 +136,36 @@ func (r *BaseRepository[T]) FindOne(customQuery CustomQueryFn) (*T, error) {
 	return &result, nil
 }
 
-func (r *BaseRepository[T]) FindAll(opts *common.QueryOption, query CustomQueryFn) ([]*T, int64, error) {
-	filter := bson.M{}
-	if query != nil {
-		filter = query(filter)
+// FindFirst returns the first document of FindAll(opts, query): it is filtered by
+// query and the search and structured conditions of opts, and ordered by opts, so
+// the request DTO of a list endpoint also drives single lookups. Limit and Page are
+// ignored. Like FindOne, it returns mongo.ErrNoDocuments when nothing matches.
+func (r *BaseRepository[T]) FindFirst(opts *common.QueryOption, query CustomQueryFn) (*T, error) {
+	if opts == nil {
+		opts = &common.QueryOption{}
+	}
+
+	filter, err := r.filter(opts, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+	err = r.Collection.FindOne(
+		r.Context,
+		filter,
+		options.FindOne().SetSort(convertSortFields(opts.GetOrders())),
+	).Decode(&result)
+	if err != nil {
+		return nil, err
 	}
-	if r.enableSoftDelete {
-		filter["is_deleted"] = false
+	return &result, nil
+}
+
+func (r *BaseRepository[T]) FindAll(opts *common.QueryOption, query CustomQueryFn) ([]*T, int64, error) {
+	filter, err := r.filter(opts, query)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	var results []*T
@@ -168,6 +198,72 @@ func (r *BaseRepository[T]) FindAll(opts *common.QueryOption, query CustomQueryF
 	return results, count, nil
 }
 
+// filter returns the filter of FindAll: query, the soft delete flag, the search of
+// opts on the search fields and the structured conditions of opts, combined by $and.
+func (r *BaseRepository[T]) filter(opts *common.QueryOption, query CustomQueryFn) (bson.M, error) {
+	filter := bson.M{}
+	if query != nil {
+		filter = query(filter)
+	}
+	r.excludeTrashed(filter)
+
+	conds := []bson.M{filter}
+	if opts.Search != "" && len(r.searchFields) > 0 {
+		conds = append(conds, r.filterSearch(opts.Search))
+	}
+	for _, cond := range opts.Conditions {
+		var c common.Condition
+		switch v := cond.(type) {
+		case common.Condition:
+			c = v
+		case *common.Condition:
+			c = *v
+		default:
+			return nil, fmt.Errorf("%w: unsupported condition type %T", common.ErrInvalidCondition, cond)
+		}
+
+		f, err := FilterCondition(c)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, f)
+	}
+	if len(conds) > 1 {
+		filter = bson.M{"$and": conds}
+	}
+
+	return filter, nil
+}
+
+// fieldValues returns the values of entity's fields, a pointer to a struct, keyed by
+// their bson tags.
+func fieldValues(entity any, fields []string) (bson.M, error) {
+	values := bson.M{}
+	val := reflect.ValueOf(entity).Elem()
+	typ := val.Type()
+
+	for _, field := range fields {
+		var found bool
+
+		// cari field berdasarkan bson tag
+		for i := 0; i < typ.NumField(); i++ {
+			structField := typ.Field(i)
+			bsonTag := strings.Split(structField.Tag.Get("bson"), ",")[0]
+
+			if bsonTag == field {
+				values[bsonTag] = val.Field(i).Interface()
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("field %s not found on struct", field)
+		}
+	}
+	return values, nil
+}
+
 // extractEntityID assumes the struct has a field with `bson:"_id"`
 func extractEntityID(entity any) (any, error) {
 	val := reflect.ValueOf(entity).Elem()
@@ -184,6 +280,24 @@ func extractEntityID(entity any) (any, error) {
 	return nil, errors.New("no _id field found in entity")
 }
 
+// setEntityID sets the `bson:"_id"` field of entity to id when their types match.
+func setEntityID(entity any, id any) {
+	val := reflect.ValueOf(entity).Elem()
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		if strings.Split(typ.Field(i).Tag.Get("bson"), ",")[0] != "_id" {
+			continue
+		}
+
+		v := reflect.ValueOf(id)
+		if f := val.Field(i); f.CanSet() && v.Type().AssignableTo(f.Type()) {
+			f.Set(v)
+		}
+		return
+	}
+}
+
 func convertSortFields(fields []string) bson.D {
 	sort := bson.D{}
 	for _, field := range fields {
@@ -0,0 +1,96 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNoUpdateFields is returned by BulkUpdate without fields to update.
+var ErrNoUpdateFields = errors.New("bulk update requires at least one field")
+
+// BulkInsert inserts entities with one bulk write, split by the driver into batches
+// the server accepts. Ordered writes stop at the first failure, leaving the entities
+// before it inserted; unordered writes attempt every entity and may run in parallel
+// on the server, the fastest for ingestion bursts. On failure the error is a
+// mongo.BulkWriteException listing the failed writes, e.g. duplicate keys, and the
+// result counts the succeeded ones.
+func (r *BaseRepository[T]) BulkInsert(entities []*T, ordered bool) (*mongo.BulkWriteResult, error) {
+	models := make([]mongo.WriteModel, 0, len(entities))
+	for _, entity := range entities {
+		models = append(models, mongo.NewInsertOneModel().SetDocument(entity))
+	}
+	return r.bulkWrite(models, ordered)
+}
+
+// BulkUpdate sets fields (bson tags) of each of entities, matched by _id as in
+// Update, with one bulk write. ordered is as in BulkInsert.
+func (r *BaseRepository[T]) BulkUpdate(entities []*T, ordered bool, fields ...string) (*mongo.BulkWriteResult, error) {
+	if len(fields) == 0 {
+		return nil, ErrNoUpdateFields
+	}
+
+	models := make([]mongo.WriteModel, 0, len(entities))
+	for _, entity := range entities {
+		if entity == nil {
+			return nil, errors.New("entity is nil")
+		}
+
+		id, err := extractEntityID(entity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract ID: %w", err)
+		}
+
+		update, err := fieldValues(entity, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{ID: id}).
+			SetUpdate(bson.M{"$set": update}))
+	}
+	return r.bulkWrite(models, ordered)
+}
+
+// BulkUpsert replaces the document matching filter(entity) with each of entities,
+// inserting it when there is none, with one bulk write; ordered is as in BulkInsert.
+// A nil filter matches by _id. Replacing keeps the stored _id, so T's _id field needs
+// omitempty when filter matches on other fields, e.g. an external event ID, and the
+// field filtered on needs a unique index for concurrent upserts not to insert twice.
+func (r *BaseRepository[T]) BulkUpsert(entities []*T, filter func(entity *T) bson.M, ordered bool) (*mongo.BulkWriteResult, error) {
+	models := make([]mongo.WriteModel, 0, len(entities))
+	for _, entity := range entities {
+		if entity == nil {
+			return nil, errors.New("entity is nil")
+		}
+
+		var f bson.M
+		if filter != nil {
+			f = filter(entity)
+		} else {
+			id, err := extractEntityID(entity)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract ID: %w", err)
+			}
+			f = bson.M{ID: id}
+		}
+
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(f).
+			SetReplacement(entity).
+			SetUpsert(true))
+	}
+	return r.bulkWrite(models, ordered)
+}
+
+func (r *BaseRepository[T]) bulkWrite(models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+	// the driver rejects an empty bulk write
+	if len(models) == 0 {
+		return &mongo.BulkWriteResult{}, nil
+	}
+	return r.Collection.BulkWrite(r.Context, models, options.BulkWrite().SetOrdered(ordered))
+}
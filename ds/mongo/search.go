@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// textIndexes caches, by collection namespace, whether a collection has a text index.
+var textIndexes sync.Map
+
+// filterSearch returns the search filter of FindAll: $text when the collection has a
+// text index, so the index serves the search, and otherwise a case-insensitive
+// substring match on the search fields, see FilterSearch, with search matched
+// literally rather than as a regular expression.
+func (r *BaseRepository[T]) filterSearch(search string) bson.M {
+	if hasTextIndex(r.Context, r.Collection) {
+		return bson.M{"$text": bson.M{"$search": search}}
+	}
+	return FilterSearch(regexp.QuoteMeta(search), r.searchFields...)
+}
+
+// hasTextIndex reports whether col has a text index. The answer is looked up once
+// per collection and process, so a text index created later is used after a
+// restart. A failed lookup is not cached and reports false.
+func hasTextIndex(ctx context.Context, col *Collection) bool {
+	ns := col.Database().Name() + "." + col.Name()
+	if ok, found := textIndexes.Load(ns); found {
+		return ok.(bool)
+	}
+
+	specs, err := col.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return false
+	}
+
+	found := false
+	for _, spec := range specs {
+		elems, err := spec.KeysDocument.Elements()
+		if err != nil {
+			continue
+		}
+		for _, elem := range elems {
+			if v, ok := elem.Value().StringValueOK(); ok && v == "text" {
+				found = true
+			}
+		}
+	}
+
+	textIndexes.Store(ns, found)
+	return found
+}
@@ -0,0 +1,110 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// softDeleteFields describes how T marks soft-deleted documents.
+type softDeleteFields struct {
+	deletedAt bool // a deleted_at timestamp, null or missing while the document is live
+	isDeleted bool // an is_deleted flag, kept in sync when deleted_at is used too
+}
+
+// softDeleteFieldsOf looks up the deleted_at and is_deleted bson tags of T. Without
+// either, is_deleted is used as before deleted_at was supported.
+func softDeleteFieldsOf[T any]() softDeleteFields {
+	var fields softDeleteFields
+
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return softDeleteFields{isDeleted: true}
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		switch strings.Split(typ.Field(i).Tag.Get("bson"), ",")[0] {
+		case "deleted_at":
+			fields.deletedAt = true
+		case "is_deleted":
+			fields.isDeleted = true
+		}
+	}
+
+	if !fields.deletedAt {
+		fields.isDeleted = true
+	}
+	return fields
+}
+
+// WithTrashed returns a copy of the repository whose FindByID, FindOne, FindFirst,
+// FindAll and Aggregate include soft-deleted documents.
+func (r *BaseRepository[T]) WithTrashed() *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.withTrashed = true
+	return repo
+}
+
+// SoftDelete marks the document as deleted: deleted_at is set to the current time
+// when T has that field, is_deleted to true when it has that one. It is a no-op
+// unless soft delete is enabled; deleting an already deleted document keeps its
+// deleted_at.
+func (r *BaseRepository[T]) SoftDelete(id any) error {
+	if !r.enableSoftDelete {
+		return nil
+	}
+
+	if !r.softDelete.deletedAt {
+		_, err := r.Collection.UpdateByID(r.Context, id, bson.M{"$set": bson.M{"is_deleted": true}})
+		return err
+	}
+
+	set := bson.M{"deleted_at": time.Now()}
+	if r.softDelete.isDeleted {
+		set["is_deleted"] = true
+	}
+	_, err := r.Collection.UpdateOne(r.Context, bson.M{ID: id, "deleted_at": nil}, bson.M{"$set": set})
+	return err
+}
+
+// Restore undoes SoftDelete. It is a no-op unless soft delete is enabled.
+func (r *BaseRepository[T]) Restore(id any) error {
+	if !r.enableSoftDelete {
+		return nil
+	}
+
+	set := bson.M{}
+	if r.softDelete.deletedAt {
+		set["deleted_at"] = nil
+	}
+	if r.softDelete.isDeleted {
+		set["is_deleted"] = false
+	}
+	_, err := r.Collection.UpdateByID(r.Context, id, bson.M{"$set": set})
+	return err
+}
+
+// ForceDelete permanently deletes the document, whether soft-deleted or not.
+func (r *BaseRepository[T]) ForceDelete(id any) error {
+	_, err := r.Collection.DeleteOne(r.Context, bson.M{ID: id})
+	return err
+}
+
+// excludeTrashed filters out soft-deleted documents unless WithTrashed was called.
+// A null deleted_at matches a missing one too, so documents stored before the field
+// was added stay live. With both fields, documents soft-deleted before deleted_at
+// was added only have is_deleted set, so both are checked.
+func (r *BaseRepository[T]) excludeTrashed(filter bson.M) {
+	switch {
+	case !r.enableSoftDelete || r.withTrashed:
+	case r.softDelete.deletedAt && r.softDelete.isDeleted:
+		filter["deleted_at"] = nil
+		filter["is_deleted"] = bson.M{"$ne": true}
+	case r.softDelete.deletedAt:
+		filter["deleted_at"] = nil
+	default:
+		filter["is_deleted"] = false
+	}
+}
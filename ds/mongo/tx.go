@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunInTx runs fn in a multi-document transaction on the default connection. The
+// operations of fn join it through the ctx passed to fn, e.g. repositories given it
+// with WithContext; fn's error aborts the transaction. Transactions need a replica
+// set or a sharded cluster.
+func RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return runInTx(ctx, defaultDB.Client(), fn)
+}
+
+// RunInTx runs fn in a multi-document transaction, see the package-level RunInTx.
+// Use it to make writes across repositories atomic, e.g. an order and its tracking
+// event.
+func (r *BaseRepository[T]) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return runInTx(ctx, r.Collection.Database().Client(), fn)
+}
+
+// RunInTxWithRepo runs fn in a multi-document transaction with a copy of the
+// repository bound to it.
+func (r *BaseRepository[T]) RunInTxWithRepo(ctx context.Context, fn func(*BaseRepository[T]) error) error {
+	return r.RunInTx(ctx, func(ctx context.Context) error {
+		return fn(r.WithCtx(ctx))
+	})
+}
+
+// runInTx runs fn in a transaction of client. The driver retries the whole
+// transaction on transient errors, such as a write conflict or a primary election,
+// so fn must have no side effects outside of it. Within a transaction already in
+// ctx, fn joins it: MongoDB has no nested transactions.
+func runInTx(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	sess, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"errors"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -77,6 +78,78 @@ func RequestSort(sort []string) primitive.D {
 	return result
 }
 
+// FilterCondition translates a structured condition to a BSON filter. Field names
+// follow RequestSort: "id" is "_id" and "__" separates nested fields.
+//
+// Example:
+//
+//	FilterCondition(common.Or(
+//		common.Where("status", common.OpIn, []string{"new", "paid"}),
+//		common.Where("customer__name", common.OpLike, "budi"),
+//	))
+//	=>
+//	{
+//	  "$or": [
+//	    {"status": {"$in": ["new", "paid"]}},
+//	    {"customer.name": {"$regex": "budi", "$options": "i"}}
+//	  ]
+//	}
+func FilterCondition(cond common.Condition) (primitive.M, error) {
+	if err := cond.Validate(); err != nil {
+		return nil, err
+	}
+	return conditionFilter(cond), nil
+}
+
+func conditionFilter(cond common.Condition) bson.M {
+	switch {
+	case len(cond.And) > 0:
+		return bson.M{"$and": conditionFilters(cond.And)}
+	case len(cond.Or) > 0:
+		return bson.M{"$or": conditionFilters(cond.Or)}
+	}
+
+	field := cond.Field
+	if field == "id" {
+		field = "_id"
+	}
+	field = strings.ReplaceAll(field, "__", ".")
+
+	switch cond.Operator {
+	case common.OpNe:
+		return bson.M{field: bson.M{"$ne": cond.Value}}
+	case common.OpGt:
+		return bson.M{field: bson.M{"$gt": cond.Value}}
+	case common.OpGte:
+		return bson.M{field: bson.M{"$gte": cond.Value}}
+	case common.OpLt:
+		return bson.M{field: bson.M{"$lt": cond.Value}}
+	case common.OpLte:
+		return bson.M{field: bson.M{"$lte": cond.Value}}
+	case common.OpIn:
+		return bson.M{field: bson.M{"$in": cond.Value}}
+	case common.OpNotIn:
+		return bson.M{field: bson.M{"$nin": cond.Value}}
+	case common.OpLike:
+		return bson.M{field: bson.M{"$regex": regexp.QuoteMeta(cond.Value.(string)), "$options": "i"}}
+	case common.OpIsNull:
+		// nil matches both null and missing fields
+		if cond.Value.(bool) {
+			return bson.M{field: nil}
+		}
+		return bson.M{field: bson.M{"$ne": nil}}
+	}
+	return bson.M{field: cond.Value}
+}
+
+func conditionFilters(conds []common.Condition) []bson.M {
+	filters := make([]bson.M, len(conds))
+	for i, c := range conds {
+		filters[i] = conditionFilter(c)
+	}
+	return filters
+}
+
 // StructFilter returns a BSON map of specific fields from a struct.
 // If no fields are specified, it returns all fields.
 func StructFilter(m any, fields ...string) primitive.M {
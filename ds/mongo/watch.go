@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// watchMaxBackoff caps the delay between attempts to reopen a change stream.
+const watchMaxBackoff = 30 * time.Second
+
+// ChangeEvent is a change stream event of a collection of T. FullDocument is set for
+// inserts and replaces, and for updates with WatchOptions.FullDocument.
+type ChangeEvent[T any] struct {
+	OperationType     string              `bson:"operationType"` // insert, update, replace, delete...
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      *T                  `bson:"fullDocument"`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+}
+
+// UpdateDescription lists the fields changed by an update event.
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+// ChangeHandler handles a change stream event.
+type ChangeHandler[T any] func(ctx context.Context, event ChangeEvent[T]) error
+
+// ResumeTokenStore persists the resume tokens of change streams, so a watcher
+// restarted by a deploy continues where it stopped.
+type ResumeTokenStore interface {
+	// Load returns the token saved under name, nil when there is none.
+	Load(ctx context.Context, name string) (bson.Raw, error)
+	Save(ctx context.Context, name string, token bson.Raw) error
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Name identifies the resume token in Store, default the collection name. Give
+	// watchers of the same collection with different pipelines their own name.
+	Name string
+
+	// Store persists the resume token after each event; nil keeps it in memory, so
+	// a restarted watcher only sees changes made after it started.
+	Store ResumeTokenStore
+
+	// FullDocument looks up the current document of update events.
+	FullDocument bool
+}
+
+// Watch subscribes to the changes of the repository's collection matching pipeline
+// and calls handler with each, one at a time in order, until ctx is done. When the
+// stream fails it reopens it with backoff from the last resume token, so no change
+// is missed unless the token fell off the oplog, in which case it restarts from the
+// current time. A handler error is logged and the event skipped. Change streams
+// need a replica set or a sharded cluster.
+func (r *BaseRepository[T]) Watch(ctx context.Context, pipeline mongo.Pipeline, handler ChangeHandler[T], opts *WatchOptions) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	name := opts.Name
+	if name == "" {
+		name = r.Collection.Name()
+	}
+
+	log := watchLogger().With(zap.String("watcher", name))
+
+	var token bson.Raw
+	if opts.Store != nil {
+		var err error
+		if token, err = opts.Store.Load(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	delay := time.Second
+	for {
+		streamOpts := options.ChangeStream()
+		if opts.FullDocument {
+			streamOpts.SetFullDocument(options.UpdateLookup)
+		}
+		if token != nil {
+			streamOpts.SetStartAfter(token)
+		}
+
+		stream, err := r.Collection.Collection.Watch(ctx, pipeline, streamOpts)
+		if err == nil {
+			log.Info("MGO/WATCH STARTED")
+			delay = time.Second
+
+			err = watchStream(ctx, stream, handler, func(t bson.Raw) {
+				token = t
+				if opts.Store != nil {
+					if err := opts.Store.Save(ctx, name, t); err != nil {
+						log.Warn("MGO/WATCH TOKEN SAVE FAILED", zap.Error(err))
+					}
+				}
+			}, log)
+			_ = stream.Close(context.WithoutCancel(ctx))
+		}
+
+		if ctx.Err() != nil {
+			log.Info("MGO/WATCH STOPPED")
+			return nil
+		}
+
+		if isHistoryLost(err) {
+			log.Warn("MGO/WATCH HISTORY LOST, RESTARTING FROM NOW", zap.Duration("retry_in", delay), zap.Error(err))
+			token = nil
+		} else {
+			log.Warn("MGO/WATCH DISCONNECTED", zap.Duration("retry_in", delay), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("MGO/WATCH STOPPED")
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, watchMaxBackoff)
+	}
+}
+
+// watchStream handles the events of stream until it fails, passing the resume token
+// of each handled event to saved.
+func watchStream[T any](ctx context.Context, stream *mongo.ChangeStream, handler ChangeHandler[T], saved func(bson.Raw), log *zap.Logger) error {
+	for stream.Next(ctx) {
+		var event ChangeEvent[T]
+		if err := stream.Decode(&event); err != nil {
+			log.Error("MGO/WATCH DECODE FAILED", zap.Error(err))
+		} else {
+			safelyHandle(ctx, handler, event, log)
+		}
+		saved(stream.ResumeToken())
+	}
+	return stream.Err()
+}
+
+func safelyHandle[T any](ctx context.Context, handler ChangeHandler[T], event ChangeEvent[T], log *zap.Logger) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Error("MGO/WATCH HANDLER PANICKED", zap.String("operation", event.OperationType), zap.Any("error", rec))
+		}
+	}()
+
+	if err := handler(ctx, event); err != nil {
+		log.Error("MGO/WATCH HANDLER FAILED", zap.String("operation", event.OperationType), zap.Error(err))
+	}
+}
+
+// isHistoryLost reports whether the resume token is no longer in the oplog.
+func isHistoryLost(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 286 || cmdErr.HasErrorLabel("NonResumableChangeStreamError"))
+}
+
+func watchLogger() *zap.Logger {
+	if logger == nil {
+		return zap.NewNop()
+	}
+	return logger
+}
+
+// collectionTokenStore keeps resume tokens in a collection, one document per
+// watcher.
+type collectionTokenStore struct {
+	col *Collection
+}
+
+// NewResumeTokenStore returns a ResumeTokenStore saving tokens in col, e.g.
+// NewCollection("resume_tokens").
+func NewResumeTokenStore(col *Collection) ResumeTokenStore {
+	return &collectionTokenStore{col: col}
+}
+
+func (s *collectionTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	var doc struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err := s.col.FindOne(ctx, bson.M{ID: name}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return doc.Token, err
+}
+
+func (s *collectionTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	_, err := s.col.UpdateOne(ctx,
+		bson.M{ID: name},
+		bson.M{"$set": bson.M{"token": token, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/uptrace/bun/schema"
+)
+
+// auditColumns holds the audit fields of T, nil for the columns it lacks.
+type auditColumns struct {
+	createdAt *schema.Field
+	updatedAt *schema.Field
+	createdBy *schema.Field
+	updatedBy *schema.Field
+}
+
+func auditColumnsOf(table *schema.Table) auditColumns {
+	return auditColumns{
+		createdAt: table.FieldMap["created_at"],
+		updatedAt: table.FieldMap["updated_at"],
+		createdBy: table.FieldMap["created_by"],
+		updatedBy: table.FieldMap["updated_by"],
+	}
+}
+
+// creationColumns are the audit columns an update of an existing row must keep.
+func (a auditColumns) creationColumns() []string {
+	var cols []string
+	for _, f := range []*schema.Field{a.createdAt, a.createdBy} {
+		if f != nil {
+			cols = append(cols, f.Name)
+		}
+	}
+	return cols
+}
+
+// stampCreated fills the audit fields of a new entity left unset: created_at and
+// updated_at with the current time, created_by and updated_by with the user of the
+// context's session.
+func (r *BaseRepository[T]) stampCreated(entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+	now := time.Now()
+	userID := common.GetContextUserID(r.Context)
+
+	return errors.Join(
+		setIfZero(v, r.audit.createdAt, now),
+		setIfZero(v, r.audit.updatedAt, now),
+		setIfZero(v, r.audit.createdBy, userID),
+		setIfZero(v, r.audit.updatedBy, userID),
+	)
+}
+
+// stampUpdated sets updated_at to the current time and updated_by to the user of the
+// context's session, and returns fields with their columns added when the update is
+// limited to fields.
+func (r *BaseRepository[T]) stampUpdated(entity *T, fields []string) ([]string, error) {
+	v := reflect.ValueOf(entity).Elem()
+
+	if f := r.audit.updatedAt; f != nil {
+		if err := f.ScanValue(v, time.Now()); err != nil {
+			return nil, err
+		}
+		fields = withColumn(fields, f.Name)
+	}
+
+	if f := r.audit.updatedBy; f != nil {
+		if userID := common.GetContextUserID(r.Context); userID != "" {
+			if err := f.ScanValue(v, userID); err != nil {
+				return nil, err
+			}
+			fields = withColumn(fields, f.Name)
+		}
+	}
+
+	return fields, nil
+}
+
+// stampUpdatedSet returns a copy of the UpdateWhere set with updated_at and updated_by
+// added unless set already has them.
+func (r *BaseRepository[T]) stampUpdatedSet(set map[string]any) map[string]any {
+	set = maps.Clone(set)
+	if set == nil {
+		set = map[string]any{}
+	}
+
+	if f := r.audit.updatedAt; f != nil {
+		if _, ok := set[f.Name]; !ok {
+			set[f.Name] = time.Now()
+		}
+	}
+
+	if f := r.audit.updatedBy; f != nil {
+		if _, ok := set[f.Name]; !ok {
+			if userID := common.GetContextUserID(r.Context); userID != "" {
+				set[f.Name] = userID
+			}
+		}
+	}
+
+	return set
+}
+
+// setIfZero sets the field of strct to value when the field exists, is unset and
+// value is not empty.
+func setIfZero[V comparable](strct reflect.Value, f *schema.Field, value V) error {
+	var zero V
+	if f == nil || value == zero || !f.HasZeroValue(strct) {
+		return nil
+	}
+	return f.ScanValue(strct, value)
+}
+
+// withColumn adds col to a non-empty column list, which would otherwise mean all
+// columns.
+func withColumn(fields []string, col string) []string {
+	if len(fields) == 0 || slices.Contains(fields, col) {
+		return fields
+	}
+	return append(slices.Clone(fields), col)
+}
@@ -4,15 +4,35 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 
 	"github.com/logistics-id/engine/common"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
 )
 
+// ErrNoConflictColumns is returned by Upsert without conflict columns.
+var ErrNoConflictColumns = errors.New("upsert requires at least one conflict column")
+
+// ErrNoPredicate is returned by UpdateWhere and DeleteWhere without a predicate, so a
+// missing filter never rewrites a whole table.
+var ErrNoPredicate = errors.New("bulk update or delete requires a predicate")
+
+// ErrNoUpdateColumns is returned by UpdateWhere without columns to set.
+var ErrNoUpdateColumns = errors.New("bulk update requires at least one column")
+
 // CustomQueryFn is a function type for custom query modifications specific to Bun/PostgreSQL
 type CustomQueryFn func(q *bun.SelectQuery) *bun.SelectQuery
 
+// PredicateFn adds the where clauses selecting the rows of UpdateWhere and DeleteWhere.
+// bun.QueryBuilder is shared by select, update and delete queries.
+type PredicateFn func(q bun.QueryBuilder) bun.QueryBuilder
+
 type BaseRepository[T any] struct {
 	DB               bun.IDB
 	Context          context.Context
@@ -20,16 +40,31 @@ type BaseRepository[T any] struct {
 	searchFields     []string
 	defaultRelations []string
 	enableSoftDelete bool
+	softDelete       softDeleteColumns
+	withTrashed      bool
+	audit            auditColumns
+	txRetry          *TxRetryPolicy
+	search           SearchOptions
 }
 
+// NewBaseRepository creates a repository of T stored in table. With enableSoftDelete,
+// SoftDelete sets deleted_at when T has that column and is_deleted otherwise, and
+// reads skip the soft-deleted rows. The created_at, updated_at, created_by and
+// updated_by columns of T are filled on insert and update, see Insert and Update.
 func NewBaseRepository[T any](db *bun.DB, table string, searchFields, defaultRelations []string, enableSoftDelete bool) *BaseRepository[T] {
-	return &BaseRepository[T]{
+	r := &BaseRepository[T]{
 		DB:               db,
 		table:            table,
 		searchFields:     searchFields,
 		defaultRelations: defaultRelations,
 		enableSoftDelete: enableSoftDelete,
 	}
+	if db != nil {
+		table := db.Dialect().Tables().Get(reflect.TypeFor[T]())
+		r.softDelete = softDeleteColumnsOf(table)
+		r.audit = auditColumnsOf(table)
+	}
+	return r
 }
 
 func (r *BaseRepository[T]) WithContext(ctx context.Context) common.BaseRepositoryInterface[T] {
@@ -47,6 +82,11 @@ func (r *BaseRepository[T]) WithCtx(ctx context.Context) *BaseRepository[T] {
 		searchFields:     r.searchFields,
 		defaultRelations: r.defaultRelations,
 		enableSoftDelete: r.enableSoftDelete,
+		softDelete:       r.softDelete,
+		withTrashed:      r.withTrashed,
+		audit:            r.audit,
+		txRetry:          r.txRetry,
+		search:           r.search,
 	}
 }
 
@@ -61,114 +101,403 @@ func (r *BaseRepository[T]) WithTx(ctx context.Context, tx bun.Tx) *BaseReposito
 		searchFields:     r.searchFields,
 		defaultRelations: r.defaultRelations,
 		enableSoftDelete: r.enableSoftDelete,
+		softDelete:       r.softDelete,
+		withTrashed:      r.withTrashed,
+		audit:            r.audit,
+		txRetry:          r.txRetry,
+		search:           r.search,
+	}
+}
+
+// WithRelations returns a copy of the repository whose reads also load relations,
+// after the default ones.
+func (r *BaseRepository[T]) WithRelations(relations ...string) *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.defaultRelations = slices.Clone(r.defaultRelations)
+	for _, rel := range relations {
+		if !slices.Contains(repo.defaultRelations, rel) {
+			repo.defaultRelations = append(repo.defaultRelations, rel)
+		}
 	}
+	return repo
 }
 
+// WithoutRelations returns a copy of the repository whose reads load no relations,
+// e.g. for list endpoints; chain WithRelations to load only some.
+func (r *BaseRepository[T]) WithoutRelations() *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.defaultRelations = nil
+	return repo
+}
+
+// Insert inserts entity. Its created_at and updated_at fields, when T has them, are
+// set to the current time and created_by and updated_by to the UserID of the
+// context's session, unless already set.
 func (r *BaseRepository[T]) Insert(entity *T) error {
-	_, err := r.DB.NewInsert().Model(entity).Exec(r.Context)
-	return err
+	if err := r.stampCreated(entity); err != nil {
+		return err
+	}
+
+	return r.write(func(db bun.IDB) error {
+		_, err := db.NewInsert().Model(entity).Exec(r.Context)
+		return err
+	})
 }
 
-func (r *BaseRepository[T]) FindByID(id any) (*T, error) {
-	entity := new(T)
-	q := r.DB.NewSelect().
-		Model(entity)
+// DefaultInsertBatchSize is the batch size of InsertMany when batchSize is not positive.
+const DefaultInsertBatchSize = 1000
 
-	q.Where(fmt.Sprintf("%s.id = ?", r.table), id)
+// InsertMany inserts entities with one multi-row INSERT per batchSize entities, all
+// in a single transaction (a savepoint within WithTx) so a failed batch inserts
+// nothing. Generated values such as IDs are set on the entities, audit fields are
+// filled as in Insert.
+func (r *BaseRepository[T]) InsertMany(entities []*T, batchSize int) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultInsertBatchSize
+	}
 
-	if r.enableSoftDelete {
-		q.Where(fmt.Sprintf("%s.is_deleted = false", r.table))
+	for _, entity := range entities {
+		if err := r.stampCreated(entity); err != nil {
+			return err
+		}
+	}
+
+	insert := func(ctx context.Context, db bun.IDB) error {
+		for batch := range slices.Chunk(entities, batchSize) {
+			if _, err := db.NewInsert().Model(&batch).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	for _, rel := range r.defaultRelations {
-		q.Relation(rel)
+	return r.write(func(db bun.IDB) error {
+		if len(entities) <= batchSize {
+			return insert(r.Context, db)
+		}
+
+		return db.RunInTx(r.Context, nil, func(ctx context.Context, tx bun.Tx) error {
+			return insert(ctx, tx)
+		})
+	})
+}
+
+// Upsert inserts entity or, when a row with the same conflictColumns exists (they
+// must match a unique index or constraint), updates its updateColumns from entity.
+// Without updateColumns every column but the primary key, conflictColumns, created_at
+// and created_by is updated. Audit fields are filled as in Insert, and the entity is
+// refreshed with the stored row, e.g. its ID.
+func (r *BaseRepository[T]) Upsert(entity *T, conflictColumns []string, updateColumns ...string) error {
+	if len(conflictColumns) == 0 {
+		return ErrNoConflictColumns
 	}
 
-	err := q.Scan(r.Context)
+	if len(updateColumns) == 0 {
+		keep := append(slices.Clone(conflictColumns), r.audit.creationColumns()...)
+		updateColumns = upsertColumns(r.DB.Dialect().Tables().Get(reflect.TypeFor[T]()), keep)
+	}
+
+	if err := r.stampCreated(entity); err != nil {
+		return err
+	}
+
+	return r.write(func(db bun.IDB) error {
+		q := db.NewInsert().Model(entity)
+
+		if len(updateColumns) == 0 {
+			// Nothing to update, keep the existing row as is
+			res, err := q.On("CONFLICT (?) DO NOTHING", bun.In(idents(conflictColumns))).
+				Returning("*").
+				Exec(r.Context)
+			if err != nil {
+				return err
+			}
+
+			// The existing row is not returned, read it by the conflict columns
+			if n, err := res.RowsAffected(); err != nil || n > 0 {
+				return err
+			}
+			sel := db.NewSelect().Model(entity).WherePK(conflictColumns...)
+			if r.softDelete.bunTag {
+				sel.WhereAllWithDeleted()
+			}
+			return sel.Scan(r.Context)
+		}
+
+		q.On("CONFLICT (?) DO UPDATE", bun.In(idents(conflictColumns)))
+		for _, col := range updateColumns {
+			q.Set("? = EXCLUDED.?", bun.Ident(col), bun.Ident(col))
+		}
+
+		_, err := q.Returning("*").Exec(r.Context)
+		return err
+	})
+}
+
+func (r *BaseRepository[T]) FindByID(id any) (*T, error) {
+	entity := new(T)
+
+	err := r.read(func(db bun.IDB) error {
+		q := db.NewSelect().
+			Model(entity)
+
+		q.Where(fmt.Sprintf("%s.id = ?", r.table), id)
+
+		r.excludeTrashed(q.QueryBuilder())
+
+		for _, rel := range r.defaultRelations {
+			q.Relation(rel)
+		}
+
+		return q.Scan(r.Context)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return entity, nil
 }
 
-func (r *BaseRepository[T]) Update(entity *T, fields ...string) error {
-	query := r.DB.NewUpdate().Model(entity).WherePK()
-	if len(fields) > 0 {
-		query.Column(fields...)
+// FindByIDs returns the entities with the given IDs in no particular order, skipping
+// soft-deleted and missing ones, with default relations loaded.
+func (r *BaseRepository[T]) FindByIDs(ids []any) ([]*T, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	_, err := query.Exec(r.Context)
-	return err
-}
 
-func (r *BaseRepository[T]) SoftDelete(id any) error {
-	if !r.enableSoftDelete {
-		return nil
+	var result []*T
+
+	err := r.read(func(db bun.IDB) error {
+		result = nil
+
+		q := db.NewSelect().
+			Model(&result).
+			Where(fmt.Sprintf("%s.id IN (?)", r.table), bun.In(ids))
+
+		r.excludeTrashed(q.QueryBuilder())
+
+		for _, rel := range r.defaultRelations {
+			q.Relation(rel)
+		}
+
+		return q.Scan(r.Context)
+	})
+	if err != nil {
+		return nil, err
 	}
-	_, err := r.DB.NewUpdate().
-		Model((*T)(nil)).
-		Set("is_deleted = true").
-		Where("id = ?", id).
-		Exec(r.Context)
-	return err
+	return result, nil
 }
 
-func (r *BaseRepository[T]) FindAll(opts *common.QueryOption, customQuery CustomQueryFn) ([]*T, int64, error) {
-	var result []*T
+// Count returns the number of entities matching customQuery, excluding soft-deleted
+// ones.
+func (r *BaseRepository[T]) Count(customQuery CustomQueryFn) (int64, error) {
+	var total int
 
-	q := r.DB.NewSelect().Model(&result)
+	err := r.read(func(db bun.IDB) (err error) {
+		q := db.NewSelect().Model((*T)(nil))
 
-	if opts.Search != "" && len(r.searchFields) > 0 {
-		FilterSearch(q, opts.Search, r.searchFields...)
+		r.excludeTrashed(q.QueryBuilder())
+
+		if customQuery != nil {
+			q = customQuery(q)
+		}
+
+		total, err = q.Count(r.Context)
+		return err
+	})
+	return int64(total), err
+}
+
+// Exists reports whether an entity matches customQuery, excluding soft-deleted ones.
+// It runs SELECT EXISTS, which stops at the first match, unlike a count.
+func (r *BaseRepository[T]) Exists(customQuery CustomQueryFn) (bool, error) {
+	var exists bool
+
+	err := r.read(func(db bun.IDB) (err error) {
+		q := db.NewSelect().Model((*T)(nil))
+
+		r.excludeTrashed(q.QueryBuilder())
+
+		if customQuery != nil {
+			q = customQuery(q)
+		}
+
+		exists, err = q.Exists(r.Context)
+		return err
+	})
+	return exists, err
+}
+
+// Update updates entity by primary key, only its fields columns when given. Its
+// updated_at field, when T has it, is set to the current time and updated_by to the
+// UserID of the context's session, and both are added to fields.
+func (r *BaseRepository[T]) Update(entity *T, fields ...string) error {
+	fields, err := r.stampUpdated(entity, fields)
+	if err != nil {
+		return err
 	}
 
-	for _, cond := range opts.Conditions {
-		if strCond, ok := cond.(string); ok {
-			q.Where(strCond)
+	return r.write(func(db bun.IDB) error {
+		query := db.NewUpdate().Model(entity).WherePK()
+		if len(fields) > 0 {
+			query.Column(fields...)
 		}
+		_, err := query.Exec(r.Context)
+		return err
+	})
+}
+
+// UpdateWhere sets columns on every non-deleted row matching where in one statement
+// and returns the number of rows updated. Values are bound; use bun.Safe for SQL
+// expressions such as bun.Safe("now()"). updated_at and updated_by are set as in
+// Update unless set has them.
+func (r *BaseRepository[T]) UpdateWhere(set map[string]any, where PredicateFn) (int64, error) {
+	if where == nil {
+		return 0, ErrNoPredicate
 	}
+	if len(set) == 0 {
+		return 0, ErrNoUpdateColumns
+	}
+
+	set = r.stampUpdatedSet(set)
+
+	return r.writeRows(func(db bun.IDB) (sql.Result, error) {
+		q := db.NewUpdate().Model((*T)(nil))
+
+		// Sorted so the same update always renders the same SQL
+		for _, col := range slices.Sorted(maps.Keys(set)) {
+			q.Set("? = ?", bun.Ident(col), set[col])
+		}
 
-	if r.enableSoftDelete {
-		q.Where(fmt.Sprintf("%s.is_deleted = false", r.table))
+		r.excludeTrashed(q.QueryBuilder())
+		q.QueryBuilder().WhereGroup(" AND ", where)
+
+		return q.Exec(r.Context)
+	})
+}
+
+// DeleteWhere soft-deletes every row matching where when soft delete is enabled and
+// deletes them otherwise, in one statement, returning the number of rows affected.
+func (r *BaseRepository[T]) DeleteWhere(where PredicateFn) (int64, error) {
+	if where == nil {
+		return 0, ErrNoPredicate
 	}
 
-	for _, rel := range r.defaultRelations {
-		q.Relation(rel)
+	if !r.enableSoftDelete {
+		return r.ForceDeleteWhere(where)
 	}
 
-	if customQuery != nil {
-		q = customQuery(q)
+	return r.writeRows(func(db bun.IDB) (sql.Result, error) {
+		q := r.markDeleted(db.NewUpdate().Model((*T)(nil)))
+		q.QueryBuilder().WhereGroup(" AND ", where)
+
+		return q.Exec(r.Context)
+	})
+}
+
+// ForceDeleteWhere permanently deletes every row matching where, soft-deleted or not.
+func (r *BaseRepository[T]) ForceDeleteWhere(where PredicateFn) (int64, error) {
+	if where == nil {
+		return 0, ErrNoPredicate
 	}
 
-	total, err := q.Count(r.Context)
+	return r.writeRows(func(db bun.IDB) (sql.Result, error) {
+		q := db.NewDelete().Model((*T)(nil))
+		if r.softDelete.bunTag {
+			q.ForceDelete()
+		}
+		q.QueryBuilder().WhereGroup(" AND ", where)
+
+		return q.Exec(r.Context)
+	})
+}
+
+func (r *BaseRepository[T]) FindAll(opts *common.QueryOption, customQuery CustomQueryFn) ([]*T, int64, error) {
+	var (
+		result []*T
+		total  int
+	)
+
+	err := r.read(func(db bun.IDB) (err error) {
+		result = nil
+
+		q := db.NewSelect().Model(&result)
+
+		if err := r.applyOptions(q, opts); err != nil {
+			return err
+		}
+
+		for _, rel := range r.defaultRelations {
+			q.Relation(rel)
+		}
+
+		if customQuery != nil {
+			q = customQuery(q)
+		}
+
+		total, err = q.Count(r.Context)
+		if err != nil || total == 0 {
+			return err
+		}
+
+		q.OrderExpr(RequestSort(opts.GetOrders()))
+		q.Limit(int(opts.GetLimit()))
+		q.Offset(int(opts.GetOffset()))
+
+		return q.Scan(r.Context)
+	})
 	if err != nil || total == 0 {
 		return nil, 0, err
 	}
 
-	q.OrderExpr(RequestSort(opts.GetOrders()))
-	q.Limit(int(opts.GetLimit()))
-	q.Offset(int(opts.GetOffset()))
+	return result, int64(total), nil
+}
 
-	if err := q.Scan(r.Context); err != nil {
-		return nil, 0, err
+// applyOptions adds the search, conditions and soft delete filters of FindAll to q.
+func (r *BaseRepository[T]) applyOptions(q *bun.SelectQuery, opts *common.QueryOption) error {
+	if opts.Search != "" && len(r.searchFields) > 0 {
+		r.filterSearch(q, opts.Search, opts.OrderBy == "")
 	}
 
-	return result, int64(total), nil
+	for _, cond := range opts.Conditions {
+		switch cond := cond.(type) {
+		case string:
+			q.Where(cond)
+		case common.Condition:
+			if err := FilterCondition(q, cond); err != nil {
+				return err
+			}
+		case *common.Condition:
+			if err := FilterCondition(q, *cond); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unsupported condition type %T", common.ErrInvalidCondition, cond)
+		}
+	}
+
+	r.excludeTrashed(q.QueryBuilder())
+	return nil
 }
 
 func (r *BaseRepository[T]) FindOne(customQuery CustomQueryFn) (*T, error) {
 	var result T
 
-	q := r.DB.NewSelect().Model(&result)
+	err := r.read(func(db bun.IDB) error {
+		q := db.NewSelect().Model(&result)
 
-	if customQuery != nil {
-		q = customQuery(q)
-	}
+		if customQuery != nil {
+			q = customQuery(q)
+		}
 
-	for _, rel := range r.defaultRelations {
-		q.Relation(rel)
-	}
+		for _, rel := range r.defaultRelations {
+			q.Relation(rel)
+		}
 
-	err := q.Limit(1).Scan(r.Context, &result)
+		return q.Limit(1).Scan(r.Context, &result)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -180,10 +509,10 @@ func (r *BaseRepository[T]) FindOne(customQuery CustomQueryFn) (*T, error) {
 // This method provides full control - you receive the context and transaction,
 // and can create multiple repository instances with WithTx as needed.
 // Use this when you need to work with multiple different repositories in the same transaction.
+// The context passed to fn carries the transaction, so repositories given it with
+// WithContext join it as well, see ContextWithTx.
 func (r *BaseRepository[T]) RunInTx(ctx context.Context, fn func(context.Context, bun.Tx) error) error {
-	return r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		return fn(ctx, tx)
-	})
+	return r.runInTx(ctx, fn)
 }
 
 // RunInTxWithRepo executes a function within a database transaction,
@@ -191,8 +520,64 @@ func (r *BaseRepository[T]) RunInTx(ctx context.Context, fn func(context.Context
 // This is a convenience method for simpler cases where you only need one repository.
 // For multiple repositories or more control, use RunInTx instead.
 func (r *BaseRepository[T]) RunInTxWithRepo(ctx context.Context, fn func(*BaseRepository[T]) error) error {
-	return r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+	return r.runInTx(ctx, func(ctx context.Context, tx bun.Tx) error {
 		repoWithTx := r.WithTx(ctx, tx)
 		return fn(repoWithTx)
 	})
 }
+
+// read runs the read-only fn through readFrom, in the schema of the context's tenant.
+func (r *BaseRepository[T]) read(fn func(db bun.IDB) error) error {
+	return readFrom(r.db(), func(db bun.IDB) error {
+		return tenantScoped(r.Context, db, fn)
+	})
+}
+
+// write runs fn on the primary, in the schema of the context's tenant.
+func (r *BaseRepository[T]) write(fn func(db bun.IDB) error) error {
+	return tenantScoped(r.Context, r.db(), fn)
+}
+
+// db returns the transaction of the context, see ContextWithTx, or the repository's
+// DB.
+func (r *BaseRepository[T]) db() bun.IDB {
+	if r.Context == nil {
+		return r.DB
+	}
+	return dbFrom(r.Context, r.DB)
+}
+
+// writeRows is write for a statement returning the number of rows affected.
+func (r *BaseRepository[T]) writeRows(fn func(db bun.IDB) (sql.Result, error)) (int64, error) {
+	var rows int64
+	err := r.write(func(db bun.IDB) error {
+		res, err := fn(db)
+		if err != nil {
+			return err
+		}
+		rows, err = res.RowsAffected()
+		return err
+	})
+	return rows, err
+}
+
+// upsertColumns returns the columns of table an upsert updates: all but the primary
+// key and the kept columns.
+func upsertColumns(table *schema.Table, keep []string) []string {
+	var cols []string
+	for _, f := range table.DataFields {
+		if !slices.Contains(keep, f.Name) {
+			cols = append(cols, f.Name)
+		}
+	}
+	return cols
+}
+
+// idents quotes column names for use as a comma-separated list with bun.In.
+func idents(cols []string) []bun.Ident {
+	ids := make([]bun.Ident, len(cols))
+	for i, col := range cols {
+		ids[i] = bun.Ident(col)
+	}
+	return ids
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -16,12 +17,76 @@ type Config struct {
 	Password   string // Database password
 	Database   string // Database name
 	Datasource string // Full DSN string (overrides Server/Username/Password/Database)
+
+	// Replicas are read replica DSNs. FindAll, FindByID and FindOne of repositories
+	// built on the client's DB read from them, falling back to the primary when none
+	// is healthy; writes and transactions always use the primary.
+	Replicas []string
+
+	// Connection pool of the primary and of each replica. Zero uses the Default*
+	// value; a negative value passes 0 to database/sql, i.e. no open connection,
+	// lifetime or idle time limit, and no idle connections kept.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// SlowQueryThreshold logs slower queries at WARN with their row count, default
+	// DefaultSlowQueryThreshold; negative disables it. ExplainSlowQueries also logs
+	// the EXPLAIN ANALYZE plan of slow SELECTs, which runs them twice: set it in
+	// development only, e.g. from engine.Config.IsDev.
+	SlowQueryThreshold time.Duration
+	ExplainSlowQueries bool
+
+	// Metrics records query latency and errors and the pool stats of the primary and
+	// replicas, see NewMetrics; nil records nothing.
+	Metrics *Metrics
+}
+
+// Connection pool defaults, sized to stay well within a PgBouncer pool under burst
+// load; database/sql alone would open connections without limit.
+const (
+	DefaultMaxOpenConns    = 20
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = 30 * time.Minute
+	DefaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// DefaultSlowQueryThreshold is the slow query threshold when Config.SlowQueryThreshold
+// is zero.
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// configurePool applies the pool settings of cfg to sqldb.
+func (cfg *Config) configurePool(sqldb *sql.DB) {
+	sqldb.SetMaxOpenConns(max(valueOr(cfg.MaxOpenConns, DefaultMaxOpenConns), 0))
+	sqldb.SetMaxIdleConns(max(valueOr(cfg.MaxIdleConns, DefaultMaxIdleConns), 0))
+	sqldb.SetConnMaxLifetime(max(valueOr(cfg.ConnMaxLifetime, DefaultConnMaxLifetime), 0))
+	sqldb.SetConnMaxIdleTime(max(valueOr(cfg.ConnMaxIdleTime, DefaultConnMaxIdleTime), 0))
+}
+
+// queryHook returns the logging hook of db.
+func (cfg *Config) queryHook(db *bun.DB, l *zap.Logger) *ZapQueryHook {
+	return &ZapQueryHook{
+		Logger:        l,
+		SlowThreshold: max(valueOr(cfg.SlowQueryThreshold, DefaultSlowQueryThreshold), 0),
+		Explain:       cfg.ExplainSlowQueries,
+		DB:            db,
+		Metrics:       cfg.Metrics,
+	}
+}
+
+func valueOr[V int | time.Duration](v, def V) V {
+	if v == 0 {
+		return def
+	}
+	return v
 }
 
 type Client struct {
-	db     *bun.DB
-	config *Config
-	logger *zap.Logger
+	db       *bun.DB
+	replicas *replicaSet
+	config   *Config
+	logger   *zap.Logger
 }
 
 func NewClient(cfg *Config, l *zap.Logger) (*Client, error) {
@@ -31,6 +96,7 @@ func NewClient(cfg *Config, l *zap.Logger) (*Client, error) {
 	)
 
 	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(cfg.Datasource)))
+	cfg.configurePool(sqldb)
 
 	if err := sqldb.Ping(); err != nil {
 		l.Error("PG/CONN FAILED", zap.Error(err))
@@ -39,9 +105,10 @@ func NewClient(cfg *Config, l *zap.Logger) (*Client, error) {
 	}
 
 	db := bun.NewDB(sqldb, pgdialect.New())
+	cfg.Metrics.trackPool(sqldb, cfg.Database, "primary")
 
 	// Add custom zap logger for query hooks
-	db.AddQueryHook(&ZapQueryHook{Logger: l})
+	db.AddQueryHook(cfg.queryHook(db, l))
 
 	// db.AddQueryHook(bundebug.NewQueryHook(
 	// 	bundebug.WithVerbose(true),
@@ -49,19 +116,46 @@ func NewClient(cfg *Config, l *zap.Logger) (*Client, error) {
 
 	l.Info("PG/CONN CONNECTED", zap.String("action", "connection"))
 
-	return &Client{
+	c := &Client{
 		db:     db,
 		config: cfg,
 		logger: l,
-	}, nil
+	}
+
+	if len(cfg.Replicas) > 0 {
+		c.replicas = openReplicas(cfg, l)
+		replicaSets.Store(db, c.replicas)
+	}
+
+	return c, nil
 }
 
 func (c *Client) GetDB() *bun.DB {
 	return c.db
 }
 
+// GetReadDB returns a healthy replica for ad-hoc read-only queries, or the primary
+// when there is none. Replicas lag behind the primary, so read your own writes from
+// GetDB.
+func (c *Client) GetReadDB() bun.IDB {
+	if c.replicas != nil {
+		if r := c.replicas.pick(); r != nil {
+			return r.db
+		}
+	}
+	return c.db
+}
+
 func (c *Client) Close() error {
-	client.logger.Info("PG/CONN CLOSED")
+	c.logger.Info("PG/CONN CLOSED")
+	c.config.Metrics.untrackPool(c.db.DB)
+
+	if c.replicas != nil {
+		replicaSets.Delete(c.db)
+		if err := c.replicas.close(); err != nil {
+			c.logger.Warn("PG/REPLICA CLOSE FAILED", zap.Error(err))
+		}
+	}
 
 	return c.db.Close()
 }
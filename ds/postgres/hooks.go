@@ -12,8 +12,25 @@ import (
 	"go.uber.org/zap"
 )
 
+// explainTimeout bounds an EXPLAIN ANALYZE of a slow query.
+const explainTimeout = 30 * time.Second
+
+type explainKey struct{}
+
 type ZapQueryHook struct {
 	Logger *zap.Logger
+
+	// SlowThreshold logs queries taking longer at WARN as "PG/QUERY SLOW"; zero
+	// disables it.
+	SlowThreshold time.Duration
+
+	// Explain runs EXPLAIN ANALYZE on DB for slow SELECTs and logs the plan. It
+	// executes the query a second time, so enable it in development only.
+	Explain bool
+	DB      bun.IDB
+
+	// Metrics records the query latency and errors by operation, when not nil.
+	Metrics *Metrics
 }
 
 func (h *ZapQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
@@ -22,20 +39,71 @@ func (h *ZapQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) c
 }
 
 func (h *ZapQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	if ctx.Value(explainKey{}) != nil {
+		return
+	}
+
+	duration := time.Since(event.StartTime)
+	h.Metrics.queryDone(event.Operation(), duration.Seconds(), event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows))
+
 	log := h.Logger.With(
 		zap.String("event", event.Operation()),
 		zap.String("query", strings.ReplaceAll(event.Query, "\"", "")),
 		zap.String("request_id", common.GetContextRequestID(ctx)),
-		zap.Duration("duration", time.Since(event.StartTime)),
+		zap.Duration("duration", duration),
 	)
 
-	if event.Err != nil {
+	switch {
+	case event.Err != nil:
 		if errors.Is(event.Err, sql.ErrNoRows) {
 			log.Warn("PG/QUERY", zap.Error(event.Err))
 		} else {
 			log.Error("PG/QUERY ", zap.Error(event.Err))
 		}
-	} else {
+	case h.SlowThreshold > 0 && duration > h.SlowThreshold:
+		if event.Result != nil {
+			if rows, err := event.Result.RowsAffected(); err == nil {
+				log = log.With(zap.Int64("rows", rows))
+			}
+		}
+		log.Warn("PG/QUERY SLOW", zap.Duration("threshold", h.SlowThreshold))
+
+		if h.Explain && h.DB != nil && event.Operation() == "SELECT" {
+			go h.explain(ctx, event.Query, log)
+		}
+	default:
 		log.Info("PG/QUERY")
 	}
 }
+
+// explain logs the plan of a slow query without holding up the caller. The query is
+// explained with the search_path of the tenant in ctx, as it ran.
+func (h *ZapQueryHook) explain(ctx context.Context, query string, log *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), explainTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, explainKey{}, true)
+
+	var plan []string
+	err := tenantScoped(ctx, h.DB, func(db bun.IDB) error {
+		rows, err := db.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				return err
+			}
+			plan = append(plan, line)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Warn("PG/QUERY EXPLAIN FAILED", zap.Error(err))
+		return
+	}
+
+	log.Warn("PG/QUERY EXPLAIN", zap.String("plan", strings.Join(plan, "\n")))
+}
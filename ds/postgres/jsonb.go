@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+)
+
+// JSON returns value marshalled as a jsonb literal, for comparisons and updates of
+// JSONB columns; a marshalling error fails the query.
+func JSON(value any) schema.QueryAppender {
+	return jsonValue{value}
+}
+
+type jsonValue struct {
+	value any
+}
+
+func (v jsonValue) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	data, err := json.Marshal(v.value)
+	if err != nil {
+		return nil, err
+	}
+	b = fmter.Dialect().AppendString(b, string(data))
+	return append(b, "::jsonb"...), nil
+}
+
+// JSONPath returns the text at path of a JSONB column (column #>> '{a,b}'), for use
+// as an expression in Where or OrderExpr, e.g.
+// q.Where("? = ?", JSONPath("metadata", "cod", "status"), "paid").
+func JSONPath(column string, path ...string) schema.QueryWithArgs {
+	return bun.SafeQuery("? #>> ?", bun.Ident(column), pgdialect.Array(path))
+}
+
+// JSONSet returns column with the value at path replaced or added (jsonb_set), as a
+// value of UpdateWhere to update part of a document without rewriting it, e.g.
+// map[string]any{"metadata": JSONSet("metadata", []string{"cod", "status"}, "paid")}.
+// Missing intermediate objects are not created, see jsonb_set.
+func JSONSet(column string, path []string, value any) schema.QueryWithArgs {
+	return bun.SafeQuery("jsonb_set(?, ?, ?, true)", bun.Ident(column), pgdialect.Array(path), JSON(value))
+}
+
+// JSONContains filters the rows whose JSONB column contains value (@>), e.g.
+// JSONContains("metadata", map[string]any{"service": "express"}). It can use a GIN
+// index on column.
+func JSONContains(column string, value any) CustomQueryFn {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("? @> ?", bun.Ident(column), JSON(value))
+	}
+}
+
+// JSONPathEquals filters the rows whose JSONB column holds value at path, compared
+// as JSON so numbers and booleans match their type.
+func JSONPathEquals(column string, path []string, value any) CustomQueryFn {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("? #> ? = ?", bun.Ident(column), pgdialect.Array(path), JSON(value))
+	}
+}
+
+// JSONHasKey filters the rows whose JSONB column has key at its top level.
+func JSONHasKey(column, key string) CustomQueryFn {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		// jsonb_exists is the ? operator, which would clash with placeholders
+		return q.Where("jsonb_exists(?, ?)", bun.Ident(column), key)
+	}
+}
+
+// Queries combines query functions into one CustomQueryFn applying them in order,
+// e.g. to pass several JSONB filters to FindAll.
+func Queries(fns ...CustomQueryFn) CustomQueryFn {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, fn := range fns {
+			if fn != nil {
+				q = fn(q)
+			}
+		}
+		return q
+	}
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"go.uber.org/zap"
+)
+
+const (
+	// listenerPingInterval is how long the listener waits for a notification before
+	// pinging its connection; a ping unanswered for as long again reconnects.
+	listenerPingInterval = 30 * time.Second
+	listenerPingChannel  = "engine_listener_ping"
+	listenerMaxBackoff   = 30 * time.Second
+)
+
+// NotifyHandler handles the payload of a notification.
+type NotifyHandler func(ctx context.Context, payload string) error
+
+// Listener subscribes to LISTEN/NOTIFY channels on a dedicated connection and
+// dispatches the notifications to handlers, one at a time in the order received. It
+// reconnects with backoff when the connection drops; notifications sent meanwhile
+// are lost, so OnReconnect hooks should resync, e.g. flush a cache.
+type Listener struct {
+	db     *bun.DB
+	logger *zap.Logger
+
+	mu          sync.RWMutex
+	handlers    map[string][]NotifyHandler
+	onReconnect []func(ctx context.Context)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewListener creates a listener on the connection settings of db, which must use
+// pgdriver like NewClient does.
+func NewListener(db *bun.DB, l *zap.Logger) *Listener {
+	return &Listener{
+		db:       db,
+		logger:   l.With(zap.String("component", "ds.postgres.listener")),
+		handlers: map[string][]NotifyHandler{},
+	}
+}
+
+// Handle registers a handler for the raw payloads of channel. Register handlers
+// before Start.
+func (l *Listener) Handle(channel string, handler NotifyHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[channel] = append(l.handlers[channel], handler)
+}
+
+// HandleTyped registers a handler receiving the JSON payloads of channel decoded
+// into T, as sent by Notify.
+func HandleTyped[T any](l *Listener, channel string, handler func(ctx context.Context, payload T) error) {
+	l.Handle(channel, func(ctx context.Context, raw string) error {
+		var payload T
+		if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+			return fmt.Errorf("decode %s payload: %w", channel, err)
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// OnReconnect registers a hook run after the listener reconnected, to recover from
+// the notifications missed while it was disconnected.
+func (l *Listener) OnReconnect(hook func(ctx context.Context)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReconnect = append(l.onReconnect, hook)
+}
+
+// Start subscribes to the channels with handlers and receives in the background until
+// ctx is done or Close. Only the first subscription error is returned; later
+// connection failures are retried.
+func (l *Listener) Start(ctx context.Context) error {
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+
+	ln, err := l.listen(ctx)
+	if err != nil {
+		l.cancel()
+		close(l.done)
+		l.logger.Error("PG/LISTEN FAILED", zap.Error(err))
+		return err
+	}
+
+	l.logger.Info("PG/LISTEN STARTED", zap.Strings("channels", l.channels()))
+	go l.run(ctx, ln)
+	return nil
+}
+
+// Close stops the listener and waits for the handler in progress.
+func (l *Listener) Close() error {
+	if l.cancel == nil {
+		return nil
+	}
+	l.cancel()
+	<-l.done
+	return nil
+}
+
+// Notify sends payload, marshalled as JSON, to the listeners of channel. Within a
+// transaction (db a bun.Tx) it is delivered on commit and dropped on rollback.
+// Payloads are limited to 8000 bytes, so send keys to invalidate rather than rows.
+func Notify(ctx context.Context, db bun.IDB, channel string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "SELECT pg_notify(?, ?)", channel, string(data))
+	return err
+}
+
+func (l *Listener) channels() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Sorted(maps.Keys(l.handlers))
+}
+
+func (l *Listener) listen(ctx context.Context) (*pgdriver.Listener, error) {
+	ln := pgdriver.NewListener(l.db)
+	if err := ln.Listen(ctx, append(l.channels(), listenerPingChannel)...); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// run receives until ctx is done, reconnecting with jittered exponential backoff.
+func (l *Listener) run(ctx context.Context, ln *pgdriver.Listener) {
+	defer close(l.done)
+
+	for {
+		err := l.receive(ctx, ln)
+		_ = ln.Close()
+		if ctx.Err() != nil {
+			l.logger.Info("PG/LISTEN STOPPED")
+			return
+		}
+		l.logger.Warn("PG/LISTEN DISCONNECTED", zap.Error(err))
+
+		if ln = l.reconnect(ctx); ln == nil {
+			l.logger.Info("PG/LISTEN STOPPED")
+			return
+		}
+		l.logger.Info("PG/LISTEN RECONNECTED")
+
+		l.mu.RLock()
+		hooks := l.onReconnect
+		l.mu.RUnlock()
+		for _, hook := range hooks {
+			l.safely("reconnect", func() error { hook(ctx); return nil })
+		}
+	}
+}
+
+func (l *Listener) reconnect(ctx context.Context) *pgdriver.Listener {
+	delay := time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		ln, err := l.listen(ctx)
+		if err == nil {
+			return ln
+		}
+
+		l.logger.Warn("PG/LISTEN RECONNECT FAILED", zap.Duration("retry_in", delay), zap.Error(err))
+		delay = min(delay*2, listenerMaxBackoff)
+	}
+}
+
+// receive dispatches notifications until the connection fails. When idle it pings
+// itself through the pool, so a connection that silently died is detected.
+func (l *Listener) receive(ctx context.Context, ln *pgdriver.Listener) error {
+	// Closing the listener unblocks the read on shutdown
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+
+	pinged := false
+	for {
+		channel, payload, err := ln.ReceiveTimeout(ctx, listenerPingInterval)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return err
+			}
+			if pinged {
+				return errors.New("ping timeout")
+			}
+			if err := pgdriver.Notify(ctx, l.db, listenerPingChannel, ""); err != nil {
+				return err
+			}
+			pinged = true
+			continue
+		}
+
+		// Any notification proves the connection alive
+		pinged = false
+		if channel != listenerPingChannel {
+			l.dispatch(ctx, channel, payload)
+		}
+	}
+}
+
+func (l *Listener) dispatch(ctx context.Context, channel, payload string) {
+	l.mu.RLock()
+	handlers := l.handlers[channel]
+	l.mu.RUnlock()
+
+	for _, handler := range handlers {
+		l.safely(channel, func() error { return handler(ctx, payload) })
+	}
+}
+
+// safely runs fn, logging its error or panic.
+func (l *Listener) safely(channel string, fn func() error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			l.logger.Error("PG/LISTEN HANDLER PANICKED", zap.String("channel", channel), zap.Any("error", rec))
+		}
+	}()
+
+	if err := fn(); err != nil {
+		l.logger.Error("PG/LISTEN HANDLER FAILED", zap.String("channel", channel), zap.Error(err))
+	}
+}
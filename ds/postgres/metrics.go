@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors of the PostgreSQL clients, see
+// Config.Metrics. A nil *Metrics records nothing.
+type Metrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	pool     *poolCollector
+}
+
+// NewMetrics creates the query and connection pool collectors under namespace and
+// registers them with reg. Share one Metrics between the clients of a process; their
+// pools are told apart by the database and role labels.
+func NewMetrics(namespace string, reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pg_query_duration_seconds",
+			Help:      "Query latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pg_query_errors_total",
+			Help:      "Total number of failed queries, not counting sql.ErrNoRows.",
+		}, []string{"operation"}),
+		pool: newPoolCollector(namespace),
+	}
+
+	reg.MustRegister(m.duration, m.errors, m.pool)
+	return m
+}
+
+func (m *Metrics) queryDone(operation string, seconds float64, failed bool) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(operation).Observe(seconds)
+	if failed {
+		m.errors.WithLabelValues(operation).Inc()
+	}
+}
+
+// trackPool reports the pool stats of db until untrackPool.
+func (m *Metrics) trackPool(db *sql.DB, database, role string) {
+	if m != nil {
+		m.pool.add(db, database, role)
+	}
+}
+
+func (m *Metrics) untrackPool(db *sql.DB) {
+	if m != nil {
+		m.pool.remove(db)
+	}
+}
+
+type poolLabels struct {
+	database string
+	role     string
+}
+
+// poolCollector reads sql.DB stats at scrape time.
+type poolCollector struct {
+	mu  sync.RWMutex
+	dbs map[*sql.DB]poolLabels
+
+	maxOpen      *prometheus.Desc
+	connections  *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newPoolCollector(namespace string) *poolCollector {
+	labels := []string{"database", "role"}
+
+	return &poolCollector{
+		dbs: map[*sql.DB]poolLabels{},
+		maxOpen: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "pg_pool_max_open_connections"),
+			"Maximum number of open connections of the pool, 0 for no limit.",
+			labels, nil,
+		),
+		connections: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "pg_pool_connections"),
+			"Number of open connections of the pool by state (in_use or idle).",
+			append(labels, "state"), nil,
+		),
+		waitCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "pg_pool_wait_count_total"),
+			"Total number of connections waited for because the pool was exhausted.",
+			labels, nil,
+		),
+		waitDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "pg_pool_wait_duration_seconds_total"),
+			"Total time blocked waiting for a connection, in seconds.",
+			labels, nil,
+		),
+	}
+}
+
+func (c *poolCollector) add(db *sql.DB, database, role string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dbs[db] = poolLabels{database: database, role: role}
+}
+
+func (c *poolCollector) remove(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.dbs, db)
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.connections
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for db, l := range c.dbs {
+		s := db.Stats()
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), l.database, l.role)
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.InUse), l.database, l.role, "in_use")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Idle), l.database, l.role, "idle")
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount), l.database, l.role)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds(), l.database, l.role)
+	}
+}
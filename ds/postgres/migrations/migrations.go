@@ -0,0 +1,203 @@
+// Package migrations applies versioned schema migrations to a PostgreSQL database.
+// Migrations are SQL files read from an fs.FS (usually an embed.FS) and/or Go
+// functions, recorded in a versions table and serialized across replicas with a
+// PostgreSQL advisory lock, so every pod of a service can run them on startup.
+package migrations
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"regexp"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+	"go.uber.org/zap"
+)
+
+// DefaultTable is the versions table used when Config.Table is empty.
+const DefaultTable = "schema_migrations"
+
+// ErrUnknownCommand is returned by Run for a command other than up, down or status.
+var ErrUnknownCommand = errors.New("migrations: unknown command")
+
+// MigrationFunc is a Go migration step, run against the database outside a
+// transaction; use db.RunInTx when the step needs one.
+type MigrationFunc = migrate.MigrationFunc
+
+// Config configures a Migrator.
+type Config struct {
+	// FS holds the SQL migrations named <version>_<name>.up.sql and
+	// <version>_<name>.down.sql, e.g. 20250601120000_create_shipments.up.sql. Use
+	// .tx.up.sql / .tx.down.sql to run a file in a transaction. Optional when every
+	// migration is registered in Go.
+	FS fs.FS
+
+	Table string // versions table, default DefaultTable
+}
+
+// Migrator applies and rolls back migrations.
+type Migrator struct {
+	db         *bun.DB
+	migrations *migrate.Migrations
+	migrator   *migrate.Migrator
+	lockKey    int64
+	logger     *zap.Logger
+}
+
+var nameRE = regexp.MustCompile(`^(\d{1,14})_([0-9a-z_\-]+)$`)
+
+// New creates a Migrator and loads the SQL migrations of cfg.FS.
+func New(db *bun.DB, cfg *Config, l *zap.Logger) (*Migrator, error) {
+	table := cfg.Table
+	if table == "" {
+		table = DefaultTable
+	}
+
+	ms := migrate.NewMigrations()
+	if cfg.FS != nil {
+		if err := ms.Discover(cfg.FS); err != nil {
+			return nil, fmt.Errorf("migrations: %w", err)
+		}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte("migrations:" + table))
+
+	return &Migrator{
+		db:         db,
+		migrations: ms,
+		migrator: migrate.NewMigrator(db, ms,
+			migrate.WithTableName(table),
+			migrate.WithLocksTableName(table+"_locks"),
+			// A failed migration must be retried on the next run, not recorded as applied
+			migrate.WithMarkAppliedOnSuccess(true),
+		),
+		lockKey: int64(h.Sum64()),
+		logger:  l.With(zap.String("component", "ds.postgres.migrations"), zap.String("table", table)),
+	}, nil
+}
+
+// Register adds a Go migration named like the SQL files without extension, e.g.
+// "20250601120000_backfill_tracking_codes". down may be nil.
+func (m *Migrator) Register(name string, up, down MigrationFunc) error {
+	matches := nameRE.FindStringSubmatch(name)
+	if matches == nil {
+		return fmt.Errorf("migrations: unsupported migration name format: %q", name)
+	}
+
+	migration := migrate.Migration{Name: matches[1], Comment: matches[2]}
+	if up != nil {
+		migration.Up = func(ctx context.Context, db *bun.DB, _ any) error { return up(ctx, db) }
+	}
+	if down != nil {
+		migration.Down = func(ctx context.Context, db *bun.DB, _ any) error { return down(ctx, db) }
+	}
+	m.migrations.Add(migration)
+
+	return nil
+}
+
+// Migrate applies every pending migration in version order, waiting for other
+// replicas running migrations on the same table first. It matches engine.StartHook so
+// it can be registered with engine.OnStart(m.Migrate).
+func (m *Migrator) Migrate(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		group, err := m.migrator.Migrate(ctx)
+		if err != nil {
+			m.logger.Error("PG/MIGRATE FAILED", zap.Error(err))
+			return err
+		}
+
+		if group.IsZero() {
+			m.logger.Info("PG/MIGRATE UP TO DATE")
+		} else {
+			m.logger.Info("PG/MIGRATE APPLIED", zap.Stringer("group", group))
+		}
+		return nil
+	})
+}
+
+// Rollback reverts the last group of migrations, i.e. those applied by the last
+// Migrate that did anything.
+func (m *Migrator) Rollback(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		group, err := m.migrator.Rollback(ctx)
+		if err != nil {
+			m.logger.Error("PG/MIGRATE ROLLBACK FAILED", zap.Error(err))
+			return err
+		}
+
+		if group.IsZero() {
+			m.logger.Info("PG/MIGRATE NOTHING TO ROLLBACK")
+		} else {
+			m.logger.Info("PG/MIGRATE ROLLED BACK", zap.Stringer("group", group))
+		}
+		return nil
+	})
+}
+
+// Status returns every known migration; applied ones have a non-zero GroupID.
+func (m *Migrator) Status(ctx context.Context) (ms migrate.MigrationSlice, err error) {
+	err = m.locked(ctx, func() error {
+		ms, err = m.migrator.MigrationsWithStatus(ctx)
+		return err
+	})
+	return
+}
+
+// Run executes a CLI command: "up" migrates, "down" rolls back the last group and
+// "status" logs the applied and pending migrations. It lets a service expose
+// migrations as e.g. `service migrate up` without its own wiring.
+func (m *Migrator) Run(ctx context.Context, command string) error {
+	switch command {
+	case "up":
+		return m.Migrate(ctx)
+	case "down":
+		return m.Rollback(ctx)
+	case "status":
+		ms, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		m.logger.Info("PG/MIGRATE STATUS",
+			zap.Stringer("applied", ms.Applied()),
+			zap.Stringer("pending", ms.Unapplied()),
+		)
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownCommand, command)
+}
+
+// locked runs fn holding a session-level advisory lock on a dedicated connection,
+// blocking while another replica holds it, once the versions table exists.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", m.lockKey); err != nil {
+		m.logger.Error("PG/MIGRATE LOCK FAILED", zap.Error(err))
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(?)", m.lockKey); err != nil {
+			m.logger.Warn("PG/MIGRATE UNLOCK FAILED", zap.Error(err))
+			// Closing the conn would return its session, still holding the lock, to the
+			// pool; discard it instead so ending the session releases the lock
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if err := m.migrator.Init(ctx); err != nil {
+		m.logger.Error("PG/MIGRATE FAILED", zap.Error(err))
+		return err
+	}
+
+	return fn()
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// DefaultPageLimit is the page size of FindPage when limit is not positive.
+const DefaultPageLimit = 25
+
+// ErrInvalidCursor is returned by FindPage for a cursor it did not issue for the
+// same order.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// keysetColumn is a column of a keyset order.
+type keysetColumn struct {
+	field *schema.Field
+	desc  bool
+}
+
+// pageCursor is the JSON form of a page cursor: the order it was issued for and the
+// order values of the last row of the page.
+type pageCursor struct {
+	Order  string `json:"o"`
+	Values []any  `json:"v"`
+}
+
+// FindPage returns up to limit entities after cursor in the given order, plus the
+// cursor of the next page, empty on the last page. Unlike FindAll's OFFSET it seeks
+// with a WHERE on the order columns, so every page costs the same however deep; pass
+// an empty cursor for the first page.
+//
+// order uses FindAll's syntax ("-created_at" for descending) limited to the entity's
+// own non-null columns, default "-id"; the primary key is appended as a tie-breaker
+// in the direction of the last column, so index e.g. (created_at DESC, id DESC).
+// customQuery may filter but must not change the order or limit. Soft-deleted rows
+// are excluded and default relations loaded as in FindAll.
+func (r *BaseRepository[T]) FindPage(cursor string, limit int, order []string, customQuery CustomQueryFn) ([]*T, string, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if len(order) == 0 {
+		order = []string{"-id"}
+	}
+
+	table := r.DB.Dialect().Tables().Get(reflect.TypeFor[T]())
+	cols, err := keysetOrder(table, r.table, order)
+	if err != nil {
+		return nil, "", err
+	}
+	orderKey := keysetOrderKey(cols)
+
+	var values []any
+	if cursor != "" {
+		if values, err = decodeCursor(cursor, orderKey, len(cols)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var result []*T
+	err = r.read(func(db bun.IDB) error {
+		result = nil
+
+		q := db.NewSelect().Model(&result)
+
+		r.excludeTrashed(q.QueryBuilder())
+
+		for _, rel := range r.defaultRelations {
+			q.Relation(rel)
+		}
+
+		if customQuery != nil {
+			q = customQuery(q)
+		}
+
+		if values != nil {
+			q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return keysetWhere(q, cols, values)
+			})
+		}
+
+		for _, c := range cols {
+			if c.desc {
+				q.OrderExpr("?TableAlias.? DESC", bun.Ident(c.field.Name))
+			} else {
+				q.OrderExpr("?TableAlias.? ASC", bun.Ident(c.field.Name))
+			}
+		}
+
+		// One extra row tells whether there is a next page
+		return q.Limit(limit + 1).Scan(r.Context)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(result) <= limit {
+		return result, "", nil
+	}
+	result = result[:limit]
+
+	next, err := encodeCursor(orderKey, cols, result[limit-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return result, next, nil
+}
+
+// keysetOrder resolves order to columns of table and appends the primary key.
+func keysetOrder(table *schema.Table, alias string, order []string) ([]keysetColumn, error) {
+	var cols []keysetColumn
+	seen := map[string]bool{}
+
+	for _, o := range order {
+		c := keysetColumn{}
+		if strings.HasPrefix(o, "-") {
+			c.desc = true
+			o = o[1:]
+		}
+		o = strings.TrimPrefix(o, alias+".")
+
+		field, ok := table.FieldMap[o]
+		if !ok {
+			return nil, fmt.Errorf("FindPage: %q is not a column of %s", o, table.Name)
+		}
+		if seen[field.Name] {
+			continue
+		}
+		seen[field.Name] = true
+
+		c.field = field
+		cols = append(cols, c)
+	}
+
+	// The tie-breaker follows the last column so one index serves the whole order
+	desc := len(cols) > 0 && cols[len(cols)-1].desc
+	for _, pk := range table.PKs {
+		if !seen[pk.Name] {
+			cols = append(cols, keysetColumn{field: pk, desc: desc})
+		}
+	}
+
+	return cols, nil
+}
+
+// keysetWhere adds the rows after values in the order of cols:
+// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ..., with < for descending columns.
+func keysetWhere(q *bun.SelectQuery, cols []keysetColumn, values []any) *bun.SelectQuery {
+	for i := range cols {
+		q = q.WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for j := range i {
+				q = q.Where("?TableAlias.? = ?", bun.Ident(cols[j].field.Name), values[j])
+			}
+
+			op := ">"
+			if cols[i].desc {
+				op = "<"
+			}
+			return q.Where("?TableAlias.? "+op+" ?", bun.Ident(cols[i].field.Name), values[i])
+		})
+	}
+	return q
+}
+
+func keysetOrderKey(cols []keysetColumn) string {
+	keys := make([]string, len(cols))
+	for i, c := range cols {
+		keys[i] = c.field.Name
+		if c.desc {
+			keys[i] = "-" + keys[i]
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+func encodeCursor(orderKey string, cols []keysetColumn, last any) (string, error) {
+	strct := reflect.ValueOf(last).Elem()
+
+	c := pageCursor{Order: orderKey, Values: make([]any, len(cols))}
+	for i, col := range cols {
+		c.Values[i] = col.field.Value(strct).Interface()
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the order values of a cursor. Numbers are kept as
+// json.Number, so they are sent as literals PostgreSQL casts to the column type
+// without losing precision, as are times and strings.
+func decodeCursor(s, orderKey string, n int) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var c pageCursor
+	if err := dec.Decode(&c); err != nil || c.Order != orderKey || len(c.Values) != n {
+		return nil, ErrInvalidCursor
+	}
+	return c.Values, nil
+}
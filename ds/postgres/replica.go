@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"go.uber.org/zap"
+)
+
+// replicaCheckInterval is how often replicas are pinged to update their health.
+const replicaCheckInterval = 5 * time.Second
+
+// replicaSets maps a primary *bun.DB to its read replicas so repositories built on
+// the primary route their reads without holding the Client.
+var replicaSets sync.Map // *bun.DB -> *replicaSet
+
+type replica struct {
+	db      *bun.DB
+	healthy atomic.Bool
+}
+
+// replicaSet round-robins reads over the healthy replicas of a primary.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	logger   *zap.Logger
+	metrics  *Metrics
+}
+
+// openReplicas connects to the replica DSNs of cfg. A replica that is down at startup
+// is kept and used once the health check sees it back.
+func openReplicas(cfg *Config, l *zap.Logger) *replicaSet {
+	s := &replicaSet{stop: make(chan struct{}), logger: l, metrics: cfg.Metrics}
+
+	for i, dsn := range cfg.Replicas {
+		rl := l.With(zap.Int("replica", i))
+
+		sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+		cfg.configurePool(sqldb)
+		cfg.Metrics.trackPool(sqldb, cfg.Database, fmt.Sprintf("replica_%d", i))
+		db := bun.NewDB(sqldb, pgdialect.New())
+		db.AddQueryHook(cfg.queryHook(db, rl))
+
+		r := &replica{db: db}
+		if err := sqldb.Ping(); err != nil {
+			rl.Warn("PG/REPLICA DOWN", zap.Error(err))
+		} else {
+			r.healthy.Store(true)
+			rl.Info("PG/REPLICA CONNECTED")
+		}
+		s.replicas = append(s.replicas, r)
+	}
+
+	go s.monitor()
+
+	return s
+}
+
+// pick returns the next healthy replica, nil when none is.
+func (s *replicaSet) pick() *replica {
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return healthy[s.next.Add(1)%uint64(len(healthy))]
+}
+
+func (s *replicaSet) monitor() {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for i, r := range s.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			err := r.db.PingContext(ctx)
+			cancel()
+
+			if healthy := err == nil; r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					s.logger.Info("PG/REPLICA UP", zap.Int("replica", i))
+				} else {
+					s.logger.Warn("PG/REPLICA DOWN", zap.Int("replica", i), zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// markDown takes a replica out of rotation until the next successful health check.
+func (s *replicaSet) markDown(r *replica, err error) {
+	if r.healthy.Swap(false) {
+		s.logger.Warn("PG/REPLICA DOWN", zap.Error(err))
+	}
+}
+
+func (s *replicaSet) close() error {
+	close(s.stop)
+
+	var errs []error
+	for _, r := range s.replicas {
+		s.metrics.untrackPool(r.db.DB)
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// replicasOf returns the replicas of db, nil when db is not a primary with replicas,
+// e.g. a transaction.
+func replicasOf(db bun.IDB) *replicaSet {
+	primary, ok := db.(*bun.DB)
+	if !ok {
+		return nil
+	}
+	if s, ok := replicaSets.Load(primary); ok {
+		return s.(*replicaSet)
+	}
+	return nil
+}
+
+// readFrom runs the read-only query fn on a healthy replica of db, or on db itself
+// when it has none. A replica whose connection fails is marked down and fn is
+// retried on db.
+func readFrom(db bun.IDB, fn func(db bun.IDB) error) error {
+	s := replicasOf(db)
+	if s == nil {
+		return fn(db)
+	}
+
+	r := s.pick()
+	if r == nil {
+		return fn(db)
+	}
+
+	err := fn(r.db)
+	if err != nil && isConnError(err) {
+		s.markDown(r, err)
+		return fn(db)
+	}
+	return err
+}
+
+// isConnError reports whether err means the connection, not the query, failed.
+func isConnError(err error) bool {
+	// context.DeadlineExceeded is a net.Error too, but the caller gave up
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// SearchMode selects how FindAll matches QueryOption.Search against the search
+// fields of a repository.
+type SearchMode int
+
+const (
+	// SearchILike matches substrings with ILIKE, see FilterSearch. It is the default
+	// and needs no index, but scans the table.
+	SearchILike SearchMode = iota
+	// SearchFullText matches words with full-text search, see FilterFullTextSearch,
+	// and ranks rows by ts_rank.
+	SearchFullText
+	// SearchTrigram matches similar words with pg_trgm, see FilterTrigramSearch, and
+	// ranks rows by similarity. It tolerates typos and partial words.
+	SearchTrigram
+)
+
+// DefaultSearchLanguage is the text search configuration of SearchFullText when
+// SearchOptions.Language is empty. It does not stem, which suits names and
+// addresses.
+const DefaultSearchLanguage = "simple"
+
+// SearchOptions configures the search of FindAll, see WithSearch.
+type SearchOptions struct {
+	Mode     SearchMode
+	Language string // text search configuration of SearchFullText, e.g. "indonesian"
+}
+
+// WithSearch returns a copy of the repository whose FindAll searches with opts. With
+// SearchFullText or SearchTrigram, results are ordered by rank unless
+// QueryOption.OrderBy is set, the default order breaking ties.
+func (r *BaseRepository[T]) WithSearch(opts SearchOptions) *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.search = opts
+	return repo
+}
+
+// filterSearch adds the search filter of the repository's mode to q, ordered by rank
+// when ranked is set.
+func (r *BaseRepository[T]) filterSearch(q *bun.SelectQuery, search string, ranked bool) {
+	switch r.search.Mode {
+	case SearchFullText:
+		language := r.search.Language
+		if language == "" {
+			language = DefaultSearchLanguage
+		}
+		FilterFullTextSearch(q, search, language, r.searchFields...)
+		if ranked {
+			q.OrderExpr("? DESC", FullTextRank(search, language, r.searchFields...))
+		}
+	case SearchTrigram:
+		FilterTrigramSearch(q, search, r.searchFields...)
+		if ranked {
+			q.OrderExpr("? DESC", TrigramRank(search, r.searchFields...))
+		}
+	default:
+		FilterSearch(q, search, r.searchFields...)
+	}
+}
+
+// FilterFullTextSearch adds a full-text search filter matching any of the fields
+// against search, parsed with websearch_to_tsquery ("quoted phrases", -excluded,
+// or). Each field can use an expression index such as
+// CREATE INDEX ON shipments USING gin (to_tsvector('simple', consignee_name)).
+func FilterFullTextSearch(q *bun.SelectQuery, search, language string, fields ...string) {
+	q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, field := range fields {
+			q = q.WhereOr(
+				fmt.Sprintf("to_tsvector(?::regconfig, %s) @@ websearch_to_tsquery(?::regconfig, ?)", field),
+				language, language, search,
+			)
+		}
+		return q
+	})
+}
+
+// FullTextRank returns the ts_rank of the fields against search, for ordering the
+// matches of FilterFullTextSearch.
+func FullTextRank(search, language string, fields ...string) schema.QueryWithArgs {
+	query := "ts_rank("
+	var args []any
+	for i, field := range fields {
+		if i > 0 {
+			query += " || "
+		}
+		query += fmt.Sprintf("to_tsvector(?::regconfig, coalesce(%s, ''))", field)
+		args = append(args, language)
+	}
+	query += ", websearch_to_tsquery(?::regconfig, ?))"
+	args = append(args, language, search)
+
+	return bun.SafeQuery(query, args...)
+}
+
+// FilterTrigramSearch adds a pg_trgm filter matching the fields having a word similar
+// to search (search <% field, see pg_trgm.word_similarity_threshold). It needs the
+// pg_trgm extension, and each field can use an index such as
+// CREATE INDEX ON shipments USING gin (consignee_name gin_trgm_ops).
+func FilterTrigramSearch(q *bun.SelectQuery, search string, fields ...string) {
+	q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, field := range fields {
+			q = q.WhereOr(fmt.Sprintf("? <%% %s", field), search)
+		}
+		return q
+	})
+}
+
+// TrigramRank returns the best word similarity of the fields to search, for ordering
+// the matches of FilterTrigramSearch.
+func TrigramRank(search string, fields ...string) schema.QueryWithArgs {
+	if len(fields) == 1 {
+		return bun.SafeQuery(fmt.Sprintf("word_similarity(?, %s)", fields[0]), search)
+	}
+
+	query := "greatest("
+	var args []any
+	for i, field := range fields {
+		if i > 0 {
+			query += ", "
+		}
+		query += fmt.Sprintf("word_similarity(?, %s)", field)
+		args = append(args, search)
+	}
+	query += ")"
+
+	return bun.SafeQuery(query, args...)
+}
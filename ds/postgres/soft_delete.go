@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// softDeleteColumns describes how T marks soft-deleted rows.
+type softDeleteColumns struct {
+	deletedAt bool // a deleted_at timestamp, NULL while the row is live
+	isDeleted bool // an is_deleted flag, kept in sync when deleted_at is used too
+	bunTag    bool // a field tagged soft_delete, which bun filters by itself
+}
+
+func softDeleteColumnsOf(table *schema.Table) softDeleteColumns {
+	_, deletedAt := table.FieldMap["deleted_at"]
+	_, isDeleted := table.FieldMap["is_deleted"]
+
+	return softDeleteColumns{
+		deletedAt: deletedAt,
+		isDeleted: isDeleted,
+		bunTag:    table.SoftDeleteField != nil,
+	}
+}
+
+// WithTrashed returns a copy of the repository whose FindByID, FindAll and FindPage
+// include soft-deleted rows.
+func (r *BaseRepository[T]) WithTrashed() *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.withTrashed = true
+	return repo
+}
+
+// SoftDelete marks the row as deleted: deleted_at is set to the current time when T
+// has that column, is_deleted to true when it has that one. It is a no-op unless soft
+// delete is enabled; deleting an already deleted row keeps its deleted_at.
+func (r *BaseRepository[T]) SoftDelete(id any) error {
+	if !r.enableSoftDelete {
+		return nil
+	}
+
+	return r.write(func(db bun.IDB) error {
+		q := db.NewUpdate().
+			Model((*T)(nil)).
+			Where("id = ?", id)
+
+		_, err := r.markDeleted(q).Exec(r.Context)
+		return err
+	})
+}
+
+// Restore undoes SoftDelete. It is a no-op unless soft delete is enabled.
+func (r *BaseRepository[T]) Restore(id any) error {
+	if !r.enableSoftDelete {
+		return nil
+	}
+
+	return r.write(func(db bun.IDB) error {
+		q := db.NewUpdate().
+			Model((*T)(nil)).
+			Where("id = ?", id)
+
+		if r.softDelete.bunTag {
+			q.WhereAllWithDeleted()
+		}
+
+		if r.softDelete.deletedAt {
+			q.Set("deleted_at = NULL")
+			if r.softDelete.isDeleted {
+				q.Set("is_deleted = false")
+			}
+		} else {
+			q.Set("is_deleted = false")
+		}
+
+		_, err := q.Exec(r.Context)
+		return err
+	})
+}
+
+// ForceDelete permanently deletes the row, whether soft-deleted or not.
+func (r *BaseRepository[T]) ForceDelete(id any) error {
+	return r.write(func(db bun.IDB) error {
+		q := db.NewDelete().
+			Model((*T)(nil)).
+			Where("id = ?", id)
+
+		if r.softDelete.bunTag {
+			q.ForceDelete()
+		}
+
+		_, err := q.Exec(r.Context)
+		return err
+	})
+}
+
+// markDeleted sets the soft delete columns on q, skipping rows already deleted so
+// their deleted_at is kept.
+func (r *BaseRepository[T]) markDeleted(q *bun.UpdateQuery) *bun.UpdateQuery {
+	if !r.softDelete.deletedAt {
+		return q.Set("is_deleted = true")
+	}
+
+	q.Set("deleted_at = current_timestamp").Where("deleted_at IS NULL")
+	if r.softDelete.isDeleted {
+		q.Set("is_deleted = true")
+	}
+	return q
+}
+
+// excludeTrashed filters out soft-deleted rows unless WithTrashed was called.
+func (r *BaseRepository[T]) excludeTrashed(q bun.QueryBuilder) {
+	if r.withTrashed {
+		if r.softDelete.bunTag {
+			q.WhereAllWithDeleted()
+		}
+		return
+	}
+
+	if !r.enableSoftDelete {
+		return
+	}
+
+	// with both columns, rows soft-deleted before deleted_at was added only have
+	// is_deleted set, so both are checked
+	if r.softDelete.deletedAt {
+		q.Where(fmt.Sprintf("%s.deleted_at IS NULL", r.table))
+	}
+	if r.softDelete.isDeleted || !r.softDelete.deletedAt {
+		q.Where(fmt.Sprintf("%s.is_deleted = false", r.table))
+	}
+}
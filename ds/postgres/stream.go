@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/uptrace/bun"
+)
+
+// streamedError wraps an error raised after FindEach delivered rows, hiding it from
+// readFrom so a failed export is not replayed on the primary from the first row.
+type streamedError struct {
+	err error
+}
+
+func (e streamedError) Error() string {
+	return e.err.Error()
+}
+
+// FindEach calls fn with every entity matching opts, reading the rows one at a time
+// from the connection instead of loading them all like FindAll, so exports of
+// millions of rows run in constant memory. opts filters and orders as in FindAll;
+// Limit and Page are ignored, and relations are not loaded. The iteration stops at
+// the first error of fn, which is returned.
+//
+// The connection is held until the iteration ends, so keep fn fast, e.g. writing
+// to a buffered CSV encoder. Within a transaction fn cannot query through it, its
+// connection being busy streaming.
+func (r *BaseRepository[T]) FindEach(opts *common.QueryOption, fn func(*T) error) error {
+	if opts == nil {
+		opts = &common.QueryOption{}
+	}
+
+	err := r.read(func(db bun.IDB) error {
+		q := db.NewSelect().Model((*T)(nil))
+
+		if err := r.applyOptions(q, opts); err != nil {
+			return err
+		}
+
+		q.OrderExpr(RequestSort(opts.GetOrders()))
+
+		rows, err := q.Rows(r.Context)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		delivered := false
+		for rows.Next() {
+			entity := new(T)
+			if err := q.DB().ScanRow(r.Context, rows, entity); err != nil {
+				return streamedError{err}
+			}
+			if err := fn(entity); err != nil {
+				return streamedError{err}
+			}
+			delivered = true
+		}
+
+		if err := rows.Err(); err != nil {
+			if delivered {
+				return streamedError{err}
+			}
+			return err
+		}
+		return nil
+	})
+
+	var streamed streamedError
+	if errors.As(err, &streamed) {
+		return streamed.err
+	}
+	return err
+}
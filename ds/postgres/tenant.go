@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/uptrace/bun"
+)
+
+// tenantScopeKey marks a context whose transaction already has the search_path of
+// its tenant, so repositories using it skip setting it again.
+type tenantScopeKey struct{}
+
+// RunInTenantTx runs fn in a transaction of db whose search_path is the schema of the
+// tenant in ctx (common.WithContextTenant) followed by public, so unqualified tables
+// resolve to the tenant's schema and shared tables to public. Without a tenant it is
+// a plain transaction. The ctx passed to fn carries tx (ContextWithTx), and
+// repositories given it reuse the search_path; within a transaction already in ctx,
+// fn runs in a savepoint of it.
+func RunInTenantTx(ctx context.Context, db bun.IDB, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return runInTenantTx(ctx, db, nil, fn)
+}
+
+// runInTenantTx is RunInTenantTx starting the transaction with opts, e.g. its
+// isolation level, which a savepoint ignores.
+func runInTenantTx(ctx context.Context, db bun.IDB, opts *sql.TxOptions, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return dbFrom(ctx, db).RunInTx(ctx, opts, func(ctx context.Context, tx bun.Tx) error {
+		ctx = ContextWithTx(ctx, tx)
+
+		// A savepoint inherits the search_path of its transaction
+		tenant := common.GetContextTenant(ctx)
+		if tenant != "" && ctx.Value(tenantScopeKey{}) != tenant {
+			if err := setSearchPath(ctx, tx, tenant); err != nil {
+				return err
+			}
+			ctx = context.WithValue(ctx, tenantScopeKey{}, tenant)
+		}
+		return fn(ctx, tx)
+	})
+}
+
+// tenantScoped runs fn on db, or when ctx carries a tenant, with the tenant's
+// search_path: set locally in db when it is a transaction, in a transaction of its
+// own otherwise.
+func tenantScoped(ctx context.Context, db bun.IDB, fn func(db bun.IDB) error) error {
+	tenant := common.GetContextTenant(ctx)
+	if tenant == "" {
+		return fn(db)
+	}
+
+	if tx, ok := db.(bun.Tx); ok {
+		if ctx.Value(tenantScopeKey{}) != tenant {
+			if err := setSearchPath(ctx, tx, tenant); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	}
+
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := setSearchPath(ctx, tx, tenant); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// setSearchPath sets the search_path of tx until it ends, like SET LOCAL but with the
+// schema bound as a value.
+func setSearchPath(ctx context.Context, tx bun.Tx, tenant string) error {
+	schema := `"` + strings.ReplaceAll(tenant, `"`, `""`) + `"`
+	_, err := tx.ExecContext(ctx, "SELECT set_config('search_path', ?, true)", schema+", public")
+	return err
+}
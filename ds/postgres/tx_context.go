@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx. Repositories given the context,
+// e.g. through WithContext, run on tx instead of their DB, so a use case can span
+// several repositories in one transaction without passing tx around.
+func ContextWithTx(ctx context.Context, tx bun.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction set by ContextWithTx.
+func TxFromContext(ctx context.Context) (bun.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(bun.Tx)
+	return tx, ok
+}
+
+// dbFrom returns the transaction in ctx unless db already is a transaction, set
+// explicitly with WithTx.
+func dbFrom(ctx context.Context, db bun.IDB) bun.IDB {
+	if _, ok := db.(bun.Tx); ok {
+		return db
+	}
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// RunInNestedTx runs fn in a savepoint when db is a transaction or ctx carries one
+// (ContextWithTx), and in a new transaction otherwise. fn's error rolls back to the
+// savepoint, undoing only its own work and leaving the outer transaction usable, so
+// composable service methods can each declare a transactional boundary whether or
+// not their caller already opened one. It is RunInTenantTx, which repositories'
+// RunInTx and Client.RunInTx use as well, under a name stating the intent.
+func RunInNestedTx(ctx context.Context, db bun.IDB, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return RunInTenantTx(ctx, db, fn)
+}
+
+// RunInTx runs fn in a transaction whose ctx carries it, see ContextWithTx. Within
+// a transaction already in ctx it runs in a savepoint of it.
+func (c *Client) RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return RunInTenantTx(ctx, c.db, fn)
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// TxRetryPolicy retries transactions that failed on a serialization failure or a
+// deadlock with exponential backoff and full jitter.
+type TxRetryPolicy struct {
+	MaxAttempts    int // total attempts including the first, 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// TxOptions starts each attempt, e.g. &sql.TxOptions{Isolation: sql.LevelSerializable}
+	// for the serializable transactions whose failures are worth retrying. It is set
+	// before the tenant's search_path, so fn need not SET TRANSACTION itself. Nil uses
+	// the database default; it is ignored within a transaction.
+	TxOptions *sql.TxOptions
+}
+
+// DefaultTxRetryPolicy suits short OLTP transactions, whose conflicts usually clear
+// within milliseconds.
+var DefaultTxRetryPolicy = TxRetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 20 * time.Millisecond,
+	MaxBackoff:     time.Second,
+}
+
+// IsRetryableTxError reports whether err is a serialization failure (SQLSTATE 40001)
+// or a deadlock (40P01), after which PostgreSQL expects the whole transaction to be
+// retried.
+func IsRetryableTxError(err error) bool {
+	var pgErr pgdriver.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Field('C') {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
+
+// RunInTxWithRetry is RunInTenantTx run again, from the start, while it fails with
+// IsRetryableTxError, up to policy.MaxAttempts times. fn must therefore have no side
+// effects outside the transaction, such as publishing messages. Within a transaction
+// (db a bun.Tx or one in ctx, see ContextWithTx) it is not retried: the outer
+// transaction is aborted and only its owner can retry it. It never sleeps past the
+// ctx deadline.
+func RunInTxWithRetry(ctx context.Context, db bun.IDB, policy TxRetryPolicy, fn func(ctx context.Context, tx bun.Tx) error) error {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultTxRetryPolicy.MaxAttempts
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultTxRetryPolicy.InitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultTxRetryPolicy.MaxBackoff
+	}
+
+	if _, ok := dbFrom(ctx, db).(bun.Tx); ok {
+		policy.MaxAttempts = 1
+	}
+
+	backoff := policy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := runInTenantTx(ctx, db, policy.TxOptions, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !IsRetryableTxError(err) {
+			return err
+		}
+
+		wait := rand.N(backoff) + 1
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, policy.MaxBackoff)
+	}
+}
+
+// WithTxRetry returns a copy of the repository whose RunInTx and RunInTxWithRepo
+// retry with policy, see RunInTxWithRetry.
+func (r *BaseRepository[T]) WithTxRetry(policy TxRetryPolicy) *BaseRepository[T] {
+	repo := r.WithCtx(r.Context)
+	repo.txRetry = &policy
+	return repo
+}
+
+// runInTx runs fn in a transaction of the repository's DB, retried when WithTxRetry
+// set a policy.
+func (r *BaseRepository[T]) runInTx(ctx context.Context, fn func(ctx context.Context, tx bun.Tx) error) error {
+	if r.txRetry == nil {
+		return RunInTenantTx(ctx, r.DB, fn)
+	}
+	return RunInTxWithRetry(ctx, r.DB, *r.txRetry, fn)
+}
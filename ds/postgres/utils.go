@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/lib/pq"
+	"github.com/logistics-id/engine/common"
 	"github.com/uptrace/bun"
 )
 
@@ -19,6 +20,83 @@ func FilterSearch(q *bun.SelectQuery, search string, fields ...string) {
 	})
 }
 
+// FilterCondition adds a structured condition to q as a parameterized where clause:
+// fields are quoted as identifiers ("users.name" included) and values bound.
+func FilterCondition(q *bun.SelectQuery, cond common.Condition) error {
+	if err := cond.Validate(); err != nil {
+		return err
+	}
+
+	q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		return whereCondition(q, cond, false)
+	})
+	return nil
+}
+
+// whereCondition adds cond to the current where group, joined with OR when or is set.
+func whereCondition(q *bun.SelectQuery, cond common.Condition, or bool) *bun.SelectQuery {
+	sep := " AND "
+	if or {
+		sep = " OR "
+	}
+
+	switch {
+	case len(cond.And) > 0:
+		return q.WhereGroup(sep, func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, sub := range cond.And {
+				q = whereCondition(q, sub, false)
+			}
+			return q
+		})
+	case len(cond.Or) > 0:
+		return q.WhereGroup(sep, func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, sub := range cond.Or {
+				q = whereCondition(q, sub, true)
+			}
+			return q
+		})
+	}
+
+	query, args := conditionExpr(cond)
+	if or {
+		return q.WhereOr(query, args...)
+	}
+	return q.Where(query, args...)
+}
+
+// conditionExpr returns the SQL of a validated comparison.
+func conditionExpr(cond common.Condition) (string, []any) {
+	field := bun.Ident(cond.Field)
+
+	switch cond.Operator {
+	case common.OpNe:
+		return "? <> ?", []any{field, cond.Value}
+	case common.OpGt:
+		return "? > ?", []any{field, cond.Value}
+	case common.OpGte:
+		return "? >= ?", []any{field, cond.Value}
+	case common.OpLt:
+		return "? < ?", []any{field, cond.Value}
+	case common.OpLte:
+		return "? <= ?", []any{field, cond.Value}
+	case common.OpIn:
+		return "? IN (?)", []any{field, bun.In(cond.Value)}
+	case common.OpNotIn:
+		return "? NOT IN (?)", []any{field, bun.In(cond.Value)}
+	case common.OpLike:
+		return "? ILIKE ?", []any{field, "%" + likeEscaper.Replace(cond.Value.(string)) + "%"}
+	case common.OpIsNull:
+		if cond.Value.(bool) {
+			return "? IS NULL", []any{field}
+		}
+		return "? IS NOT NULL", []any{field}
+	}
+	return "? = ?", []any{field, cond.Value}
+}
+
+// likeEscaper escapes the LIKE wildcards of a literal pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func RequestSort(sort []string) string {
 	var result []string
 
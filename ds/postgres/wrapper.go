@@ -1,9 +1,13 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 	"go.uber.org/zap"
@@ -29,7 +33,11 @@ func NewConnection(c *Config, l *zap.Logger) (err error) {
 }
 
 // ConfigDefault creating an config readed from .env file
-// make sure you load the env file in your init app
+// make sure you load the env file in your init app.
+// POSTGRES_REPLICA_SERVERS lists comma-separated read replica hosts sharing the
+// primary's credentials. POSTGRES_MAX_OPEN_CONNS, POSTGRES_MAX_IDLE_CONNS,
+// POSTGRES_CONN_MAX_LIFETIME and POSTGRES_CONN_MAX_IDLE_TIME (e.g. "30m") tune the
+// pool; unset or invalid values keep the defaults.
 func ConfigDefault(db string) *Config {
 	c := &Config{
 		Server:   os.Getenv("POSTGRES_SERVER"),
@@ -43,6 +51,20 @@ func ConfigDefault(db string) *Config {
 		c.Username, c.Password, c.Server, c.Database,
 	)
 
+	c.MaxOpenConns, _ = strconv.Atoi(os.Getenv("POSTGRES_MAX_OPEN_CONNS"))
+	c.MaxIdleConns, _ = strconv.Atoi(os.Getenv("POSTGRES_MAX_IDLE_CONNS"))
+	c.ConnMaxLifetime, _ = time.ParseDuration(os.Getenv("POSTGRES_CONN_MAX_LIFETIME"))
+	c.ConnMaxIdleTime, _ = time.ParseDuration(os.Getenv("POSTGRES_CONN_MAX_IDLE_TIME"))
+
+	for _, server := range strings.Split(os.Getenv("POSTGRES_REPLICA_SERVERS"), ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			c.Replicas = append(c.Replicas, fmt.Sprintf(
+				"postgres://%s:%s@%s/%s?sslmode=disable",
+				c.Username, c.Password, server, c.Database,
+			))
+		}
+	}
+
 	return c
 }
 
@@ -52,6 +74,29 @@ func GetDB() *bun.DB {
 	return client.GetDB()
 }
 
+// GetReadDB returns a healthy replica of the global connection, or the primary when
+// there is none.
+func GetReadDB() bun.IDB {
+	return client.GetReadDB()
+}
+
+// RunInTx runs fn in a transaction of the global connection, see Client.RunInTx.
+func RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return client.RunInTx(ctx, fn)
+}
+
+// WithAdvisoryLock runs fn holding an advisory lock on the global connection, see
+// Client.WithAdvisoryLock.
+func WithAdvisoryLock(ctx context.Context, key string, fn LockFn) error {
+	return client.WithAdvisoryLock(ctx, key, fn)
+}
+
+// TryWithAdvisoryLock runs fn if the advisory lock is free, see
+// Client.TryWithAdvisoryLock.
+func TryWithAdvisoryLock(ctx context.Context, key string, fn LockFn) error {
+	return client.TryWithAdvisoryLock(ctx, key, fn)
+}
+
 // CloseConnection closes the default client connection.
 func CloseConnection() error {
 	if client.db == nil {
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"go.uber.org/zap"
@@ -35,6 +36,20 @@ func (r *Redis) Save(key string, value any) error {
 	return err
 }
 
+// SaveTTL marshals 'value' to JSON and stores it under the key, expiring after ttl.
+func (r *Redis) SaveTTL(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", r.key(key), data, "PX", ttl.Milliseconds())
+	return err
+}
+
 // Read retrieves the JSON value from Redis under the key and unmarshals into 'out'.
 func (r *Redis) Read(key string, out any) error {
 	conn := r.Pool.Get()
@@ -5,7 +5,7 @@ go 1.24.3
 require (
 	github.com/gomodule/redigo v1.9.2
 	github.com/google/uuid v1.6.0
-	github.com/logistics-id/engine/common v0.0.19-dev
+	github.com/logistics-id/engine/common v0.0.20-dev
 	go.uber.org/zap v1.27.0
 )
 
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RateLimitResult is the outcome of a fixed-window rate limit check.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // time until the current window ends
+}
+
+// FixedWindow counts a hit for key within a fixed window and reports whether it is
+// still under limit. The window starts on the first hit and expires after window.
+func FixedWindow(conn redis.Conn, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	conn.Send("MULTI")
+	conn.Send("INCR", key)
+	conn.Send("PTTL", key)
+
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := redis.Int(replies[0], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := redis.Int64(replies[1], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new window (or a key that lost its expiry) starts ticking now.
+	reset := time.Duration(ttl) * time.Millisecond
+	if ttl < 0 {
+		if _, err := conn.Do("PEXPIRE", key, window.Milliseconds()); err != nil {
+			return nil, err
+		}
+		reset = window
+	}
+
+	return &RateLimitResult{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		Reset:     reset,
+	}, nil
+}
+
+// RateLimit counts a hit for the prefixed key within a fixed window.
+func (r *Redis) RateLimit(key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	return FixedWindow(conn, r.key(key), limit, window)
+}
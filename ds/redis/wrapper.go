@@ -46,11 +46,24 @@ func NewConnection(cfg *Config, l *zap.Logger) error {
 	return nil
 }
 
+// Ping checks the global defaultCache connectivity, e.g. for readiness probes.
+func Ping(ctx context.Context) error {
+	if cache == nil {
+		return ErrNotInitialized()
+	}
+
+	return cache.Ping()
+}
+
 func GetConn() redis.Conn {
 	return cache.Pool.Get()
 }
 
+// GetPool returns the pool of the global defaultCache, nil before NewConnection.
 func GetPool() *redis.Pool {
+	if cache == nil {
+		return nil
+	}
 	return cache.Pool
 }
 
@@ -74,6 +87,27 @@ func Save(ctx context.Context, key string, value any) error {
 	return err
 }
 
+// SaveTTL stores value under the given key with an expiry in global defaultCache instance, logs the operation.
+func SaveTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if cache == nil {
+		return ErrNotInitialized()
+	}
+
+	started := time.Now()
+	err := cache.SaveTTL(key, value, ttl)
+
+	cache.Logger.Info("RED/QUERY",
+		zap.String("action", "save"),
+		zap.String("key", key),
+		zap.Duration("ttl", ttl),
+		zap.Duration("duration", time.Since(started)),
+		zap.String("request_id", common.GetContextRequestID(ctx)),
+		zap.Error(err),
+	)
+
+	return err
+}
+
 // Read retrieves value stored under the given key into out from global defaultCache, logs the operation.
 func Read(ctx context.Context, key string, out any) error {
 	if cache == nil {
@@ -95,6 +129,10 @@ func Read(ctx context.Context, key string, out any) error {
 }
 
 func GetCmd(cmd string, key string) ([]string, error) {
+	if cache == nil {
+		return nil, ErrNotInitialized()
+	}
+
 	return cache.GetStrings(cmd, key)
 }
 
@@ -118,6 +156,26 @@ func Delete(ctx context.Context, key string) error {
 	return err
 }
 
+// RateLimit counts a hit for key in a fixed window using the global defaultCache.
+// Only failures are logged since it runs on every limited request.
+func RateLimit(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	if cache == nil {
+		return nil, ErrNotInitialized()
+	}
+
+	rl, err := cache.RateLimit(key, limit, window)
+	if err != nil {
+		cache.Logger.Error("RED/QUERY",
+			zap.String("action", "rate_limit"),
+			zap.String("key", key),
+			zap.String("request_id", common.GetContextRequestID(ctx)),
+			zap.Error(err),
+		)
+	}
+
+	return rl, err
+}
+
 func ConfigDefault(prefix string) *Config {
 	return &Config{
 		Prefix:   prefix,
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/logistics-id/engine/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthConfig configures the JWT auth interceptors, the gRPC counterpart of the REST
+// JWTAuthMiddleware and RequirePermission.
+//
+// Every method requires a valid bearer token unless it is listed in Public; the
+// health and reflection services are always public. Method keys are full method
+// names ("/order.OrderService/Cancel") or a whole service ("/order.OrderService/*").
+type AuthConfig struct {
+	// Permissions maps methods to the permission checked with common.ValidTokenPermission.
+	Permissions map[string]string
+
+	// Public lists methods callable without a token.
+	Public []string
+}
+
+func (a *AuthConfig) public(method string) bool {
+	if strings.HasPrefix(method, "/grpc.health.v1.") || strings.HasPrefix(method, "/grpc.reflection.") {
+		return true
+	}
+	for _, m := range a.Public {
+		if matchMethod(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AuthConfig) permission(method string) string {
+	if perm, ok := a.Permissions[method]; ok {
+		return perm
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		return a.Permissions[method[:i]+"/*"]
+	}
+	return ""
+}
+
+// authenticate decodes the bearer token of the incoming metadata and returns ctx
+// carrying its claims under common.ContextUserKey and the token itself under
+// common.ContextSessionKey.
+func (a *AuthConfig) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if a.public(method) {
+		return ctx, nil
+	}
+
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("authorization"); len(vals) > 0 {
+			token, _ = strings.CutPrefix(vals[0], "Bearer ")
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims, err := common.TokenDecode(token)
+	if err != nil || claims == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	ctx = context.WithValue(ctx, common.ContextUserKey, claims)
+	ctx = context.WithValue(ctx, common.ContextSessionKey, token)
+
+	if perm := a.permission(method); perm != "" && !common.ValidTokenPermission(ctx, perm) {
+		return nil, status.Errorf(codes.PermissionDenied, "missing permission %s", perm)
+	}
+	return ctx, nil
+}
+
+// NewAuthUnaryInterceptor authenticates unary calls, see AuthConfig.
+func NewAuthUnaryInterceptor(cfg *AuthConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx, err := cfg.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewAuthStreamInterceptor authenticates streaming calls, see AuthConfig.
+func NewAuthStreamInterceptor(cfg *AuthConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := cfg.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// matchMethod matches a full method name against a method or "/pkg.Service/*" key.
+func matchMethod(pattern, method string) bool {
+	if service, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(method, service+"/")
+	}
+	return pattern == method
+}
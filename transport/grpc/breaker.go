@@ -0,0 +1,194 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CircuitBreakerPolicy opens the circuit of a target once too many calls fail, failing
+// calls fast with UNAVAILABLE instead of letting each one wait for its deadline. After
+// OpenTimeout the circuit turns half-open and lets HalfOpenProbes calls through: if
+// they all succeed it closes, if one fails it opens again.
+type CircuitBreakerPolicy struct {
+	FailureRatio   float64       // failed share of calls in Window that opens the circuit
+	MinRequests    int           // calls in Window before the ratio is considered
+	Window         time.Duration // counting window, counts reset when it ends
+	OpenTimeout    time.Duration // time open before probing
+	HalfOpenProbes int           // calls let through while half-open
+	Codes          []codes.Code  // status codes counted as failures
+}
+
+// DefaultCircuitBreakerPolicy counts the codes of an unhealthy or overloaded server as
+// failures, not the codes of a rejected request such as INVALID_ARGUMENT or NOT_FOUND.
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{
+	FailureRatio:   0.5,
+	MinRequests:    20,
+	Window:         10 * time.Second,
+	OpenTimeout:    5 * time.Second,
+	HalfOpenProbes: 3,
+	Codes: []codes.Code{
+		codes.Unavailable,
+		codes.DeadlineExceeded,
+		codes.ResourceExhausted,
+		codes.Internal,
+		codes.Unknown,
+	},
+}
+
+// ErrCircuitOpen is returned for calls rejected by an open circuit.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit breaker open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// NewCircuitBreakerInterceptor keeps a circuit per connection target. Chain it before
+// NewRetryInterceptor so a call counts once, after its retries.
+func NewCircuitBreakerInterceptor(policy CircuitBreakerPolicy, log *zap.Logger) grpc.UnaryClientInterceptor {
+	if policy.FailureRatio <= 0 {
+		policy.FailureRatio = DefaultCircuitBreakerPolicy.FailureRatio
+	}
+	if policy.MinRequests <= 0 {
+		policy.MinRequests = DefaultCircuitBreakerPolicy.MinRequests
+	}
+	if policy.Window <= 0 {
+		policy.Window = DefaultCircuitBreakerPolicy.Window
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = DefaultCircuitBreakerPolicy.OpenTimeout
+	}
+	if policy.HalfOpenProbes <= 0 {
+		policy.HalfOpenProbes = DefaultCircuitBreakerPolicy.HalfOpenProbes
+	}
+	if len(policy.Codes) == 0 {
+		policy.Codes = DefaultCircuitBreakerPolicy.Codes
+	}
+
+	var breakers sync.Map // target -> *circuitBreaker
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		v, _ := breakers.LoadOrStore(cc.Target(), &circuitBreaker{policy: &policy, target: cc.Target(), log: log})
+		cb := v.(*circuitBreaker)
+
+		if !cb.allow() {
+			return ErrCircuitOpen
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		cb.done(err != nil && slices.Contains(policy.Codes, status.Code(err)))
+		return err
+	}
+}
+
+type circuitBreaker struct {
+	policy *CircuitBreakerPolicy
+	target string
+	log    *zap.Logger
+
+	mu        sync.Mutex
+	state     circuitState
+	since     time.Time // start of the window, or when the circuit opened
+	requests  int
+	failures  int
+	probes    int // calls let through while half-open
+	successes int // probes that succeeded
+}
+
+// allow reports whether a call may go through, moving an open circuit to half-open
+// once OpenTimeout passed.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	switch cb.state {
+	case circuitOpen:
+		if now.Sub(cb.since) < cb.policy.OpenTimeout {
+			return false
+		}
+		cb.setState(circuitHalfOpen, now)
+		fallthrough
+	case circuitHalfOpen:
+		if cb.probes >= cb.policy.HalfOpenProbes {
+			return false
+		}
+		cb.probes++
+		return true
+	}
+
+	if now.Sub(cb.since) >= cb.policy.Window {
+		cb.since, cb.requests, cb.failures = now, 0, 0
+	}
+	return true
+}
+
+// done records the outcome of an allowed call.
+func (cb *circuitBreaker) done(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	switch cb.state {
+	case circuitHalfOpen:
+		if failed {
+			cb.setState(circuitOpen, now)
+			return
+		}
+		if cb.successes++; cb.successes >= cb.policy.HalfOpenProbes {
+			cb.setState(circuitClosed, now)
+		}
+	case circuitClosed:
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= cb.policy.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= cb.policy.FailureRatio {
+			cb.setState(circuitOpen, now)
+		}
+	}
+}
+
+func (cb *circuitBreaker) setState(state circuitState, now time.Time) {
+	if state == circuitOpen {
+		cb.log.Warn("GRPC/CIRCUIT OPEN",
+			zap.String("service_host", cb.target),
+			zap.Int("requests", cb.requests),
+			zap.Int("failures", cb.failures),
+		)
+	} else {
+		cb.log.Info("GRPC/CIRCUIT "+strings.ToUpper(state.String()), zap.String("service_host", cb.target))
+	}
+
+	cb.state = state
+	cb.since, cb.requests, cb.failures, cb.probes, cb.successes = now, 0, 0, 0, 0
+}
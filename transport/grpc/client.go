@@ -2,16 +2,13 @@ package grpc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/logistics-id/engine/common"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/protobuf/proto"
 )
 
 type Client struct {
@@ -31,29 +28,70 @@ func NewClient(ctx context.Context, serviceName string) (*Client, error) {
 		return nil, ErrServiceNotInitialized
 	}
 
-	log := Service.logger.With(
+	return newClient(ctx, serviceName, Service.logger.With(
 		zap.String("action", "client"),
 		zap.String("service_name", serviceName),
 		zap.String("request_id", common.GetContextRequestID(ctx)),
-	)
+	))
+}
 
+// newClient dials serviceName through the registry resolver, balancing calls across
+// its healthy instances.
+func newClient(ctx context.Context, serviceName string, log *zap.Logger) (*Client, error) {
 	reg := Service.registry
 	// dialTimeout := Service.config.DialTimeout
 	// if dialTimeout == 0 {
 	// 	dialTimeout = 5 * time.Second // default
 	// }
 
-	target, err := reg.PickOne(ctx, serviceName)
+	creds, err := ClientCredentials(Service.config.TLS)
 	if err != nil {
+		log.Error("TLS CONFIG FAILED", zap.Error(err))
+		return nil, err
+	}
+
+	// Fail fast when the service has no instance at all
+	if _, err := reg.PickOne(ctx, serviceName); err != nil {
 		log.Error("DISCOVERY FAILED", zap.Error(err))
 		return nil, err
 	}
+	target := ResolverScheme + ":///" + serviceName
+
+	retry := DefaultRetryPolicy
+	if Service.config.Retry != nil {
+		retry = *Service.config.Retry
+	}
 
-	conn, err := grpc.NewClient(
-		target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithUnaryInterceptor(NewZapClientLogger(log)),
-	)
+	unary := []grpc.UnaryClientInterceptor{NewPropagationUnaryInterceptor()}
+	if Service.config.CallTimeout > 0 {
+		// Before the logger and retries so they see, and share, the deadline
+		unary = append(unary, NewTimeoutInterceptor(Service.config.CallTimeout))
+	}
+	unary = append(unary, NewZapClientLogger(log, Service.config.PayloadLog))
+	if Service.config.CircuitBreaker != nil {
+		unary = append(unary, NewCircuitBreakerInterceptor(*Service.config.CircuitBreaker, log))
+	}
+	unary = append(unary, NewRetryInterceptor(retry, log))
+	unary = append(unary, Service.options.unaryClient...)
+
+	stream := []grpc.StreamClientInterceptor{
+		NewPropagationStreamInterceptor(),
+		NewZapStreamClientLogger(log, Service.config.PayloadLog),
+	}
+	stream = append(stream, Service.options.streamClient...)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithResolvers(Service.resolver),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(serviceConfig, serviceName)),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+	if Service.config.Keepalive != nil {
+		opts = append(opts, Service.config.Keepalive.dialOption())
+	}
+
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		log.Error("DIAL FAILED", zap.String("service_host", target), zap.Error(err))
 		return nil, err
@@ -84,22 +122,34 @@ func (c *Client) Close() error {
 //	client, closeFn, err := grpc.GetClient(ctx, "auth-service", pb.NewAuthServiceClient)
 //	defer closeFn()
 //
-// Now use `client` as your typed client.
+// Now use `client` as your typed client. The connection comes from the Service
+// ClientPool and is shared with other callers; closeFn releases it, and the pool
+// does not close it while held, so call closeFn once the client, and any stream
+// opened with it, is no longer used.
 func GetClient[T any](
 	ctx context.Context,
 	serviceName string,
 	factory GRPCClientFactory[T],
 ) (client T, closer func(), err error) {
-	cli, err := NewClient(ctx, serviceName)
+	if Service == nil {
+		var zero T
+		return zero, nil, ErrServiceNotInitialized
+	}
+
+	cli, release, err := Service.pool.Get(ctx, serviceName)
 	if err != nil {
 		var zero T
 		return zero, nil, err
 	}
 
-	return factory(cli.Conn()), func() { cli.Close() }, nil
+	return factory(cli.Conn()), release, nil
 }
 
-func NewZapClientLogger(log *zap.Logger) grpc.UnaryClientInterceptor {
+// NewZapClientLogger logs every unary call with its redacted and capped payloads, see
+// PayloadLogConfig.
+func NewZapClientLogger(log *zap.Logger, payload ...*PayloadLogConfig) grpc.UnaryClientInterceptor {
+	pl := newPayloadLogger(payload...)
+
 	return func(
 		ctx context.Context,
 		method string,
@@ -110,37 +160,28 @@ func NewZapClientLogger(log *zap.Logger) grpc.UnaryClientInterceptor {
 	) error {
 		reqID := common.GetContextRequestID(ctx)
 
-		var reqPayload []byte
-		if pb, ok := req.(proto.Message); ok {
-			reqPayload, _ = json.Marshal(pb)
-		}
-
-		md := metadata.Pairs(string(common.ContextRequestIDKey), reqID)
-		ctx = metadata.NewOutgoingContext(ctx, md)
-
 		start := time.Now()
 		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil && pl.skip(method) {
+			return nil
+		}
 
-		var respPayload []byte
-		if err == nil {
-			if pb, ok := reply.(proto.Message); ok {
-				respPayload, err = json.Marshal(pb)
-			}
+		resp := reply
+		if err != nil {
+			resp = nil
 		}
 
-		log = log.With(
+		l := log.With(
 			zap.String("method", method),
 			zap.String("service_host", cc.Target()),
 			zap.String("request_id", reqID),
-			zap.Any("payload", json.RawMessage(reqPayload)),
-			zap.Any("response", json.RawMessage(respPayload)),
-			zap.Duration("duration", time.Duration(time.Since(start))),
-		)
+			zap.Duration("duration", time.Since(start)),
+		).With(pl.fields(method, req, resp)...)
 
 		if err != nil {
-			log.Error("GRPC/CLIENT", zap.Error(err))
+			l.Error("GRPC/CLIENT", zap.Error(err))
 		} else {
-			log.Info("GRPC/CLIENT")
+			l.Info("GRPC/CLIENT")
 		}
 
 		return err
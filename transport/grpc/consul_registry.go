@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ConsulServiceRegistry registers instances with the local Consul agent using a TTL
+// check, so instances that stop heartbeating turn critical and drop out of discovery.
+// Namespace is applied as a service tag.
+type ConsulServiceRegistry struct {
+	Client    *api.Client
+	Namespace string
+}
+
+// NewConsulRegistry connects to the Consul agent at address, default CONSUL_HTTP_ADDR
+// or 127.0.0.1:8500.
+func NewConsulRegistry(namespace, address string) (*ConsulServiceRegistry, error) {
+	cfg := api.DefaultConfig()
+	if address != "" {
+		cfg.Address = address
+	}
+
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsulServiceRegistry{
+		Client:    client,
+		Namespace: namespace,
+	}, nil
+}
+
+func (r *ConsulServiceRegistry) id(serviceName, address string) string {
+	return serviceName + "-" + address
+}
+
+func (r *ConsulServiceRegistry) Register(ctx context.Context, serviceName, address string, ttl time.Duration) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+
+	id := r.id(serviceName, address)
+	reg := &api.AgentServiceRegistration{
+		ID:      id,
+		Name:    serviceName,
+		Address: host,
+		Port:    port,
+		Check: &api.AgentServiceCheck{
+			CheckID:                        "service:" + id,
+			TTL:                            ttl.String(),
+			DeregisterCriticalServiceAfter: (3 * ttl).String(),
+		},
+	}
+	if r.Namespace != "" {
+		reg.Tags = []string{r.Namespace}
+	}
+
+	if err := r.Client.Agent().ServiceRegister(reg); err != nil {
+		return err
+	}
+	return r.Client.Agent().UpdateTTL("service:"+id, "", api.HealthPassing)
+}
+
+func (r *ConsulServiceRegistry) Unregister(ctx context.Context, serviceName, address string) error {
+	return r.Client.Agent().ServiceDeregister(r.id(serviceName, address))
+}
+
+func (r *ConsulServiceRegistry) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	entries, _, err := r.Client.Health().Service(serviceName, r.Namespace, true, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return entryAddresses(entries), nil
+}
+
+// Watch calls update with the passing instances of serviceName whenever they change,
+// using Consul blocking queries.
+func (r *ConsulServiceRegistry) Watch(ctx context.Context, serviceName string, update func([]string)) {
+	var index uint64
+	for ctx.Err() == nil {
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute}
+		entries, meta, err := r.Client.Health().Service(serviceName, r.Namespace, true, q.WithContext(ctx))
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		if meta.LastIndex == index {
+			continue // wait timed out without changes
+		}
+		if meta.LastIndex < index {
+			index = 0 // the index went backwards, e.g. after an agent reset: start over
+		} else {
+			index = meta.LastIndex
+		}
+		update(entryAddresses(entries))
+	}
+}
+
+func entryAddresses(entries []*api.ServiceEntry) []string {
+	addresses := make([]string, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		addresses = append(addresses, net.JoinHostPort(host, strconv.Itoa(e.Service.Port)))
+	}
+	return addresses
+}
+
+// Heartbeat passes the TTL check every ttl/2 and registers the instance again when
+// the agent lost it, e.g. after an agent restart.
+func (r *ConsulServiceRegistry) Heartbeat(ctx context.Context, serviceName, address string, ttl time.Duration) {
+	go func() {
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+
+		checkID := "service:" + r.id(serviceName, address)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Client.Agent().UpdateTTL(checkID, "", api.HealthPassing); err != nil {
+					_ = r.Register(ctx, serviceName, address, ttl)
+				}
+			}
+		}
+	}()
+}
+
+func (r *ConsulServiceRegistry) PickOne(ctx context.Context, serviceName string) (string, error) {
+	addresses, err := r.Discover(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
+	return pickRandom(serviceName, addresses)
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMissingDeadline is returned by servers with Config.RequireDeadline for unary calls
+// sent without a deadline.
+var ErrMissingDeadline = status.Error(codes.InvalidArgument, "call has no deadline")
+
+// NewTimeoutInterceptor bounds unary calls whose ctx has no deadline to timeout;
+// deadlines set by the caller are kept, shorter or longer.
+func NewTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// NewRequireDeadlineInterceptor rejects unary calls without a deadline with
+// ErrMissingDeadline. Health checks are exempt, as are streams, which are often meant
+// to stay open.
+func NewRequireDeadlineInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if _, ok := ctx.Deadline(); !ok && !matchMethod("/grpc.health.v1.Health/*", info.FullMethod) {
+			return nil, ErrMissingDeadline
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,141 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"path"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdServiceRegistry stores every instance under "/<namespace>/services/<name>/<address>"
+// attached to a lease, so instances that stop heartbeating expire with it.
+type EtcdServiceRegistry struct {
+	Client    *clientv3.Client
+	Namespace string
+
+	mu     sync.Mutex
+	leases map[string]clientv3.LeaseID
+}
+
+// NewEtcdRegistry connects to the etcd cluster at endpoints.
+func NewEtcdRegistry(namespace string, endpoints []string) (*EtcdServiceRegistry, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdServiceRegistry{
+		Client:    client,
+		Namespace: namespace,
+		leases:    map[string]clientv3.LeaseID{},
+	}, nil
+}
+
+func (r *EtcdServiceRegistry) prefix(service string) string {
+	return path.Join("/", r.Namespace, "services", service) + "/"
+}
+
+// leaseSeconds rounds ttl up to whole seconds, etcd's lease unit, and to at least
+// one, so a sub-second ttl does not truncate to a lease expiring at once.
+func leaseSeconds(ttl time.Duration) int64 {
+	return max(1, int64(math.Ceil(ttl.Seconds())))
+}
+
+func (r *EtcdServiceRegistry) Register(ctx context.Context, serviceName, address string, ttl time.Duration) error {
+	lease, err := r.Client.Grant(ctx, leaseSeconds(ttl))
+	if err != nil {
+		return err
+	}
+
+	key := r.prefix(serviceName) + address
+	if _, err := r.Client.Put(ctx, key, address, clientv3.WithLease(lease.ID)); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.leases[key] = lease.ID
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *EtcdServiceRegistry) Unregister(ctx context.Context, serviceName, address string) error {
+	key := r.prefix(serviceName) + address
+
+	r.mu.Lock()
+	lease, ok := r.leases[key]
+	delete(r.leases, key)
+	r.mu.Unlock()
+
+	if ok {
+		_, err := r.Client.Revoke(ctx, lease)
+		return err
+	}
+	_, err := r.Client.Delete(ctx, key)
+	return err
+}
+
+func (r *EtcdServiceRegistry) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	resp, err := r.Client.Get(ctx, r.prefix(serviceName), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		addresses = append(addresses, string(kv.Value))
+	}
+	return addresses, nil
+}
+
+// Heartbeat renews the lease every ttl/2 and registers the instance again under a
+// new lease when the old one expired.
+func (r *EtcdServiceRegistry) Heartbeat(ctx context.Context, serviceName, address string, ttl time.Duration) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(leaseSeconds(ttl)) * time.Second / 2)
+		defer ticker.Stop()
+
+		key := r.prefix(serviceName) + address
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.mu.Lock()
+				lease, ok := r.leases[key]
+				r.mu.Unlock()
+
+				if !ok {
+					_ = r.Register(ctx, serviceName, address, ttl)
+					continue
+				}
+				if _, err := r.Client.KeepAliveOnce(ctx, lease); err != nil {
+					_ = r.Register(ctx, serviceName, address, ttl)
+				}
+			}
+		}
+	}()
+}
+
+// Watch calls update with the instances of serviceName after every change under its
+// prefix.
+func (r *EtcdServiceRegistry) Watch(ctx context.Context, serviceName string, update func([]string)) {
+	for range r.Client.Watch(ctx, r.prefix(serviceName), clientv3.WithPrefix()) {
+		if addresses, err := r.Discover(ctx, serviceName); err == nil {
+			update(addresses)
+		}
+	}
+}
+
+func (r *EtcdServiceRegistry) PickOne(ctx context.Context, serviceName string) (string, error) {
+	addresses, err := r.Discover(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
+	return pickRandom(serviceName, addresses)
+}
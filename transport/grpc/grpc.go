@@ -15,6 +15,29 @@ type Config struct {
 	Namespace         string
 	TTL               time.Duration
 	DialTimeout       time.Duration
+	TLS               *TLSConfig            // nil serves and dials without TLS
+	Retry             *RetryPolicy          // client retries, default DefaultRetryPolicy
+	CircuitBreaker    *CircuitBreakerPolicy // nil disables the client circuit breaker
+	CallTimeout       time.Duration         // client timeout of unary calls without a deadline, zero leaves them unbounded
+	RequireDeadline   bool                  // server rejects unary calls without a deadline
+	Keepalive         *KeepaliveConfig      // nil keeps the gRPC defaults: no client pings, servers accept one every 5m
+	PayloadLog        *PayloadLogConfig     // redaction, size cap and per-method opt-out of logged payloads
+	ClientIdleTimeout time.Duration         // pooled client connections, default DefaultClientIdleTimeout
+	Reflection        bool                  // serve grpc.reflection for grpcurl/evans, keep it off in production
+	Auth              *AuthConfig           // nil leaves every method unauthenticated
+	RateLimit         *RateLimitConfig      // nil disables rate limiting
+
+	// Registry selects the discovery backend: RegistryRedis (default), RegistryConsul,
+	// RegistryEtcd or RegistryStatic. RegistryEndpoints overrides the Consul agent
+	// address or the etcd endpoints.
+	Registry          string
+	RegistryEndpoints []string
+	ResolveInterval   time.Duration // registry polling of client connections, default DefaultResolveInterval
+
+	// StaticTargets maps service names to "host:port" targets used when discovery
+	// finds no instance or fails, or always with RegistryStatic; GRPC_TARGET_<SERVICE>
+	// does the same from the environment.
+	StaticTargets map[string][]string
 }
 
 type service struct {
@@ -22,22 +45,34 @@ type service struct {
 	config   *Config
 	logger   *zap.Logger
 	registry ServiceRegistry
+	pool     *ClientPool
+	resolver *registryBuilder
+	options  *options
 }
 
 var Service *service
 
-func NewService(config *Config, logger *zap.Logger, register func(*grpc.Server)) *service {
+// NewService creates the gRPC server and the client environment of the service; opts
+// add interceptors to the server and client chains, see Option.
+func NewService(config *Config, logger *zap.Logger, register func(*grpc.Server), opts ...Option) *service {
 	config.TTL = 30 * time.Second
 	config.DialTimeout = 5 * time.Second
 
 	logger = logger.With(zap.String("component", "transport.grpc"))
 
-	reg := NewRedisRegistry(config.Namespace, config.TTL)
+	reg, err := newRegistry(config)
+	if err != nil {
+		logger.Fatal("gRPC/REGISTRY CONFIG FAILED", zap.Error(err))
+	}
+
 	Service = &service{
-		Server:   NewServer(config, logger, reg, register),
+		Server:   NewServer(config, logger, reg, register, opts...),
 		config:   config,
 		logger:   logger,
 		registry: reg,
+		pool:     NewClientPool(config.ClientIdleTimeout, logger),
+		resolver: newRegistryBuilder(reg, config.ResolveInterval, logger),
+		options:  newOptions(opts),
 	}
 
 	return Service
@@ -49,6 +84,12 @@ func (s *service) Start(ctx context.Context) {
 
 func (s *service) Shutdown(ctx context.Context) {
 	s.Server.Shutdown(ctx)
+	s.pool.Close()
+}
+
+// Pool returns the client pool used by GetClient.
+func (s *service) Pool() *ClientPool {
+	return s.pool
 }
 
 func (s *service) Registry() ServiceRegistry {
@@ -0,0 +1,19 @@
+package grpc
+
+import (
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// SetServing reports the server as SERVING or NOT_SERVING on the grpc.health.v1.Health
+// service, both overall and for Config.ServiceName. The server starts NOT_SERVING,
+// turns SERVING once registered and goes back to NOT_SERVING on shutdown; call it to
+// take an instance out of rotation meanwhile, e.g. while its database is unreachable.
+func (s *Server) SetServing(serving bool) {
+	st := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		st = healthpb.HealthCheckResponse_SERVING
+	}
+
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(s.config.ServiceName, st)
+}
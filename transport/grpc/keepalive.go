@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// KeepaliveConfig keeps idle connections alive through load balancers and NATs that
+// drop them silently, and lets servers recycle long-lived connections.
+type KeepaliveConfig struct {
+	// Clients ping a connection idle for Time and close it when the ping is not
+	// answered within Timeout, default 60s and 20s.
+	Time    time.Duration
+	Timeout time.Duration
+
+	// MinTime is the shortest ping interval servers accept before closing the
+	// connection with "too_many_pings", default Time/2.
+	MinTime time.Duration
+
+	// Servers close connections idle for MaxConnectionIdle or older than
+	// MaxConnectionAge, after MaxConnectionAgeGrace for pending calls; zero disables.
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+}
+
+func (k *KeepaliveConfig) withDefaults() KeepaliveConfig {
+	c := *k
+	if c.Time <= 0 {
+		c.Time = 60 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 20 * time.Second
+	}
+	if c.MinTime <= 0 {
+		c.MinTime = c.Time / 2
+	}
+	return c
+}
+
+// serverOptions enforces the client ping policy, pings included while no call is in
+// flight since that is when load balancers drop connections.
+func (k *KeepaliveConfig) serverOptions() []grpc.ServerOption {
+	c := k.withDefaults()
+	return []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.MinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     c.MaxConnectionIdle,
+			MaxConnectionAge:      c.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.MaxConnectionAgeGrace,
+			Time:                  c.Time,
+			Timeout:               c.Timeout,
+		}),
+	}
+}
+
+func (k *KeepaliveConfig) dialOption() grpc.DialOption {
+	c := k.withDefaults()
+	return grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                c.Time,
+		Timeout:             c.Timeout,
+		PermitWithoutStream: true,
+	})
+}
@@ -0,0 +1,56 @@
+package grpc
+
+import "google.golang.org/grpc"
+
+// Option adds interceptors to the chains built by NewService and NewServer. Added
+// interceptors run after the built-in ones, in the order given:
+//
+//   - server: logging, recovery, deadline check, auth, rate limit, then ChainUnaryServer
+//     and ChainStreamServer, so they see the request ID, the session and recover
+//     from their own panics.
+//   - client: propagation, timeout, logging, circuit breaker, retry, then
+//     ChainUnaryClient and ChainStreamClient, so they run once per attempt.
+type Option func(*options)
+
+type options struct {
+	unaryServer  []grpc.UnaryServerInterceptor
+	streamServer []grpc.StreamServerInterceptor
+	unaryClient  []grpc.UnaryClientInterceptor
+	streamClient []grpc.StreamClientInterceptor
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ChainUnaryServer appends unary interceptors to the server chain, e.g. metrics or tracing.
+func ChainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *options) {
+		o.unaryServer = append(o.unaryServer, interceptors...)
+	}
+}
+
+// ChainStreamServer appends stream interceptors to the server chain.
+func ChainStreamServer(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(o *options) {
+		o.streamServer = append(o.streamServer, interceptors...)
+	}
+}
+
+// ChainUnaryClient appends unary interceptors to the chain of every client connection.
+func ChainUnaryClient(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.unaryClient = append(o.unaryClient, interceptors...)
+	}
+}
+
+// ChainStreamClient appends stream interceptors to the chain of every client connection.
+func ChainStreamClient(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(o *options) {
+		o.streamClient = append(o.streamClient, interceptors...)
+	}
+}
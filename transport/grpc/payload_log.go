@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Redacted replaces the value of sensitive fields in logged payloads.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the field names masked when PayloadLogConfig.RedactKeys is empty.
+var DefaultRedactKeys = []string{
+	"password", "password_confirmation", "old_password", "new_password",
+	"token", "access_token", "refresh_token", "id_token", "secret", "client_secret",
+	"authorization", "api_key", "pin", "otp", "nik", "card_number", "cvv",
+}
+
+// PayloadLogConfig controls the request and response payloads written by the logging
+// interceptors, like rest.BodyLogConfig. Payloads are always redacted and capped; the
+// zero value uses the defaults.
+type PayloadLogConfig struct {
+	// MaxSize caps each logged payload, default 4KB. Larger payloads are truncated.
+	MaxSize int
+
+	// RedactKeys are field names (case-insensitive) whose values are masked at any
+	// depth, default DefaultRedactKeys.
+	RedactKeys []string
+
+	// OmitPayload lists methods, or "/pkg.Service/*", logged without payloads, e.g.
+	// bulk endpoints.
+	OmitPayload []string
+
+	// Skip lists methods, or "/pkg.Service/*", only logged when they fail, e.g.
+	// high-traffic lookups.
+	Skip []string
+}
+
+// payloadLogger redacts and caps payloads for the logging interceptors.
+type payloadLogger struct {
+	cfg  PayloadLogConfig
+	keys map[string]struct{}
+}
+
+func newPayloadLogger(cfg ...*PayloadLogConfig) *payloadLogger {
+	p := &payloadLogger{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		p.cfg = *cfg[0]
+	}
+	if p.cfg.MaxSize <= 0 {
+		p.cfg.MaxSize = 4 << 10
+	}
+	if len(p.cfg.RedactKeys) == 0 {
+		p.cfg.RedactKeys = DefaultRedactKeys
+	}
+
+	p.keys = make(map[string]struct{}, len(p.cfg.RedactKeys))
+	for _, k := range p.cfg.RedactKeys {
+		p.keys[strings.ToLower(k)] = struct{}{}
+	}
+	return p
+}
+
+// skip reports whether a successful call of method is not logged.
+func (p *payloadLogger) skip(method string) bool {
+	return matchAny(p.cfg.Skip, method)
+}
+
+// fields returns the redacted request and response payloads of method.
+func (p *payloadLogger) fields(method string, req, resp any) []zap.Field {
+	if matchAny(p.cfg.OmitPayload, method) {
+		return nil
+	}
+	return []zap.Field{p.field("payload", req), p.field("response", resp)}
+}
+
+func (p *payloadLogger) field(key string, msg any) zap.Field {
+	pb, ok := msg.(proto.Message)
+	if !ok || pb == nil {
+		return zap.Skip()
+	}
+
+	raw, err := json.Marshal(pb)
+	if err != nil {
+		return zap.Skip()
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err == nil {
+		raw, _ = json.Marshal(p.redact(v))
+	}
+
+	if len(raw) > p.cfg.MaxSize {
+		// Truncated JSON no longer parses, log it as text
+		return zap.String(key, string(raw[:p.cfg.MaxSize])+"...(truncated)")
+	}
+	return zap.Any(key, json.RawMessage(raw))
+}
+
+func (p *payloadLogger) redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if _, ok := p.keys[strings.ToLower(k)]; ok {
+				t[k] = Redacted
+				continue
+			}
+			t[k] = p.redact(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = p.redact(t[i])
+		}
+	}
+	return v
+}
+
+func matchAny(patterns []string, method string) bool {
+	for _, m := range patterns {
+		if matchMethod(m, method) {
+			return true
+		}
+	}
+	return false
+}
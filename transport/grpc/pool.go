@@ -0,0 +1,200 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/connectivity"
+)
+
+// DefaultClientIdleTimeout closes pooled connections unused for this long when
+// Config.ClientIdleTimeout is zero.
+const DefaultClientIdleTimeout = 5 * time.Minute
+
+// ClientPool shares one connection per service between callers. Connections in
+// TRANSIENT_FAILURE or SHUTDOWN are redialed on the next Get, and connections no
+// caller holds that are idle for longer than the idle timeout are closed.
+type ClientPool struct {
+	idle time.Duration
+	log  *zap.Logger
+
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+	dials   singleflight.Group
+	stop    chan struct{}
+	once    sync.Once
+}
+
+type pooledClient struct {
+	*Client
+	lastUsed time.Time
+	refs     int  // callers holding the client, see Get
+	retired  bool // dropped from the pool, closed once refs is back to zero
+}
+
+func NewClientPool(idle time.Duration, logger *zap.Logger) *ClientPool {
+	if idle <= 0 {
+		idle = DefaultClientIdleTimeout
+	}
+
+	p := &ClientPool{
+		idle:    idle,
+		log:     logger.With(zap.String("action", "client.pool")),
+		clients: map[string]*pooledClient{},
+		stop:    make(chan struct{}),
+	}
+	go p.evictLoop()
+	return p
+}
+
+// Get returns the pooled client of serviceName, dialing it on first use or when the
+// pooled connection is unhealthy, and a release func to call once done with it. Do
+// not Close the returned client: it stays open until released, idle eviction and
+// redials only close clients no caller holds. Discovery and dialing run outside the
+// pool lock, so a slow service does not hold up the others, and concurrent Gets of
+// one service share a single dial, bound by the ctx of the first of them.
+func (p *ClientPool) Get(ctx context.Context, serviceName string) (*Client, func(), error) {
+	if pc := p.pooled(serviceName); pc != nil {
+		return pc.Client, p.releaser(pc), nil
+	}
+
+	v, err, _ := p.dials.Do(serviceName, func() (any, error) {
+		// Pooled clients outlive the request, so their logger carries no request ID
+		cli, err := newClient(ctx, serviceName, Service.logger.With(
+			zap.String("action", "client"),
+			zap.String("service_name", serviceName),
+		))
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		// A dial that ended between the pooled check and this one may have stored a client
+		if pc, ok := p.clients[serviceName]; ok && healthy(pc.conn.GetState()) {
+			_ = cli.Close()
+			return pc, nil
+		}
+
+		pc := &pooledClient{Client: cli, lastUsed: time.Now()}
+		p.clients[serviceName] = pc
+		return pc, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// callers sharing the dial each hold a reference, unless the client turned
+	// unhealthy and was closed before they could take it
+	pc := v.(*pooledClient)
+	p.mu.Lock()
+	if pc.retired {
+		p.mu.Unlock()
+		return p.Get(ctx, serviceName)
+	}
+	pc.refs++
+	pc.lastUsed = time.Now()
+	p.mu.Unlock()
+
+	return pc.Client, p.releaser(pc), nil
+}
+
+// pooled returns the healthy pooled client of serviceName with a reference held,
+// dropping an unhealthy one; nil when there is none.
+func (p *ClientPool) pooled(serviceName string) *pooledClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc, ok := p.clients[serviceName]
+	if !ok {
+		return nil
+	}
+
+	if healthy(pc.conn.GetState()) {
+		pc.refs++
+		pc.lastUsed = time.Now()
+		return pc
+	}
+
+	p.log.Warn("GRPC/CLIENT POOL REDIAL", zap.String("service_name", serviceName), zap.Stringer("state", pc.conn.GetState()))
+	delete(p.clients, serviceName)
+	p.retire(pc)
+	return nil
+}
+
+// releaser returns the release func of a reference to pc; calling it more than once
+// releases the reference once.
+func (p *ClientPool) releaser(pc *pooledClient) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			pc.refs--
+			pc.lastUsed = time.Now()
+			if pc.retired && pc.refs == 0 {
+				_ = pc.Close()
+			}
+		})
+	}
+}
+
+// retire closes pc, dropped from the pool, or leaves it to its last release while
+// callers still hold it. p.mu must be held.
+func (p *ClientPool) retire(pc *pooledClient) {
+	pc.retired = true
+	if pc.refs == 0 {
+		_ = pc.Close()
+	}
+}
+
+// Close closes every pooled connection, held or not, and stops idle eviction.
+func (p *ClientPool) Close() {
+	p.once.Do(func() { close(p.stop) })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, pc := range p.clients {
+		_ = pc.Close()
+		delete(p.clients, name)
+	}
+}
+
+func (p *ClientPool) evictLoop() {
+	ticker := time.NewTicker(p.idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.evictIdle()
+		}
+	}
+}
+
+func (p *ClientPool) evictIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, pc := range p.clients {
+		if pc.refs == 0 && time.Since(pc.lastUsed) > p.idle {
+			p.log.Debug("GRPC/CLIENT POOL EVICT", zap.String("service_name", name))
+			_ = pc.Close()
+			delete(p.clients, name)
+		}
+	}
+}
+
+// healthy reports whether a connection can be reused; IDLE connections reconnect on
+// the next call by themselves.
+func healthy(state connectivity.State) bool {
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
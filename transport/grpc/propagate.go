@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/logistics-id/engine/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewPropagationUnaryInterceptor forwards the request ID and session of ctx to the
+// server, see outgoingContext.
+func NewPropagationUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// NewPropagationStreamInterceptor forwards the request ID and session of ctx to the
+// server, see outgoingContext.
+func NewPropagationStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+// outgoingContext appends the request ID to the outgoing metadata, starting a new one
+// when ctx has none, and the session token as "authorization" unless the caller set
+// its own. The request ID is also stored in ctx for the client loggers.
+func outgoingContext(ctx context.Context) context.Context {
+	reqID := common.GetContextRequestID(ctx)
+	if reqID == "" {
+		reqID = uuid.New().String()
+		ctx = context.WithValue(ctx, common.ContextRequestIDKey, reqID)
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(string(common.ContextRequestIDKey))) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, string(common.ContextRequestIDKey), reqID)
+	}
+
+	if token := common.GetContextSessionToken(ctx); token != "" && len(md.Get("authorization")) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	return ctx
+}
@@ -0,0 +1,254 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	gredis "github.com/gomodule/redigo/redis"
+	"github.com/logistics-id/engine/common"
+	"github.com/logistics-id/engine/ds/redis"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimitConfig configures the rate limiting interceptors: every caller gets a token
+// bucket refilled at Rate per second and holding up to Burst calls. Calls over the
+// limit fail with RESOURCE_EXHAUSTED and a "retry-after" trailer in seconds.
+type RateLimitConfig struct {
+	Rate  float64 // calls per second per caller
+	Burst int     // bucket size, default Rate rounded up
+
+	// Redis shares the buckets between pods through ds/redis (call redis.NewConnection
+	// first); otherwise each pod limits in memory. Calls pass through when Redis is
+	// unavailable.
+	Redis bool
+
+	// KeyFunc identifies the caller, default the session user ID set by the auth
+	// interceptor, else the peer IP.
+	KeyFunc func(ctx context.Context, method string) string
+
+	// Exempt lists methods, or "/pkg.Service/*", that are never limited; the health
+	// service always is.
+	Exempt []string
+}
+
+// rateLimiter takes one token from the bucket of key and, when it is empty, returns
+// how long until the next token.
+type rateLimiter interface {
+	take(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// validate rejects a configuration that would never refill the buckets.
+func (cfg *RateLimitConfig) validate() error {
+	if !(cfg.Rate > 0) {
+		return fmt.Errorf("grpc: rate limit rate must be positive, got %v", cfg.Rate)
+	}
+	return nil
+}
+
+// NewRateLimitUnaryInterceptor limits unary calls, see RateLimitConfig. It panics
+// when cfg.Rate is not positive.
+func NewRateLimitUnaryInterceptor(cfg *RateLimitConfig) grpc.UnaryServerInterceptor {
+	allow := newRateLimit(cfg)
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if err := allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewRateLimitStreamInterceptor limits the opening of streams, see RateLimitConfig.
+// It panics when cfg.Rate is not positive.
+func NewRateLimitStreamInterceptor(cfg *RateLimitConfig) grpc.StreamServerInterceptor {
+	allow := newRateLimit(cfg)
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := allow(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func newRateLimit(cfg *RateLimitConfig) func(ctx context.Context, method string) error {
+	if err := cfg.validate(); err != nil {
+		panic(err)
+	}
+
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(cfg.Rate)))
+	}
+
+	var limiter rateLimiter = &memoryLimiter{rate: cfg.Rate, burst: float64(burst), buckets: map[string]*bucket{}}
+	if cfg.Redis {
+		limiter = &redisLimiter{rate: cfg.Rate, burst: burst}
+	}
+
+	keyFn := cfg.KeyFunc
+	if keyFn == nil {
+		keyFn = callerKey
+	}
+
+	return func(ctx context.Context, method string) error {
+		if isExempt(cfg.Exempt, method) {
+			return nil
+		}
+
+		ok, wait, err := limiter.take(ctx, keyFn(ctx, method))
+		if err != nil || ok {
+			return nil // fail-open
+		}
+
+		retry := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+		_ = grpc.SetTrailer(ctx, metadata.Pairs("retry-after", retry))
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %ss", retry)
+	}
+}
+
+func isExempt(exempt []string, method string) bool {
+	if matchMethod("/grpc.health.v1.Health/*", method) {
+		return true
+	}
+	for _, m := range exempt {
+		if matchMethod(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerKey returns the session user ID, else the peer IP.
+func callerKey(ctx context.Context, _ string) string {
+	switch v := ctx.Value(common.ContextUserKey).(type) {
+	case *common.SessionClaims:
+		return "user:" + v.UserID
+	case interface{ GetBase() *common.SessionClaims }:
+		if base := v.GetBase(); base != nil {
+			return "user:" + base.UserID
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + p.Addr.String()
+	}
+	return "unknown"
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// memoryLimiter keeps the buckets of one pod; full buckets are dropped periodically.
+type memoryLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	pruned  time.Time
+}
+
+func (m *memoryLimiter) take(_ context.Context, key string) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.pruned) > time.Minute {
+		m.prune(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: m.burst, last: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = min(m.burst, b.tokens+now.Sub(b.last).Seconds()*m.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / m.rate * float64(time.Second)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+func (m *memoryLimiter) prune(now time.Time) {
+	m.pruned = now
+	for key, b := range m.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*m.rate >= m.burst {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// tokenBucketScript refills and takes from a bucket hash using the Redis clock so
+// every pod sees the same time. It returns {allowed, wait in ms}.
+var tokenBucketScript = gredis.NewScript(1, `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// redisLimiter keeps the buckets in Redis hashes under "grpc:rl:<key>".
+type redisLimiter struct {
+	rate  float64
+	burst int
+}
+
+func (r *redisLimiter) take(_ context.Context, key string) (bool, time.Duration, error) {
+	pool := redis.GetPool()
+	if pool == nil {
+		return false, 0, redis.ErrNotInitialized()
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	res, err := gredis.Int64s(tokenBucketScript.Do(conn, "grpc:rl:"+key, r.rate, r.burst))
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
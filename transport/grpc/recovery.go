@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewRecoveryUnaryInterceptor turns a panic in a handler into an INTERNAL error and
+// logs it with its stack, instead of crashing the process.
+func NewRecoveryUnaryInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = recovered(ctx, log, info.FullMethod, rec)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// NewRecoveryStreamInterceptor is NewRecoveryUnaryInterceptor for streaming calls.
+func NewRecoveryStreamInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = recovered(ss.Context(), log, info.FullMethod, rec)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, log *zap.Logger, method string, rec any) error {
+	log.Error("gRPC/PANIC RECOVERED",
+		zap.String("method", method),
+		zap.String("request_id", common.GetContextRequestID(ctx)),
+		zap.Any("error", rec),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
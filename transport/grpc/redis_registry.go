@@ -3,7 +3,6 @@ package grpc
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/logistics-id/engine/ds/redis"
@@ -77,10 +76,5 @@ func (r *RedisServiceRegistry) PickOne(ctx context.Context, serviceName string)
 	if err != nil {
 		return "", err
 	}
-	if len(addresses) == 0 {
-		return "", fmt.Errorf("no healthy instances for service: %s", serviceName)
-	}
-
-	rand.Seed(time.Now().UnixNano())
-	return addresses[rand.Intn(len(addresses))], nil
+	return pickRandom(serviceName, addresses)
 }
@@ -2,6 +2,10 @@ package grpc
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -13,3 +17,57 @@ type ServiceRegistry interface {
 	Heartbeat(ctx context.Context, serviceName, address string, ttl time.Duration)
 	PickOne(ctx context.Context, serviceName string) (string, error)
 }
+
+// Registry backends selectable with Config.Registry. RegistryStatic disables discovery
+// so clients only use the static targets.
+const (
+	RegistryRedis  = "redis"
+	RegistryConsul = "consul"
+	RegistryEtcd   = "etcd"
+	RegistryStatic = "static"
+)
+
+// newRegistry builds the registry selected by config, falling back to the static
+// targets of Config.StaticTargets and GRPC_TARGET_<SERVICE> when it has no instance
+// or fails. Consul defaults to CONSUL_HTTP_ADDR and etcd to the comma-separated
+// ETCD_ENDPOINTS.
+func newRegistry(config *Config) (ServiceRegistry, error) {
+	static := NewStaticRegistry(config.StaticTargets)
+
+	var (
+		reg ServiceRegistry
+		err error
+	)
+	switch config.Registry {
+	case "", RegistryRedis:
+		reg = NewRedisRegistry(config.Namespace, config.TTL)
+	case RegistryConsul:
+		var address string
+		if len(config.RegistryEndpoints) > 0 {
+			address = config.RegistryEndpoints[0]
+		}
+		reg, err = NewConsulRegistry(config.Namespace, address)
+	case RegistryEtcd:
+		endpoints := config.RegistryEndpoints
+		if len(endpoints) == 0 {
+			endpoints = strings.Split(os.Getenv("ETCD_ENDPOINTS"), ",")
+		}
+		reg, err = NewEtcdRegistry(config.Namespace, endpoints)
+	case RegistryStatic:
+		return static, nil
+	default:
+		return nil, fmt.Errorf("unknown service registry: %s", config.Registry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMultiRegistry(reg, static), nil
+}
+
+func pickRandom(serviceName string, addresses []string) (string, error) {
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("no healthy instances for service: %s", serviceName)
+	}
+	return addresses[rand.Intn(len(addresses))], nil
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/resolver"
+)
+
+// ResolverScheme is the target scheme resolved through the ServiceRegistry, e.g.
+// "registry:///user-service".
+const ResolverScheme = "registry"
+
+// DefaultResolveInterval re-discovers instances when Config.ResolveInterval is zero.
+const DefaultResolveInterval = 10 * time.Second
+
+// RegistryWatcher is implemented by registries that push membership changes; the
+// resolver then updates right away instead of waiting for the next poll. Watch blocks
+// until ctx is done.
+type RegistryWatcher interface {
+	Watch(ctx context.Context, serviceName string, update func(addresses []string))
+}
+
+// serviceConfig balances calls across every resolved instance and, through the health
+// service, skips instances reporting NOT_SERVING.
+const serviceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": %q}
+}`
+
+// registryBuilder builds resolvers for ResolverScheme targets.
+type registryBuilder struct {
+	reg      ServiceRegistry
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func newRegistryBuilder(reg ServiceRegistry, interval time.Duration, log *zap.Logger) *registryBuilder {
+	if interval <= 0 {
+		interval = DefaultResolveInterval
+	}
+	return &registryBuilder{reg: reg, interval: interval, log: log}
+}
+
+func (b *registryBuilder) Scheme() string {
+	return ResolverScheme
+}
+
+func (b *registryBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &registryResolver{
+		builder:     b,
+		serviceName: target.Endpoint(),
+		cc:          cc,
+		cancel:      cancel,
+		now:         make(chan struct{}, 1),
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+// registryResolver polls the registry, and watches it when it supports watching, and
+// pushes the live instances to the connection.
+type registryResolver struct {
+	builder     *registryBuilder
+	serviceName string
+	cc          resolver.ClientConn
+	cancel      context.CancelFunc
+	now         chan struct{}
+
+	mu   sync.Mutex
+	last []string
+}
+
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+}
+
+func (r *registryResolver) run(ctx context.Context) {
+	if w, ok := r.builder.reg.(RegistryWatcher); ok {
+		go w.Watch(ctx, r.serviceName, r.update)
+	}
+
+	ticker := time.NewTicker(r.builder.interval)
+	defer ticker.Stop()
+
+	for {
+		r.resolve(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.now:
+		}
+	}
+}
+
+func (r *registryResolver) resolve(ctx context.Context) {
+	addresses, err := r.builder.reg.Discover(ctx, r.serviceName)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.builder.log.Warn("gRPC/RESOLVE FAILED", zap.String("service_name", r.serviceName), zap.Error(err))
+			r.cc.ReportError(err)
+		}
+		return
+	}
+	r.update(addresses)
+}
+
+// update pushes addresses to the connection when the membership changed.
+func (r *registryResolver) update(addresses []string) {
+	addresses = slices.Clone(addresses)
+	slices.Sort(addresses)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.last != nil && slices.Equal(r.last, addresses) {
+		return
+	}
+	r.last = addresses
+
+	if len(addresses) == 0 {
+		r.cc.ReportError(fmt.Errorf("no healthy instances for service: %s", r.serviceName))
+		return
+	}
+
+	state := resolver.State{Addresses: make([]resolver.Address, 0, len(addresses))}
+	for _, addr := range addresses {
+		// Verify TLS against the instance host, as when dialing it directly
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr, ServerName: host})
+	}
+
+	if err := r.cc.UpdateState(state); err != nil {
+		r.builder.log.Debug("gRPC/RESOLVER UPDATE", zap.String("service_name", r.serviceName), zap.Error(err))
+	}
+	r.builder.log.Debug("gRPC/RESOLVED", zap.String("service_name", r.serviceName), zap.Strings("addresses", addresses))
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy retries failed unary calls with exponential backoff and full jitter.
+type RetryPolicy struct {
+	MaxAttempts    int          // total attempts including the first, 1 disables retries
+	Codes          []codes.Code // retryable status codes
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries UNAVAILABLE, which gRPC returns when the request could not
+// reach a server, e.g. a pod going away during a rollout.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	Codes:          []codes.Code{codes.Unavailable},
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// NewRetryInterceptor retries unary calls failing with one of the policy codes. It
+// never sleeps past the call deadline: when the next backoff would exceed it, the
+// last error is returned.
+func NewRetryInterceptor(policy RetryPolicy, log *zap.Logger) grpc.UnaryClientInterceptor {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if len(policy.Codes) == 0 {
+		policy.Codes = DefaultRetryPolicy.Codes
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		backoff := policy.InitialBackoff
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || !slices.Contains(policy.Codes, status.Code(err)) {
+				return err
+			}
+
+			wait := rand.N(backoff) + 1
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+				return err
+			}
+
+			log.Warn("GRPC/CLIENT RETRY",
+				zap.String("method", method),
+				zap.Int("attempt", attempt),
+				zap.Duration("backoff", wait),
+				zap.Error(err),
+			)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			backoff = min(backoff*2, policy.MaxBackoff)
+		}
+	}
+}
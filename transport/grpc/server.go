@@ -2,16 +2,18 @@ package grpc
 
 import (
 	"context"
-	"encoding/json"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/logistics-id/engine/common"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
-	"google.golang.org/protobuf/proto"
+	"google.golang.org/grpc/reflection"
 )
 
 type Server struct {
@@ -20,9 +22,16 @@ type Server struct {
 	server   *grpc.Server
 	listener net.Listener
 	reg      ServiceRegistry
+	health   *health.Server
+	once     sync.Once
 }
 
-func NewServer(config *Config, logger *zap.Logger, reg ServiceRegistry, register func(*grpc.Server)) *Server {
+// shutdownTimeout bounds unregistering and draining in-flight calls on Shutdown.
+const shutdownTimeout = 10 * time.Second
+
+func NewServer(config *Config, logger *zap.Logger, reg ServiceRegistry, register func(*grpc.Server), opts ...Option) *Server {
+	o := newOptions(opts)
+
 	logger = logger.With(
 		zap.String("action", "server"),
 		zap.String("service_name", config.ServiceName),
@@ -33,18 +42,64 @@ func NewServer(config *Config, logger *zap.Logger, reg ServiceRegistry, register
 		logger.Fatal("gRPC/PORT BIND FAILED", zap.String("addr", config.Address), zap.Error(err))
 	}
 
-	s := grpc.NewServer(
-		grpc.UnaryInterceptor(NewZapServerLogger(logger)),
-	)
+	creds, err := ServerCredentials(config.TLS)
+	if err != nil {
+		logger.Fatal("gRPC/TLS CONFIG FAILED", zap.Error(err))
+	}
+
+	// The recovery interceptors run right inside the loggers, which then log the
+	// INTERNAL error of a recovered panic
+	unary := []grpc.UnaryServerInterceptor{NewZapServerLogger(logger, config.PayloadLog), NewRecoveryUnaryInterceptor(logger)}
+	stream := []grpc.StreamServerInterceptor{NewZapStreamServerLogger(logger, config.PayloadLog), NewRecoveryStreamInterceptor(logger)}
+	if config.RequireDeadline {
+		unary = append(unary, NewRequireDeadlineInterceptor())
+	}
+	if config.Auth != nil {
+		unary = append(unary, NewAuthUnaryInterceptor(config.Auth))
+		stream = append(stream, NewAuthStreamInterceptor(config.Auth))
+	}
+	if config.RateLimit != nil {
+		if err := config.RateLimit.validate(); err != nil {
+			logger.Fatal("gRPC/RATE LIMIT CONFIG FAILED", zap.Error(err))
+		}
+
+		// After auth so callers are limited by user rather than by pod IP
+		unary = append(unary, NewRateLimitUnaryInterceptor(config.RateLimit))
+		stream = append(stream, NewRateLimitStreamInterceptor(config.RateLimit))
+	}
+	unary = append(unary, o.unaryServer...)
+	stream = append(stream, o.streamServer...)
+
+	serverOpts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if config.Keepalive != nil {
+		serverOpts = append(serverOpts, config.Keepalive.serverOptions()...)
+	}
+
+	s := grpc.NewServer(serverOpts...)
 	register(s)
 
-	return &Server{
+	srv := &Server{
 		config:   config,
 		log:      logger,
 		server:   s,
 		listener: listener,
-		reg:      NewRedisRegistry(config.Namespace, config.TTL),
+		reg:      reg,
+		health:   health.NewServer(),
 	}
+
+	healthpb.RegisterHealthServer(s, srv.health)
+	srv.SetServing(false)
+
+	if config.Reflection {
+		reflection.Register(s)
+		logger.Warn("gRPC/REFLECTION ENABLED")
+	}
+
+	return srv
 }
 
 func (s *Server) Start(ctx context.Context) error {
@@ -62,21 +117,50 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
+	s.SetServing(true)
+
 	<-ctx.Done()
 	s.Shutdown(ctx)
 	return nil
 }
 
+// Shutdown unregisters the instance and drains in-flight calls, stopping the
+// remaining ones after 10s. It is safe to call more than once and with an already
+// cancelled ctx.
 func (s *Server) Shutdown(ctx context.Context) {
-	if err := s.reg.Unregister(ctx, s.config.ServiceName, s.config.AdvertisedAddress); err != nil {
-		s.log.Error("GRPC/SERVER DEREGISTER FAILED", zap.Error(err))
-	}
+	s.once.Do(func() {
+		// Fail health checks first so clients stop picking this instance
+		s.health.Shutdown()
 
-	s.server.GracefulStop()
-	s.log.Debug("GRPC/SERVER shutdown complete")
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		if err := s.reg.Unregister(ctx, s.config.ServiceName, s.config.AdvertisedAddress); err != nil {
+			s.log.Error("GRPC/SERVER DEREGISTER FAILED", zap.Error(err))
+		}
+
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.log.Warn("GRPC/SERVER drain timed out, stopping")
+			s.server.Stop()
+		}
+		s.log.Debug("GRPC/SERVER shutdown complete")
+	})
 }
 
-func NewZapServerLogger(log *zap.Logger) grpc.UnaryServerInterceptor {
+// NewZapServerLogger logs every unary call with its redacted and capped payloads,
+// see PayloadLogConfig, and puts the request ID of the incoming metadata in the
+// handler context.
+func NewZapServerLogger(log *zap.Logger, payload ...*PayloadLogConfig) grpc.UnaryServerInterceptor {
+	pl := newPayloadLogger(payload...)
+
 	return func(
 		ctx context.Context,
 		req any,
@@ -96,23 +180,13 @@ func NewZapServerLogger(log *zap.Logger) grpc.UnaryServerInterceptor {
 			}
 		}
 
-		var reqPayload []byte
-		if pb, ok := req.(proto.Message); ok {
-			reqPayload, _ = json.Marshal(pb)
-		}
-
 		start := time.Now()
 
-		// ctx = context.WithC
 		ctx = context.WithValue(ctx, common.ContextRequestIDKey, reqID)
 
 		resp, err = handler(ctx, req)
-
-		var respPayload []byte
-		if err == nil && resp != nil {
-			if pb, ok := resp.(proto.Message); ok {
-				respPayload, err = json.Marshal(pb)
-			}
+		if err == nil && pl.skip(info.FullMethod) {
+			return resp, nil
 		}
 
 		l := log.With(
@@ -120,10 +194,8 @@ func NewZapServerLogger(log *zap.Logger) grpc.UnaryServerInterceptor {
 			zap.String("method", info.FullMethod),
 			zap.String("peer", peerAddr),
 			zap.String("request_id", reqID),
-			zap.Any("payload", json.RawMessage(reqPayload)),
-			zap.Any("response", json.RawMessage(respPayload)),
 			zap.Duration("duration", time.Since(start)),
-		)
+		).With(pl.fields(info.FullMethod, req, resp)...)
 
 		if err != nil {
 			l.Error("GRPC/SERVER", zap.Error(err))
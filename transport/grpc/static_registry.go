@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// StaticServiceRegistry resolves services to fixed "host:port" targets from Targets or,
+// for services not listed there, from GRPC_TARGET_<SERVICE> (upper-cased, "-" and "."
+// as "_"), comma-separated. Registration is a no-op.
+type StaticServiceRegistry struct {
+	Targets map[string][]string
+}
+
+func NewStaticRegistry(targets map[string][]string) *StaticServiceRegistry {
+	return &StaticServiceRegistry{Targets: targets}
+}
+
+func (r *StaticServiceRegistry) Register(ctx context.Context, serviceName, address string, ttl time.Duration) error {
+	return nil
+}
+
+func (r *StaticServiceRegistry) Unregister(ctx context.Context, serviceName, address string) error {
+	return nil
+}
+
+func (r *StaticServiceRegistry) Heartbeat(ctx context.Context, serviceName, address string, ttl time.Duration) {
+}
+
+func (r *StaticServiceRegistry) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	if targets, ok := r.Targets[serviceName]; ok {
+		return targets, nil
+	}
+
+	env := "GRPC_TARGET_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(serviceName))
+
+	var targets []string
+	for _, t := range strings.Split(os.Getenv(env), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+func (r *StaticServiceRegistry) PickOne(ctx context.Context, serviceName string) (string, error) {
+	addresses, _ := r.Discover(ctx, serviceName)
+	return pickRandom(serviceName, addresses)
+}
+
+// MultiServiceRegistry discovers through Registries in order, using the first one
+// returning instances, e.g. a registry followed by static fallback targets. Instances
+// register with the first registry only.
+type MultiServiceRegistry struct {
+	Registries []ServiceRegistry
+}
+
+func NewMultiRegistry(registries ...ServiceRegistry) *MultiServiceRegistry {
+	return &MultiServiceRegistry{Registries: registries}
+}
+
+func (m *MultiServiceRegistry) Register(ctx context.Context, serviceName, address string, ttl time.Duration) error {
+	return m.Registries[0].Register(ctx, serviceName, address, ttl)
+}
+
+func (m *MultiServiceRegistry) Unregister(ctx context.Context, serviceName, address string) error {
+	return m.Registries[0].Unregister(ctx, serviceName, address)
+}
+
+func (m *MultiServiceRegistry) Heartbeat(ctx context.Context, serviceName, address string, ttl time.Duration) {
+	m.Registries[0].Heartbeat(ctx, serviceName, address, ttl)
+}
+
+// Discover returns the instances of the first registry that has any; the error of the
+// first failing registry is returned when none has.
+func (m *MultiServiceRegistry) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	var firstErr error
+	for _, r := range m.Registries {
+		addresses, err := r.Discover(ctx, serviceName)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if len(addresses) > 0 {
+			return addresses, nil
+		}
+	}
+	return nil, firstErr
+}
+
+// Watch re-discovers whenever one of the registries supporting it reports a change.
+func (m *MultiServiceRegistry) Watch(ctx context.Context, serviceName string, update func([]string)) {
+	for _, r := range m.Registries {
+		if w, ok := r.(RegistryWatcher); ok {
+			go w.Watch(ctx, serviceName, func([]string) {
+				if addresses, err := m.Discover(ctx, serviceName); err == nil {
+					update(addresses)
+				}
+			})
+		}
+	}
+	<-ctx.Done()
+}
+
+func (m *MultiServiceRegistry) PickOne(ctx context.Context, serviceName string) (string, error) {
+	addresses, err := m.Discover(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
+	return pickRandom(serviceName, addresses)
+}
@@ -0,0 +1,202 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// NewZapStreamServerLogger logs every streaming call once it ends, with the number of
+// messages received and sent and the stream duration; messages themselves are not
+// logged. Like NewZapServerLogger it reads the request ID from the incoming metadata
+// and puts it in the handler context.
+func NewZapStreamServerLogger(log *zap.Logger, payload ...*PayloadLogConfig) grpc.StreamServerInterceptor {
+	pl := newPayloadLogger(payload...)
+
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := ss.Context()
+
+		var peerAddr string
+		if p, ok := peer.FromContext(ctx); ok {
+			peerAddr = p.Addr.String()
+		}
+
+		var reqID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vals := md.Get(string(common.ContextRequestIDKey)); len(vals) > 0 {
+				reqID = vals[0]
+			}
+		}
+
+		wrapped := &serverStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ctx, common.ContextRequestIDKey, reqID),
+		}
+
+		start := time.Now()
+		err := handler(srv, wrapped)
+		if err == nil && pl.skip(info.FullMethod) {
+			return nil
+		}
+
+		l := log.With(
+			zap.String("action", "server.stream"),
+			zap.String("method", info.FullMethod),
+			zap.String("peer", peerAddr),
+			zap.String("request_id", reqID),
+			zap.Bool("client_stream", info.IsClientStream),
+			zap.Bool("server_stream", info.IsServerStream),
+			zap.Int64("received", wrapped.received.Load()),
+			zap.Int64("sent", wrapped.sent.Load()),
+			zap.Duration("duration", time.Since(start)),
+		)
+
+		if err != nil {
+			l.Error("GRPC/SERVER", zap.Error(err))
+		} else {
+			l.Info("GRPC/SERVER")
+		}
+
+		return err
+	}
+}
+
+// serverStream counts messages and overrides the stream context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+// NewZapStreamClientLogger logs every streaming call when it ends (the server closed
+// it, it failed or its context was cancelled), with message counts and duration.
+func NewZapStreamClientLogger(log *zap.Logger, payload ...*PayloadLogConfig) grpc.StreamClientInterceptor {
+	pl := newPayloadLogger(payload...)
+
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		reqID := common.GetContextRequestID(ctx)
+
+		l := log.With(
+			zap.String("action", "client.stream"),
+			zap.String("method", method),
+			zap.String("service_host", cc.Target()),
+			zap.String("request_id", reqID),
+		)
+
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			l.Error("GRPC/CLIENT", zap.Duration("duration", time.Since(start)), zap.Error(err))
+			return nil, err
+		}
+
+		s := &clientStream{ClientStream: cs, log: l, start: start, serverStreams: desc.ServerStreams, skip: pl.skip(method)}
+		go func() {
+			// A stream abandoned by the caller ends with its context
+			<-cs.Context().Done()
+			s.finish(cs.Context().Err())
+		}()
+		return s, nil
+	}
+}
+
+// clientStream counts messages and logs once when the stream ends.
+type clientStream struct {
+	grpc.ClientStream
+	log           *zap.Logger
+	start         time.Time
+	serverStreams bool
+	skip          bool // only log failures
+	received      atomic.Int64
+	sent          atomic.Int64
+	once          sync.Once
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	} else if !errors.Is(err, io.EOF) {
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.received.Add(1)
+		if !s.serverStreams {
+			// The single response completes a client-streaming call
+			s.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		if err == nil && s.skip {
+			return
+		}
+
+		l := s.log.With(
+			zap.Int64("received", s.received.Load()),
+			zap.Int64("sent", s.sent.Load()),
+			zap.Duration("duration", time.Since(s.start)),
+		)
+
+		if err != nil {
+			l.Error("GRPC/CLIENT", zap.Error(err))
+		} else {
+			l.Info("GRPC/CLIENT")
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+)
+
+// MaxBodyBytes limits request bodies to n bytes using http.MaxBytesReader. Requests
+// declaring a larger Content-Length are rejected with 413 up front; bodies that turn
+// out larger make Bind/BindForm fail with the same 413 error.
+func MaxBodyBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				ctx := &Context{
+					Context:  r.Context(),
+					Request:  r,
+					Response: w,
+				}
+				_ = ctx.Error(http.StatusRequestEntityTooLarge, MsgTooLarge, nil)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bodyError maps body read failures to 413 when the body limit was hit, 400 otherwise.
+func bodyError(err error) HTTPError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return TooLarge()
+	}
+	return BadRequest()
+}
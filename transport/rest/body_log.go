@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Redacted replaces the value of sensitive fields in logged bodies.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the field names masked when BodyLogConfig.RedactKeys is empty.
+var DefaultRedactKeys = []string{
+	"password", "password_confirmation", "old_password", "new_password",
+	"token", "access_token", "refresh_token", "id_token", "secret", "client_secret",
+	"authorization", "api_key", "pin", "otp", "nik", "card_number", "cvv",
+}
+
+// BodyLogConfig enables request and response body capture in LoggingMiddleware.
+type BodyLogConfig struct {
+	// MaxSize caps the captured bytes per body, default 4KB. Larger bodies are truncated.
+	MaxSize int
+
+	// RedactKeys are JSON/form field names (case-insensitive) whose values are masked
+	// at any depth, default DefaultRedactKeys.
+	RedactKeys []string
+
+	// Skip excludes requests from body capture, e.g. file uploads or health checks.
+	Skip func(*http.Request) bool
+}
+
+func (cfg *BodyLogConfig) setDefault() *BodyLogConfig {
+	c := *cfg
+	if c.MaxSize <= 0 {
+		c.MaxSize = 4 << 10
+	}
+	if len(c.RedactKeys) == 0 {
+		c.RedactKeys = DefaultRedactKeys
+	}
+	return &c
+}
+
+// bodyLogger captures and redacts bodies for a single LoggingMiddleware.
+type bodyLogger struct {
+	cfg  *BodyLogConfig
+	keys map[string]struct{}
+	re   *regexp.Regexp
+}
+
+func newBodyLogger(cfg *BodyLogConfig) *bodyLogger {
+	if cfg == nil {
+		return nil
+	}
+	cfg = cfg.setDefault()
+
+	b := &bodyLogger{cfg: cfg, keys: make(map[string]struct{}, len(cfg.RedactKeys))}
+	quoted := make([]string, 0, len(cfg.RedactKeys))
+	for _, k := range cfg.RedactKeys {
+		b.keys[strings.ToLower(k)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+
+	// Fallback for truncated JSON that can no longer be decoded: "key": "value" or "key": 123
+	b.re = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+	return b
+}
+
+// capture wraps the request body so the bytes read by the handler are recorded up to
+// MaxSize. It returns nil when body logging is disabled or skipped for the request.
+func (b *bodyLogger) capture(r *http.Request) *cappedBuffer {
+	if b == nil || (b.cfg.Skip != nil && b.cfg.Skip(r)) {
+		return nil
+	}
+
+	buf := &cappedBuffer{limit: b.cfg.MaxSize}
+	if r.Body != nil && r.Body != http.NoBody && loggableType(r.Header.Get("Content-Type")) {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, buf), r.Body}
+	}
+	return buf
+}
+
+func (b *bodyLogger) fields(req *cappedBuffer, reqType string, rec *responseRecorder) []zap.Field {
+	var fields []zap.Field
+
+	if req != nil && req.buf.Len() > 0 {
+		fields = append(fields,
+			zap.String("request_body", b.redact(req.buf.Bytes(), reqType, req.truncated)),
+			zap.Bool("request_body_truncated", req.truncated),
+		)
+	}
+
+	resType := rec.Header().Get("Content-Type")
+	if rec.body != nil && rec.body.buf.Len() > 0 && loggableType(resType) {
+		fields = append(fields,
+			zap.String("response_body", b.redact(rec.body.buf.Bytes(), resType, rec.body.truncated)),
+			zap.Bool("response_body_truncated", rec.body.truncated),
+		)
+	}
+
+	return fields
+}
+
+// redact masks configured keys in JSON or form bodies; other text is returned as is.
+func (b *bodyLogger) redact(body []byte, contentType string, truncated bool) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return Redacted
+		}
+		for k := range values {
+			if _, ok := b.keys[strings.ToLower(k)]; ok {
+				values[k] = []string{Redacted}
+			}
+		}
+		return values.Encode()
+
+	case strings.HasSuffix(mt, "json"):
+		if !truncated {
+			var v any
+			if err := json.Unmarshal(body, &v); err == nil {
+				out, _ := json.Marshal(b.redactValue(v))
+				return string(out)
+			}
+		}
+		return b.re.ReplaceAllString(string(body), `${1}"`+Redacted+`"`)
+	}
+
+	return string(body)
+}
+
+func (b *bodyLogger) redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if _, ok := b.keys[strings.ToLower(k)]; ok {
+				t[k] = Redacted
+				continue
+			}
+			t[k] = b.redactValue(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = b.redactValue(t[i])
+		}
+	}
+	return v
+}
+
+// loggableType reports whether a body of the content type is text worth logging;
+// binary payloads and multipart uploads are skipped.
+func loggableType(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasSuffix(mt, "json") ||
+		strings.HasSuffix(mt, "xml") ||
+		strings.HasPrefix(mt, "text/") ||
+		mt == "application/x-www-form-urlencoded"
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.limit - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+			c.truncated = true
+		} else {
+			c.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		c.truncated = true
+	}
+	return len(p), nil
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
@@ -19,6 +20,11 @@ import (
 	"github.com/logistics-id/engine/validate"
 )
 
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	stringMapType = reflect.TypeOf(map[string]string{})
+)
+
 type Context struct {
 	context.Context
 
@@ -54,7 +60,7 @@ func (c *Context) Bind(v any) error {
 		// decoder.DisallowUnknownFields()
 		if err := decoder.Decode(v); err != nil {
 			c.logger.Warn("Bind error", zap.Error(err))
-			return BadRequest()
+			return bodyError(err)
 		}
 	}
 
@@ -115,6 +121,9 @@ func (c *Context) Validate(obj any) (resp *validate.Response) {
 func (c *Context) lazyinit() {
 	c.once.Do(func() {
 		c.validator = validate.New()
+		if lang := c.Locale(); lang != DefaultLocale {
+			c.validator.Language = lang
+		}
 	})
 }
 
@@ -132,9 +141,16 @@ func (c *Context) Text(code int, msg string) {
 	c.Response.Write([]byte(msg))
 }
 
-// Error returns a structured error response with the given status code
+// Error returns a structured error response with the given status code,
+// as application/problem+json when the server enables Config.ProblemDetails.
 func (c *Context) Error(code int, message Message, errs any) error {
-	return c.JSON(code, ResponseBody{
+	message = c.T(message)
+
+	if c.problemDetails() {
+		return c.Problem(code, string(message), errs)
+	}
+
+	return c.Render(code, ResponseBody{
 		Success: false,
 		Message: string(message),
 		Errors:  errs,
@@ -159,6 +175,17 @@ func (c *Context) Param(key string) string {
 }
 
 func (c *Context) Respond(body any, err error) error {
+	if err != nil {
+		if he, ok := mapError(err); ok {
+			return c.Error(he.Code, he.Message, nil)
+		}
+	}
+
+	var (
+		ve *validate.Response
+		he HTTPError
+	)
+
 	switch {
 	case err == nil:
 		// Determine status code: use custom if set,
@@ -171,51 +198,34 @@ func (c *Context) Respond(body any, err error) error {
 			}
 
 			if rb.Message == "" {
-				rb.Message = string(MsgSuccess)
+				rb.Message = string(c.T(MsgSuccess))
 			}
 			rb.Success = true
-			return c.JSON(statusCode, rb)
+			return c.Render(statusCode, rb)
 		}
 
-		return c.JSON(statusCode, ResponseBody{
+		return c.Render(statusCode, ResponseBody{
 			Success: true,
-			Message: string(MsgSuccess),
+			Message: string(c.T(MsgSuccess)),
 			Data:    body,
 		})
 
-	case errors.As(err, new(*validate.Response)):
-		ve := err.(*validate.Response)
-		return c.JSON(http.StatusUnprocessableEntity, ResponseBody{
-			Success: false,
-			Message: string(MsgValidationError),
-			Errors:  ve.GetMessages(),
-		})
+	case errors.As(err, &ve):
+		return c.Error(http.StatusUnprocessableEntity, MsgValidationError, ve.GetMessages())
 
-	case errors.As(err, new(HTTPError)):
-		he := err.(HTTPError)
-		return c.JSON(he.Code, ResponseBody{
-			Success: false,
-			Message: he.Error(),
-		})
+	case errors.As(err, &he):
+		return c.Error(he.Code, he.Message, nil)
 
 	case errors.Is(err, sql.ErrNoRows):
-		return c.JSON(http.StatusNotFound, ResponseBody{
-			Success: false,
-			Message: string(MsgNotFound),
-			Errors:  err.Error(),
-		})
+		return c.Error(http.StatusNotFound, MsgNotFound, err.Error())
 
 	default:
-		return c.JSON(http.StatusInternalServerError, ResponseBody{
-			Success: false,
-			Message: string(MsgInternalError),
-			Errors:  err.Error(),
-		})
+		return c.Error(http.StatusInternalServerError, MsgInternalError, err.Error())
 	}
 }
 
 func (c *Context) bindQueryParams(v any) error {
-	return bindStructFields(v, c.Request.URL.Query())
+	return bindStructFields(v, c.Request.URL.Query(), "query")
 }
 
 func setFieldValue(field reflect.Value, value string) error {
@@ -241,22 +251,31 @@ func setFieldValue(field reflect.Value, value string) error {
 		return nil
 	}
 
+	if field.Type() == timeType {
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
-	case reflect.Int, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		i, err := strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			return err
 		}
 		field.SetInt(i)
-	case reflect.Uint, reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		u, err := strconv.ParseUint(value, 10, 64)
 		if err != nil {
 			return err
 		}
 		field.SetUint(u)
-	case reflect.Float64:
+	case reflect.Float32, reflect.Float64:
 		f, err := strconv.ParseFloat(value, 64)
 		if err != nil {
 			return err
@@ -272,7 +291,48 @@ func setFieldValue(field reflect.Value, value string) error {
 	return nil
 }
 
-func bindStructFields(v any, values url.Values) error {
+// parseTime accepts RFC3339 timestamps and date-only (2006-01-02) values.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
+
+// setSliceValue binds repeated query params (?id=1&id=2) into a slice field.
+func setSliceValue(field reflect.Value, values []string) error {
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, v := range values {
+		if err := setFieldValue(slice.Index(i), v); err != nil {
+			return err
+		}
+	}
+
+	field.Set(slice)
+	return nil
+}
+
+// setMapValue binds bracketed query params (?filter[status]=done) into a map[string]string field.
+func setMapValue(field reflect.Value, tag string, values url.Values) {
+	prefix := tag + "["
+	m := reflect.MakeMap(field.Type())
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") || len(vals) == 0 {
+			continue
+		}
+
+		name := key[len(prefix) : len(key)-1]
+		m.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(vals[0]))
+	}
+
+	if m.Len() > 0 {
+		field.Set(m)
+	}
+}
+
+func bindStructFields(v any, values url.Values, tagName string) error {
 	val := reflect.ValueOf(v).Elem()
 	typ := val.Type()
 
@@ -282,17 +342,44 @@ func bindStructFields(v any, values url.Values) error {
 
 		if fieldType.Anonymous && field.Kind() == reflect.Struct {
 			ptr := field.Addr().Interface()
-			if err := bindStructFields(ptr, values); err != nil {
+			if err := bindStructFields(ptr, values, tagName); err != nil {
 				return err
 			}
 			continue
 		}
 
-		tag := fieldType.Tag.Get("query")
+		tag := fieldType.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
 		if tag == "" {
 			tag = strings.ToLower(fieldType.Name)
 		}
 
+		if !field.CanSet() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Slice:
+			vals := values[tag]
+			if len(vals) == 0 {
+				vals = values[tag+"[]"]
+			}
+			if len(vals) == 0 {
+				continue
+			}
+
+			if err := setSliceValue(field, vals); err != nil {
+				return fmt.Errorf("failed to bind field '%s': %w", tag, err)
+			}
+			continue
+
+		case field.Kind() == reflect.Map && field.Type() == stringMapType:
+			setMapValue(field, tag, values)
+			continue
+		}
+
 		paramVal := values.Get(tag) // ← this works because it's url.Values
 		if paramVal == "" {
 			continue
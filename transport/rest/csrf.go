@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CSRFConfig configures the double-submit-cookie CSRF protection.
+type CSRFConfig struct {
+	CookieName string        // default "csrf_token"
+	HeaderName string        // default "X-CSRF-Token"
+	FormField  string        // default "csrf_token", checked when the header is absent
+	Path       string        // cookie path, default "/"
+	Domain     string        // cookie domain
+	Secure     bool          // send the cookie over HTTPS only
+	SameSite   http.SameSite // default http.SameSiteLaxMode
+	MaxAge     time.Duration // cookie lifetime, default 12h
+
+	// Skip exempts requests from validation; by default none is. A request with an
+	// Authorization header may still be authenticated by its session cookie, so only
+	// skip requests that cannot be, e.g. bearer requests without a session cookie.
+	Skip func(*http.Request) bool
+}
+
+func (cfg *CSRFConfig) setDefault() *CSRFConfig {
+	c := CSRFConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+
+	if c.CookieName == "" {
+		c.CookieName = "csrf_token"
+	}
+	if c.HeaderName == "" {
+		c.HeaderName = "X-CSRF-Token"
+	}
+	if c.FormField == "" {
+		c.FormField = "csrf_token"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 12 * time.Hour
+	}
+	if c.Skip == nil {
+		c.Skip = func(*http.Request) bool { return false }
+	}
+
+	return &c
+}
+
+// CSRFMiddleware validates that state-changing requests (POST, PUT, PATCH, DELETE)
+// echo the CSRF cookie value in the header (or form field), answering 403 otherwise.
+// Safe requests without a token cookie receive a fresh one.
+func CSRFMiddleware(cfg *CSRFConfig) func(http.Handler) http.Handler {
+	cfg = cfg.setDefault()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(cfg.CookieName)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				if err != nil || cookie.Value == "" {
+					cfg.issue(w)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(cfg.HeaderName)
+			if token == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+				token = r.PostFormValue(cfg.FormField)
+			}
+
+			if err != nil || cookie.Value == "" || token == "" ||
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) != 1 {
+				ctx := &Context{
+					Context:  r.Context(),
+					Request:  r,
+					Response: w,
+				}
+				_ = ctx.Error(http.StatusForbidden, MsgInvalidCSRF, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFTokenRoute registers a GET endpoint that issues a new token cookie and returns
+// the token as {"token": "..."} for clients that cannot read cookies.
+func (s *RestServer) CSRFTokenRoute(path string, cfg *CSRFConfig) {
+	cfg = cfg.setDefault()
+
+	s.GET(path, func(ctx *Context) error {
+		return ctx.Respond(map[string]string{"token": cfg.issue(ctx.Response)}, nil)
+	}, nil)
+}
+
+// issue sets a new random token cookie; it is readable by scripts so the client can echo it.
+func (cfg *CSRFConfig) issue(w http.ResponseWriter) string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    token,
+		Path:     cfg.Path,
+		Domain:   cfg.Domain,
+		MaxAge:   int(cfg.MaxAge.Seconds()),
+		Secure:   cfg.Secure,
+		HttpOnly: false,
+		SameSite: cfg.SameSite,
+	})
+
+	return token
+}
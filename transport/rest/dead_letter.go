@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/logistics-id/engine/broker"
+)
+
+// deadLetterPeekLimit bounds how many messages are scanned when peeking a single dead letter.
+const deadLetterPeekLimit = 1000
+
+type requeueRequest struct {
+	IDs []string `json:"ids" valid:"required"`
+}
+
+// DeadLetterRoutes registers admin routes to inspect and requeue dead-lettered messages:
+//
+//	GET  {prefix}/{queue}?limit=n   list parked messages
+//	GET  {prefix}/{queue}/{id}      peek a single message
+//	POST {prefix}/{queue}/requeue   requeue messages by {"ids": [...]}
+//
+// The routes are only registered when called; protect them with mws (e.g. s.Restricted("dlq:manage")).
+func (s *RestServer) DeadLetterRoutes(prefix string, m broker.DeadLetterManager, mws []func(http.Handler) http.Handler) {
+	s.GET(prefix+"/{queue}", func(ctx *Context) error {
+		limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+		letters, err := m.DeadLetters(ctx, ctx.Param("queue"), limit)
+		if err != nil {
+			return ctx.Respond(nil, err)
+		}
+
+		return ctx.Respond(letters, nil)
+	}, mws)
+
+	s.GET(prefix+"/{queue}/{id}", func(ctx *Context) error {
+		letters, err := m.DeadLetters(ctx, ctx.Param("queue"), deadLetterPeekLimit)
+		if err != nil {
+			return ctx.Respond(nil, err)
+		}
+
+		for _, l := range letters {
+			if l.ID == ctx.Param("id") {
+				return ctx.Respond(l, nil)
+			}
+		}
+
+		return ctx.Respond(nil, NotFound())
+	}, mws)
+
+	s.POST(prefix+"/{queue}/requeue", func(ctx *Context) error {
+		var req requeueRequest
+		if err := ctx.Bind(&req); err != nil {
+			return ctx.Respond(nil, err)
+		}
+
+		n, err := m.Requeue(ctx, ctx.Param("queue"), req.IDs...)
+		if err != nil {
+			return ctx.Respond(nil, err)
+		}
+
+		return ctx.Respond(map[string]int{"requeued": n}, nil)
+	}, mws)
+}
@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// DebugConfig configures the opt-in runtime debug endpoints.
+type DebugConfig struct {
+	// Prefix of the debug routes, default "/debug".
+	Prefix string
+
+	// Token required in the X-Debug-Token header or ?token= query when the routes are
+	// mounted on the public router.
+	Token string
+
+	// Listen serves the debug routes on a separate internal address (e.g. "127.0.0.1:6060")
+	// instead of the public router; Token is still enforced when set.
+	Listen string
+}
+
+// EnableDebug exposes net/http/pprof, a goroutine dump, build info and runtime stats:
+//
+//	{prefix}/pprof/            pprof index, profile, trace, heap, goroutine, ...
+//	{prefix}/goroutines        full goroutine stack dump
+//	{prefix}/buildinfo         module build information
+//	{prefix}/runtime           memory and scheduler statistics
+//
+// The routes are only registered behind a token or on an internal listener.
+func (s *RestServer) EnableDebug(cfg DebugConfig) {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "/debug"
+	}
+	cfg.Prefix = "/" + strings.Trim(cfg.Prefix, "/")
+
+	if cfg.Token == "" && cfg.Listen == "" {
+		s.Log.Warn("REST/DEBUG disabled: a token or internal listener is required")
+		return
+	}
+
+	router := s.Router
+	if cfg.Listen != "" {
+		router = mux.NewRouter()
+		s.debugSrv = &http.Server{
+			Addr:              cfg.Listen,
+			Handler:           router,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	sub := router.PathPrefix(cfg.Prefix).Subrouter()
+	if cfg.Token != "" {
+		sub.Use(debugTokenMiddleware(cfg.Token))
+	}
+
+	sub.HandleFunc("/pprof/", pprofHandler(cfg.Prefix, pprof.Index))
+	sub.HandleFunc("/pprof/cmdline", pprofHandler(cfg.Prefix, pprof.Cmdline))
+	sub.HandleFunc("/pprof/profile", pprofHandler(cfg.Prefix, pprof.Profile))
+	sub.HandleFunc("/pprof/symbol", pprofHandler(cfg.Prefix, pprof.Symbol))
+	sub.HandleFunc("/pprof/trace", pprofHandler(cfg.Prefix, pprof.Trace))
+	sub.HandleFunc("/pprof/{profile}", pprofHandler(cfg.Prefix, func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	}))
+
+	sub.HandleFunc("/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+	}).Methods(http.MethodGet)
+
+	sub.HandleFunc("/buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			http.Error(w, "build info not available", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}).Methods(http.MethodGet)
+
+	sub.HandleFunc("/runtime", func(w http.ResponseWriter, r *http.Request) {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"go_version":  runtime.Version(),
+			"goroutines":  runtime.NumGoroutine(),
+			"gomaxprocs":  runtime.GOMAXPROCS(0),
+			"num_cpu":     runtime.NumCPU(),
+			"heap_alloc":  m.HeapAlloc,
+			"heap_sys":    m.HeapSys,
+			"heap_object": m.HeapObjects,
+			"total_alloc": m.TotalAlloc,
+			"sys":         m.Sys,
+			"num_gc":      m.NumGC,
+			"pause_total": time.Duration(m.PauseTotalNs).String(),
+		})
+	}).Methods(http.MethodGet)
+
+	s.Log.Info("REST/DEBUG ENABLED", zap.String("prefix", cfg.Prefix), zap.String("listen", cfg.Listen))
+}
+
+// pprofHandler serves h with prefix replaced by /debug in the request path, since
+// net/http/pprof only recognizes profile paths under /debug/pprof/.
+func pprofHandler(prefix string, h http.HandlerFunc) http.HandlerFunc {
+	if prefix == "/debug" {
+		return h
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/debug" + strings.TrimPrefix(r.URL.Path, prefix)
+		r2.URL.RawPath = ""
+		h(w, r2)
+	}
+}
+
+func debugTokenMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := r.Header.Get("X-Debug-Token")
+			if got == "" {
+				got = r.URL.Query().Get("token")
+			}
+
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				ctx := &Context{
+					Context:  r.Context(),
+					Request:  r,
+					Response: w,
+				}
+				_ = ctx.Error(http.StatusUnauthorized, MsgUnauthorized, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrorMapperFunc converts an application error into an HTTPError, reporting false
+// when it does not handle the error.
+type ErrorMapperFunc func(err error) (HTTPError, bool)
+
+var (
+	errorMappersMu sync.RWMutex
+	errorMappers   []ErrorMapperFunc
+)
+
+// RegisterError maps a domain error (matched with errors.Is) to a status code and
+// message used by Context.Respond. An empty message uses the error text.
+//
+//	rest.RegisterError(inventory.ErrInsufficientStock, http.StatusConflict, "insufficient stock")
+func RegisterError(target error, code int, message Message) {
+	RegisterErrorMapper(func(err error) (HTTPError, bool) {
+		if !errors.Is(err, target) {
+			return HTTPError{}, false
+		}
+
+		if message == "" {
+			return HTTPError{Code: code, Message: Message(err.Error())}, true
+		}
+		return HTTPError{Code: code, Message: message}, true
+	})
+}
+
+// RegisterErrorMapper adds a custom mapper, e.g. for error types matched with errors.As.
+// Mappers run in registration order before the built-in mappings.
+func RegisterErrorMapper(fn ErrorMapperFunc) {
+	errorMappersMu.Lock()
+	defer errorMappersMu.Unlock()
+
+	errorMappers = append(errorMappers, fn)
+}
+
+// mapError returns the HTTPError of the first registered mapper handling err.
+func mapError(err error) (HTTPError, bool) {
+	errorMappersMu.RLock()
+	defer errorMappersMu.RUnlock()
+
+	for _, fn := range errorMappers {
+		if he, ok := fn(err); ok {
+			return he, true
+		}
+	}
+
+	return HTTPError{}, false
+}
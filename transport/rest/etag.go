@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// etagWriter buffers the response so the ETag can be computed before anything is sent.
+type etagWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	w.statusCode = code
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// ETagMiddleware computes a weak ETag for successful JSON responses to GET and HEAD
+// requests and answers 304 Not Modified when the client's If-None-Match matches,
+// or when If-Modified-Since is not older than a Last-Modified header set by the handler.
+func ETagMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &etagWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+				w.WriteHeader(rec.statusCode)
+				w.Write(rec.body.Bytes())
+				return
+			}
+
+			sum := sha1.Sum(rec.body.Bytes())
+			etag := `W/"` + hex.EncodeToString(sum[:]) + `"`
+			w.Header().Set("ETag", etag)
+
+			if notModified(r, etag, w.Header().Get("Last-Modified")) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.WriteHeader(rec.statusCode)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// notModified evaluates If-None-Match first and falls back to If-Modified-Since (RFC 9110 §13.2.2).
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MultipartMemory is the part of a multipart body kept in memory; the rest is spooled to disk.
+	MultipartMemory int64 = 32 << 20
+
+	// MaxUploadSize is the default size limit for files read with FormFile.
+	MaxUploadSize int64 = 10 << 20
+)
+
+// UploadedFile is a multipart file whose reads are capped to the allowed size.
+type UploadedFile struct {
+	io.Reader
+
+	Filename    string
+	ContentType string
+	Size        int64
+
+	file multipart.File
+}
+
+// Close releases the underlying multipart file.
+func (f *UploadedFile) Close() error {
+	return f.file.Close()
+}
+
+// BindForm decodes an application/x-www-form-urlencoded or multipart/form-data body
+// into the given struct using `form` tags (default lowercased field name), binds path
+// params and validates the result.
+func (c *Context) BindForm(v any) error {
+	if err := c.parseForm(); err != nil {
+		c.logger.Warn("Bind form error", zap.Error(err))
+		return bodyError(err)
+	}
+
+	if err := bindStructFields(v, c.Request.PostForm, "form"); err != nil {
+		c.logger.Warn("Bind form error", zap.Error(err))
+		return BadRequest()
+	}
+
+	if err := c.bindPathParams(v); err != nil {
+		return BadRequest()
+	}
+
+	if err := c.Validate(v); !err.Valid {
+		return err
+	}
+
+	return nil
+}
+
+// FormFile returns the uploaded file for the given form field. The file is rejected
+// with 413 when larger than maxSize (MaxUploadSize by default) and reads never go past it.
+// Callers must Close the returned file.
+func (c *Context) FormFile(name string, maxSize ...int64) (*UploadedFile, error) {
+	limit := MaxUploadSize
+	if len(maxSize) > 0 && maxSize[0] > 0 {
+		limit = maxSize[0]
+	}
+
+	if err := c.parseForm(); err != nil {
+		c.logger.Warn("Form file error", zap.Error(err))
+		return nil, bodyError(err)
+	}
+
+	file, header, err := c.Request.FormFile(name)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, HTTPError{Code: http.StatusBadRequest, Message: MsgMissingField}
+		}
+		return nil, BadRequest()
+	}
+
+	if header.Size > limit {
+		file.Close()
+		return nil, TooLarge()
+	}
+
+	return &UploadedFile{
+		Reader:      io.LimitReader(file, limit),
+		Filename:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+		file:        file,
+	}, nil
+}
+
+// parseForm parses the request body once according to its content type.
+func (c *Context) parseForm() error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if c.Request.MultipartForm != nil {
+			return nil
+		}
+		return c.Request.ParseMultipartForm(MultipartMemory)
+	}
+
+	if c.Request.PostForm != nil {
+		return nil
+	}
+	return c.Request.ParseForm()
+}
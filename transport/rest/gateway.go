@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/logistics-id/engine/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GatewayRegisterFunc registers generated grpc-gateway handlers on mux, e.g. a closure
+// calling pb.RegisterOrderServiceHandlerServer (in-process) or
+// pb.RegisterOrderServiceHandler (through a gRPC connection, see GatewayDial).
+//
+// In-process handlers call the server implementation directly and bypass every gRPC
+// interceptor: authentication, rate limiting, deadlines and panic recovery. Prefer
+// GatewayDial to the service's own gRPC address, or guard in-process handlers with
+// equivalent REST middleware.
+type GatewayRegisterFunc func(ctx context.Context, mux *runtime.ServeMux) error
+
+// GatewayDial returns a GatewayRegisterFunc that dials the gRPC server at target,
+// e.g. the local one at "127.0.0.1:9090", and registers the handlers of register on
+// that connection, so gateway requests go through the server interceptors like any
+// gRPC call. The connection is plaintext and closed once the Gateway ctx is done.
+func GatewayDial(target string, register func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error) GatewayRegisterFunc {
+	return func(ctx context.Context, mux *runtime.ServeMux) error {
+		conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			<-ctx.Done()
+			_ = conn.Close()
+		}()
+
+		return register(ctx, mux, conn)
+	}
+}
+
+// Gateway serves grpc-gateway handlers for every request under prefix, so a gRPC
+// service is also exposed as JSON over HTTP without duplicate handlers. The paths are
+// the ones of the google.api.http annotations, prefix only selects the requests sent to
+// the gateway. The requests go through the router middleware (request ID, recovery,
+// logging) and mws, e.g. s.Restricted("order.read"); the request ID is forwarded as
+// metadata and gRPC errors are rendered like Context.Error.
+func (s *RestServer) Gateway(ctx context.Context, prefix string, mws []func(http.Handler) http.Handler, register ...GatewayRegisterFunc) error {
+	gw := runtime.NewServeMux(
+		runtime.WithMetadata(gatewayMetadata),
+		runtime.WithErrorHandler(s.gatewayError),
+	)
+
+	for _, fn := range register {
+		if err := fn(ctx, gw); err != nil {
+			return err
+		}
+	}
+
+	prefix = "/" + strings.Trim(prefix, "/")
+	s.Router.PathPrefix(prefix).Handler(chainMiddleware(gw, mws))
+	s.recordRoute("*", prefix, "grpc-gateway", mws)
+
+	return nil
+}
+
+// gatewayMetadata forwards the request ID; the Authorization header is forwarded by
+// the gateway itself.
+func gatewayMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	return metadata.Pairs(string(common.ContextRequestIDKey), common.GetContextRequestID(r.Context()))
+}
+
+// gatewayError renders a gRPC error with the status code it maps to and its message.
+func (s *RestServer) gatewayError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+
+	ctx := &Context{
+		Context:  r.Context(),
+		Request:  r,
+		Response: w,
+		logger:   s.Log,
+	}
+	_ = ctx.Error(runtime.HTTPStatusFromCode(st.Code()), Message(st.Message()), nil)
+}
@@ -1,27 +1,115 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
-func debugRoutes(r *mux.Router) {
-	fmt.Println("\nREGISTERED ROUTES:")
-	fmt.Println("-------------------------------------------------------------")
-	fmt.Printf("%-8s | %-25s | %-30s\n", "METHOD", "PATH", "HANDLER")
-	fmt.Println("-------------------------------------------------------------")
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware,omitempty"`
+}
+
+// Routes returns every route of the router sorted by path and method. Routes added
+// through the server helpers (GET, POST, Handle, ...) include their handler and
+// middleware names; routes added directly on Router carry their mux name only.
+func (s *RestServer) Routes() []RouteInfo {
+	s.routeMu.RLock()
+	known := make(map[string]RouteInfo, len(s.routes))
+	for _, ri := range s.routes {
+		known[ri.Method+" "+ri.Path] = ri
+	}
+	s.routeMu.RUnlock()
+
+	var routes []RouteInfo
+	_ = s.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			if path, err = route.GetPathRegexp(); err != nil {
+				return nil
+			}
+		}
 
-	_ = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-		path, _ := route.GetPathTemplate()
-		methods, _ := route.GetMethods()
-		name := route.GetName()
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
 
 		for _, m := range methods {
-			fmt.Printf("%-8s | %-25s | %-30s\n", m, path, name)
+			if ri, ok := known[m+" "+path]; ok {
+				routes = append(routes, ri)
+				continue
+			}
+			routes = append(routes, RouteInfo{Method: m, Path: path, Handler: route.GetName()})
 		}
 		return nil
 	})
 
-	fmt.Println("-------------------------------------------------------------")
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// ExportRoutes writes the route table as JSON, e.g. to generate gateway configuration.
+func (s *RestServer) ExportRoutes(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Routes())
+}
+
+// RoutesRoute registers a GET endpoint returning the route table as JSON,
+// typically restricted to admins for permission audits.
+func (s *RestServer) RoutesRoute(path string, mws []func(http.Handler) http.Handler) {
+	s.GET(path, func(ctx *Context) error {
+		return ctx.Respond(s.Routes(), nil)
+	}, mws)
+}
+
+// PrintRoutes writes the route table as text, it runs at startup when Config.IsDev is set.
+func (s *RestServer) PrintRoutes(w io.Writer) {
+	fmt.Fprintln(w, "\nREGISTERED ROUTES:")
+	fmt.Fprintln(w, "-------------------------------------------------------------")
+	fmt.Fprintf(w, "%-8s | %-25s | %-30s | %s\n", "METHOD", "PATH", "HANDLER", "MIDDLEWARE")
+	fmt.Fprintln(w, "-------------------------------------------------------------")
+
+	for _, ri := range s.Routes() {
+		fmt.Fprintf(w, "%-8s | %-25s | %-30s | %s\n", ri.Method, ri.Path, ri.Handler, strings.Join(ri.Middleware, ", "))
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------")
+}
+
+func (s *RestServer) recordRoute(method, path, handler string, mws []func(http.Handler) http.Handler) {
+	ri := RouteInfo{Method: method, Path: path, Handler: handler}
+	for _, mw := range mws {
+		ri.Middleware = append(ri.Middleware, middlewareName(mw))
+	}
+
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	s.routes = append(s.routes, ri)
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.\d+)+$`)
+
+// middlewareName returns the constructor of a middleware closure,
+// e.g. rest.JWTAuthMiddleware for rest.JWTAuthMiddleware.func1 (or .1 when inlined).
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	return closureSuffix.ReplaceAllString(handlerName(mw), "")
 }
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultProbeTimeout bounds a readiness probe registered without a timeout.
+const DefaultProbeTimeout = 2 * time.Second
+
+// Probe checks a dependency, returning an error when it is not usable.
+type Probe func(ctx context.Context) error
+
+type probe struct {
+	name    string
+	fn      Probe
+	timeout time.Duration
+}
+
+type probeResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AddProbe registers a dependency check (e.g. postgres or redis ping) executed by /readyz.
+// A zero timeout uses DefaultProbeTimeout.
+func (s *RestServer) AddProbe(name string, fn Probe, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+
+	s.probes = append(s.probes, probe{name: name, fn: fn, timeout: timeout})
+}
+
+// registerProbeRoutes adds /livez, which reports the process is serving, and /readyz,
+// which runs every probe concurrently and answers 503 when one fails or the server
+// is shutting down so load balancers stop routing traffic to it.
+func (s *RestServer) registerProbeRoutes() {
+	s.Router.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, http.StatusOK, map[string]any{"status": "ok"})
+	}).Methods(http.MethodGet)
+
+	s.Router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if s.draining.Load() {
+			writeProbe(w, http.StatusServiceUnavailable, map[string]any{"status": "draining"})
+			return
+		}
+
+		s.probeMu.RLock()
+		probes := append([]probe(nil), s.probes...)
+		s.probeMu.RUnlock()
+
+		results := make(map[string]probeResult, len(probes))
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+
+		for _, p := range probes {
+			wg.Add(1)
+			go func(p probe) {
+				defer wg.Done()
+
+				res := runProbe(r.Context(), p)
+
+				mu.Lock()
+				results[p.name] = res
+				mu.Unlock()
+			}(p)
+		}
+		wg.Wait()
+
+		status, code := "ready", http.StatusOK
+		for _, res := range results {
+			if res.Status != "up" {
+				status, code = "degraded", http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		writeProbe(w, code, map[string]any{"status": status, "checks": results})
+	}).Methods(http.MethodGet)
+}
+
+func runProbe(ctx context.Context, p probe) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := probeResult{Status: "up", Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = "down"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func writeProbe(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
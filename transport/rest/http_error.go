@@ -24,6 +24,10 @@ const (
 	MsgServiceUnavailable Message = "service unavailable"
 	MsgBadRequest         Message = "invalid request body. please check your input format"
 	MsgNotAllowed         Message = "method not allowed"
+	MsgTooLarge           Message = "request entity too large"
+	MsgTooManyRequests    Message = "too many requests"
+	MsgGatewayTimeout     Message = "request timed out"
+	MsgInvalidCSRF        Message = "invalid csrf token"
 )
 
 type HTTPError struct {
@@ -58,3 +62,15 @@ func NotFound() HTTPError {
 func NotAllowed() HTTPError {
 	return HTTPError{Code: http.StatusMethodNotAllowed, Message: MsgNotAllowed}
 }
+
+func TooLarge() HTTPError {
+	return HTTPError{Code: http.StatusRequestEntityTooLarge, Message: MsgTooLarge}
+}
+
+func TooManyRequests() HTTPError {
+	return HTTPError{Code: http.StatusTooManyRequests, Message: MsgTooManyRequests}
+}
+
+func GatewayTimeout() HTTPError {
+	return HTTPError{Code: http.StatusGatewayTimeout, Message: MsgGatewayTimeout}
+}
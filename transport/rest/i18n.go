@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/logistics-id/engine/validate"
+)
+
+// DefaultLocale is used when Accept-Language matches no registered catalog.
+var DefaultLocale = "en"
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = map[string]map[Message]string{
+		"id": {
+			MsgSuccess:            "berhasil",
+			MsgCreated:            "data berhasil dibuat",
+			MsgUpdated:            "data berhasil diperbarui",
+			MsgDeleted:            "data berhasil dihapus",
+			MsgInvalidJSON:        "isi permintaan tidak valid",
+			MsgMissingField:       "kolom wajib belum diisi",
+			MsgInvalidField:       "nilai kolom tidak valid",
+			MsgValidationError:    "validasi gagal",
+			MsgUnauthorized:       "tidak terautentikasi",
+			MsgForbidden:          "akses ditolak",
+			MsgNotFound:           "data tidak ditemukan",
+			MsgConflict:           "data bertentangan",
+			MsgInternalError:      "terjadi kesalahan pada server",
+			MsgServiceUnavailable: "layanan tidak tersedia",
+			MsgBadRequest:         "isi permintaan tidak valid. periksa kembali format input anda",
+			MsgNotAllowed:         "metode tidak diizinkan",
+			MsgTooLarge:           "ukuran permintaan terlalu besar",
+			MsgTooManyRequests:    "terlalu banyak permintaan",
+			MsgGatewayTimeout:     "permintaan melebihi batas waktu",
+			MsgInvalidCSRF:        "token csrf tidak valid",
+		},
+	}
+)
+
+// RegisterMessages adds or overrides translations of response messages for a language.
+// Validation messages are registered separately with validate.RegisterTranslation.
+func RegisterMessages(lang string, messages map[Message]string) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if catalog[lang] == nil {
+		catalog[lang] = map[Message]string{}
+	}
+	for k, v := range messages {
+		catalog[lang][k] = v
+	}
+}
+
+// Locale returns the request language: a locale set on the context under
+// common.ContextLocaleKey, else the first Accept-Language entry with a registered
+// catalog, else DefaultLocale.
+func (c *Context) Locale() string {
+	if c.Request == nil {
+		return DefaultLocale
+	}
+
+	if l, ok := c.Request.Context().Value(common.ContextLocaleKey).(string); ok && l != "" {
+		return l
+	}
+
+	for _, part := range strings.Split(c.Request.Header.Get("Accept-Language"), ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		if tag == "" {
+			continue
+		}
+
+		// id-ID, in (legacy ISO code for Indonesian) → id
+		lang := strings.SplitN(tag, "-", 2)[0]
+		if lang == "in" {
+			lang = "id"
+		}
+
+		if lang == DefaultLocale || hasCatalog(lang) || validate.HasTranslation(lang) {
+			return lang
+		}
+	}
+
+	return DefaultLocale
+}
+
+// T translates a response message into the request locale.
+func (c *Context) T(msg Message) Message {
+	lang := c.Locale()
+	if lang == DefaultLocale {
+		return msg
+	}
+
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	if m, ok := catalog[lang][msg]; ok {
+		return Message(m)
+	}
+	return msg
+}
+
+func hasCatalog(lang string) bool {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	_, ok := catalog[lang]
+	return ok
+}
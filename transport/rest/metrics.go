@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus collectors recorded by MetricsMiddleware.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// NewMetrics creates the HTTP collectors under namespace and registers them with reg.
+func NewMetrics(namespace string, reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_response_size_bytes",
+			Help:      "HTTP response size in bytes.",
+			Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests being served.",
+		}, []string{"method", "route"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.size, m.inFlight)
+	return m
+}
+
+// Middleware records request count, latency, response size and in-flight requests
+// labeled by method, route template and status.
+func (m *Metrics) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			route := routeTemplate(r)
+
+			inFlight := m.inFlight.WithLabelValues(r.Method, route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			rec := &metricsWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			status := strconv.Itoa(rec.statusCode)
+			m.requests.WithLabelValues(r.Method, route, status).Inc()
+			m.duration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+			m.size.WithLabelValues(r.Method, route, status).Observe(float64(rec.size))
+		})
+	}
+}
+
+// MetricsMiddleware records HTTP metrics into the default Prometheus registry.
+func MetricsMiddleware() func(http.Handler) http.Handler {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewMetrics("", prometheus.DefaultRegisterer)
+	})
+
+	return defaultMetrics.Middleware()
+}
+
+// MetricsRoute exposes the default Prometheus registry at path (e.g. "/metrics").
+func (s *RestServer) MetricsRoute(path string, mws ...func(http.Handler) http.Handler) {
+	s.Router.Handle(path, chainMiddleware(promhttp.Handler(), mws)).Methods(http.MethodGet)
+}
+
+// routeTemplate returns the matched mux path template to keep label cardinality bounded.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
+
+type metricsWriter struct {
+	http.ResponseWriter
+	statusCode int
+	size       int
+}
+
+func (w *metricsWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *metricsWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *metricsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+}
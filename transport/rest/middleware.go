@@ -1,7 +1,6 @@
 package rest
 
 import (
-	"bytes"
 	"context"
 	"net/http"
 	"runtime/debug"
@@ -25,7 +24,14 @@ func RequestIDMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
-func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
+// LoggingMiddleware logs every request. Passing a BodyLogConfig also records the
+// request and response bodies, capped in size and with sensitive fields redacted.
+func LoggingMiddleware(logger *zap.Logger, body ...*BodyLogConfig) func(http.Handler) http.Handler {
+	var bl *bodyLogger
+	if len(body) > 0 {
+		bl = newBodyLogger(body[0])
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -34,12 +40,17 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 			rec := &responseRecorder{
 				ResponseWriter: w,
 				statusCode:     http.StatusOK,
-				body:           &bytes.Buffer{},
+			}
+
+			reqType := r.Header.Get("Content-Type")
+			reqBody := bl.capture(r)
+			if reqBody != nil {
+				rec.body = &cappedBuffer{limit: bl.cfg.MaxSize}
 			}
 
 			next.ServeHTTP(rec, r)
 
-			logger.Info("REST/SERVER",
+			fields := []zap.Field{
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("query", r.URL.RawQuery),
@@ -48,7 +59,12 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 				zap.String("remote", getRealIP(r)),
 				zap.String("request_id", reqID),
 				zap.Duration("duration", time.Since(start)),
-			)
+			}
+			if reqBody != nil {
+				fields = append(fields, bl.fields(reqBody, reqType, rec)...)
+			}
+
+			logger.Info("REST/SERVER", fields...)
 		})
 	}
 }
@@ -85,7 +101,7 @@ func CORSMiddleware() func(http.Handler) http.Handler {
 			// Note: header matching is case-insensitive, but browsers compare against this allow-list.
 			w.Header().Set(
 				"Access-Control-Allow-Headers",
-				"Content-Type, Authorization, X-Requested-With, X-Terminal-ID, X-Gate-Lane-ID",
+				"Content-Type, Authorization, X-Requested-With, X-Terminal-ID, X-Gate-Lane-ID, X-CSRF-Token",
 			)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 			if r.Method == http.MethodOptions {
@@ -151,6 +167,7 @@ func JWTAuthMiddleware() func(http.Handler) http.Handler {
 			}
 
 			ctxUsr := context.WithValue(r.Context(), common.ContextUserKey, claims)
+			ctxUsr = context.WithValue(ctxUsr, common.ContextSessionKey, tokenStr)
 			next.ServeHTTP(w, r.WithContext(ctxUsr))
 		})
 	}
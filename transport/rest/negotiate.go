@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// Supported response media types.
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEProtobuf = "application/x-protobuf"
+)
+
+var errNotProto = errors.New("response data is not a proto.Message")
+
+// mediaAliases maps accepted Accept values to the canonical media type.
+var mediaAliases = map[string]string{
+	"application/json":       MIMEJSON,
+	"application/xml":        MIMEXML,
+	"text/xml":               MIMEXML,
+	"application/msgpack":    MIMEMsgPack,
+	"application/x-msgpack":  MIMEMsgPack,
+	"application/x-protobuf": MIMEProtobuf,
+	"application/protobuf":   MIMEProtobuf,
+}
+
+// Negotiate returns the response media type preferred by the Accept header,
+// defaulting to JSON.
+func (c *Context) Negotiate() string {
+	type accepted struct {
+		media string
+		q     float64
+	}
+
+	var prefs []accepted
+	for _, part := range strings.Split(c.Request.Header.Get("Accept"), ",") {
+		media, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, accepted{media, q})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		if media, ok := mediaAliases[p.media]; ok {
+			return media
+		}
+		if p.media == "*/*" || p.media == "application/*" {
+			return MIMEJSON
+		}
+	}
+
+	return MIMEJSON
+}
+
+// Render writes body in the media type negotiated from the Accept header.
+// Protobuf is only used when the response data is a proto.Message; any type
+// that cannot be encoded in the requested format falls back to JSON.
+func (c *Context) Render(code int, body any) error {
+	media := c.Negotiate()
+	if media == MIMEJSON {
+		return c.JSON(code, body)
+	}
+
+	data, err := encode(media, body)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Warn("Render fallback to json", zap.String("accept", media), zap.Error(err))
+		}
+		return c.JSON(code, body)
+	}
+
+	c.Response.Header().Set("Content-Type", media)
+	c.Response.WriteHeader(code)
+	_, err = c.Response.Write(data)
+	return err
+}
+
+// XML writes an XML response with status code
+func (c *Context) XML(code int, data any) error {
+	c.Response.Header().Set("Content-Type", MIMEXML)
+	c.Response.WriteHeader(code)
+	return xml.NewEncoder(c.Response).Encode(data)
+}
+
+func encode(media string, body any) ([]byte, error) {
+	switch media {
+	case MIMEXML:
+		return xml.Marshal(body)
+
+	case MIMEMsgPack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case MIMEProtobuf:
+		data := body
+		switch rb := body.(type) {
+		case ResponseBody:
+			data = rb.Data
+		case *ResponseBody:
+			data = rb.Data
+		}
+
+		if msg, ok := data.(proto.Message); ok {
+			return proto.Marshal(msg)
+		}
+		return nil, errNotProto
+	}
+
+	return json.Marshal(body)
+}
@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"slices"
+
+	"github.com/logistics-id/engine/common"
+)
+
+// MaxPageLimit caps the page size clients can request through BindQueryOption.
+var MaxPageLimit int64 = 100
+
+// BindQueryOption parses page, limit, sort (or order_by), search and
+// filter[field] query params into a common.QueryOption. Each filter of one of
+// filterFields is added to the Conditions as an equality, so repositories apply it;
+// a filter of any other field is a bad request.
+func (c *Context) BindQueryOption(filterFields ...string) (*common.QueryOption, error) {
+	opts := &common.QueryOption{}
+	if err := c.bindQueryParams(opts); err != nil {
+		return nil, BadRequest()
+	}
+
+	if opts.OrderBy == "" {
+		opts.OrderBy = c.Query("sort")
+	}
+
+	if opts.Page < 0 || opts.Limit < 0 {
+		return nil, BadRequest()
+	}
+
+	if opts.Limit > MaxPageLimit {
+		opts.Limit = MaxPageLimit
+	}
+
+	// sorted so the conditions, and the queries built from them, are stable
+	fields := make([]string, 0, len(opts.Filters))
+	for field := range opts.Filters {
+		if !slices.Contains(filterFields, field) {
+			return nil, BadRequest()
+		}
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	for _, field := range fields {
+		opts.Conditions = append(opts.Conditions, common.Where(field, common.OpEq, opts.Filters[field]))
+	}
+
+	return opts, nil
+}
+
+// Paginated builds a response body for a page of data with meta computed from opts.
+func Paginated(data any, total int64, opts *common.QueryOption) *ResponseBody {
+	meta := BuildMeta(opts.GetPage(), opts.GetLimit(), total)
+	if total == 0 {
+		meta = &Meta{Page: opts.GetPage(), PageSize: opts.GetLimit()}
+	}
+
+	return NewResponseBody(data, meta)
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/logistics-id/engine/common"
+)
+
+// MIMEProblem is the RFC 7807 media type for error responses.
+const MIMEProblem = "application/problem+json"
+
+// ProblemType is used as the problem "type" member; override it with a URI documenting
+// your error types. RFC 7807 defaults to about:blank, meaning the title is the HTTP status text.
+var ProblemType = "about:blank"
+
+// Problem is an RFC 7807 problem details body. Validation errors are carried in
+// the "errors" extension member.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Errors    any    `json:"errors,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type problemDetailsKey struct{}
+
+// ProblemDetailsMiddleware makes every Context.Error in the request emit RFC 7807
+// problem details. NewServer installs it when Config.ProblemDetails is set.
+func ProblemDetailsMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), problemDetailsKey{}, true)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Problem writes an application/problem+json response.
+func (c *Context) Problem(code int, detail string, errs any) error {
+	p := Problem{
+		Type:   ProblemType,
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+		Errors: errs,
+	}
+
+	if c.Request != nil {
+		p.Instance = c.Request.URL.Path
+		p.RequestID = common.GetContextRequestID(c.Request.Context())
+	}
+
+	c.Response.Header().Set("Content-Type", MIMEProblem)
+	c.Response.WriteHeader(code)
+	return json.NewEncoder(c.Response).Encode(p)
+}
+
+func (c *Context) problemDetails() bool {
+	if c.Request == nil {
+		return false
+	}
+
+	enabled, _ := c.Request.Context().Value(problemDetailsKey{}).(bool)
+	return enabled
+}
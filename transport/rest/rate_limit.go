@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/logistics-id/engine/ds/redis"
+)
+
+// RateLimitMiddleware limits requests to limit per window using the ds/redis
+// fixed-window counter, keyed by keyFn (client IP when nil). It sets the
+// X-RateLimit-* headers and answers 429 with Retry-After once exhausted.
+// Requests pass through when Redis is unavailable.
+//
+// Use it globally via s.Router.Use or per route through the middleware list.
+func RateLimitMiddleware(limit int, window time.Duration, keyFn func(*http.Request) string) func(http.Handler) http.Handler {
+	if keyFn == nil {
+		keyFn = getRealIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rl, err := redis.RateLimit(r.Context(), "rl:"+keyFn(r), limit, window)
+			if err != nil {
+				next.ServeHTTP(w, r) // fail-open
+				return
+			}
+
+			reset := strconv.Itoa(int(math.Ceil(rl.Reset.Seconds())))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+			w.Header().Set("X-RateLimit-Reset", reset)
+
+			if !rl.Allowed {
+				w.Header().Set("Retry-After", reset)
+
+				ctx := &Context{
+					Context:  r.Context(),
+					Request:  r,
+					Response: w,
+				}
+				_ = ctx.Error(http.StatusTooManyRequests, MsgTooManyRequests, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
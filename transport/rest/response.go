@@ -1,22 +1,25 @@
 package rest
 
+import "encoding/xml"
+
 // Response defines the standard structure for all HTTP responses
 type ResponseBody struct {
-	Success    bool   `json:"success"`
-	Message    string `json:"message,omitempty"`
-	Data       any    `json:"data,omitempty"`
-	Errors     any    `json:"errors,omitempty"`
-	Meta       *Meta  `json:"meta,omitempty"`
-	StatusCode int    `json:"-"` // HTTP status code (not serialized in JSON)
+	XMLName    xml.Name `json:"-" xml:"response" msgpack:"-"`
+	Success    bool     `json:"success" xml:"success"`
+	Message    string   `json:"message,omitempty" xml:"message,omitempty"`
+	Data       any      `json:"data,omitempty" xml:"data,omitempty"`
+	Errors     any      `json:"errors,omitempty" xml:"errors,omitempty"`
+	Meta       *Meta    `json:"meta,omitempty" xml:"meta,omitempty"`
+	StatusCode int      `json:"-" xml:"-"` // HTTP status code (not serialized in JSON)
 }
 
 type Meta struct {
-	Page       int64 `json:"page"`
-	PageSize   int64 `json:"page_size"`
-	Total      int64 `json:"total"`
-	TotalPages int64 `json:"total_pages"`
-	HasNext    bool  `json:"has_next"`
-	HasPrev    bool  `json:"has_prev"`
+	Page       int64 `json:"page" xml:"page"`
+	PageSize   int64 `json:"page_size" xml:"page_size"`
+	Total      int64 `json:"total" xml:"total"`
+	TotalPages int64 `json:"total_pages" xml:"total_pages"`
+	HasNext    bool  `json:"has_next" xml:"has_next"`
+	HasPrev    bool  `json:"has_prev" xml:"has_prev"`
 }
 
 func BuildMeta(page, pageSize, total int64) *Meta {
@@ -3,12 +3,13 @@ package rest
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"os"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,6 +19,31 @@ import (
 type Config struct {
 	Server string
 	IsDev  bool
+
+	// Zero values fall back to 15s read/write, 60s idle and 10s shutdown.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	// DrainDelay keeps serving after /readyz starts failing on Shutdown, before
+	// connections are closed, so load balancers see the 503 and stop routing to the
+	// server first. Set it above the readiness probe period; zero closes right away.
+	DrainDelay time.Duration
+
+	// TLSCertFile and TLSKeyFile enable HTTPS (and HTTP/2) when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// H2C enables HTTP/2 over cleartext, e.g. behind a TLS-terminating proxy.
+	H2C bool
+
+	// ProblemDetails emits errors as RFC 7807 application/problem+json instead of ResponseBody.
+	ProblemDetails bool
+
+	// BodyLog enables request/response body logging with redaction.
+	BodyLog *BodyLogConfig
 }
 
 type RestServer struct {
@@ -25,6 +51,16 @@ type RestServer struct {
 	Config *Config
 	Log    *zap.Logger
 	srv    *http.Server
+	once   sync.Once
+
+	probes   []probe
+	probeMu  sync.RWMutex
+	draining atomic.Bool
+
+	debugSrv *http.Server
+
+	routes  []RouteInfo
+	routeMu sync.RWMutex
 }
 
 type HandlerFunc func(*Context) error
@@ -38,18 +74,25 @@ func NewServer(cfg *Config, logger *zap.Logger, register func(*RestServer)) *Res
 
 	r := mux.NewRouter()
 
+	if cfg.ProblemDetails {
+		r.Use(ProblemDetailsMiddleware())
+	}
+
 	// Built-in middleware
 	r.Use(CORSMiddleware())
 	r.Use(RequestIDMiddleware())
 	r.Use(RecoveryMiddleware(logger))
-	r.Use(LoggingMiddleware(logger))
+	r.Use(LoggingMiddleware(logger, cfg.BodyLog))
 
 	// Collect middleware chain for special handlers
 	builtInMiddleware := []func(http.Handler) http.Handler{
 		CORSMiddleware(),
 		RequestIDMiddleware(),
 		RecoveryMiddleware(logger),
-		LoggingMiddleware(logger),
+		LoggingMiddleware(logger, cfg.BodyLog),
+	}
+	if cfg.ProblemDetails {
+		builtInMiddleware = append([]func(http.Handler) http.Handler{ProblemDetailsMiddleware()}, builtInMiddleware...)
 	}
 
 	// Standard 404 and 405 handling
@@ -70,7 +113,7 @@ func NewServer(cfg *Config, logger *zap.Logger, register func(*RestServer)) *Res
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set(
 				"Access-Control-Allow-Headers",
-				"Content-Type, Authorization, X-Requested-With, X-Terminal-ID, X-Gate-Lane-ID",
+				"Content-Type, Authorization, X-Requested-With, X-Terminal-ID, X-Gate-Lane-ID, X-CSRF-Token",
 			)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 			w.WriteHeader(http.StatusNoContent)
@@ -96,43 +139,96 @@ func NewServer(cfg *Config, logger *zap.Logger, register func(*RestServer)) *Res
 		Log:    logger,
 	}
 
+	srv.registerProbeRoutes()
+
 	// Register application routes
 	register(srv)
 
 	if cfg.IsDev {
-		debugRoutes(r)
+		srv.PrintRoutes(os.Stdout)
 	}
 
 	return srv
 }
 
-// Start launches the HTTP server and listens for shutdown via context
+// Start launches the HTTP server in the background and shuts it down as soon as ctx is cancelled.
 func (s *RestServer) Start(ctx context.Context) {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(s.Config.H2C)
+
 	s.srv = &http.Server{
-		Addr:         s.Config.Server,
-		Handler:      s.Router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              s.Config.Server,
+		Handler:           s.Router,
+		ReadTimeout:       durationOr(s.Config.ReadTimeout, 15*time.Second),
+		ReadHeaderTimeout: s.Config.ReadHeaderTimeout,
+		WriteTimeout:      durationOr(s.Config.WriteTimeout, 15*time.Second),
+		IdleTimeout:       durationOr(s.Config.IdleTimeout, 60*time.Second),
+		Protocols:         protocols,
 	}
 
+	tls := s.Config.TLSCertFile != "" && s.Config.TLSKeyFile != ""
+
 	// Start the server asynchronously
-	s.Log.Info("REST/SERVER STARTED", zap.String("addr", s.Config.Server))
+	s.Log.Info("REST/SERVER STARTED", zap.String("addr", s.Config.Server), zap.Bool("tls", tls), zap.Bool("h2c", s.Config.H2C))
 	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tls {
+			err = s.srv.ListenAndServeTLS(s.Config.TLSCertFile, s.Config.TLSKeyFile)
+		} else {
+			err = s.srv.ListenAndServe()
+		}
+
+		if err != nil && err != http.ErrServerClosed {
 			s.Log.Error("REST/SERVER", zap.Error(err))
 		}
 	}()
+
+	if s.debugSrv != nil {
+		go func() {
+			if err := s.debugSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.Log.Error("REST/DEBUG", zap.Error(err))
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.Shutdown(ctx)
+	}()
 }
 
-// Shutdown explicitly shuts down the server
+// Shutdown fails readiness, waits Config.DrainDelay, then gracefully drains the server
+// within Config.ShutdownTimeout. It is safe to call more than once and with an already
+// cancelled ctx.
 func (s *RestServer) Shutdown(ctx context.Context) {
-	s.Log.Debug("REST/SERVER Shutting Down")
-	if shutdownErr := s.srv.Shutdown(ctx); shutdownErr != nil {
-		s.Log.Error("REST/SERVER shutdown error", zap.Error(shutdownErr))
-	} else {
-		s.Log.Debug("REST/SERVER server shut down cleanly")
+	if s.srv == nil {
+		return
 	}
+
+	s.once.Do(func() {
+		// Fail readiness first so load balancers stop sending new requests
+		s.draining.Store(true)
+		if s.Config.DrainDelay > 0 {
+			s.Log.Debug("REST/SERVER Draining", zap.Duration("delay", s.Config.DrainDelay))
+			time.Sleep(s.Config.DrainDelay)
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), durationOr(s.Config.ShutdownTimeout, 10*time.Second))
+		defer cancel()
+
+		if s.debugSrv != nil {
+			_ = s.debugSrv.Shutdown(ctx)
+		}
+
+		s.Log.Debug("REST/SERVER Shutting Down")
+		if shutdownErr := s.srv.Shutdown(ctx); shutdownErr != nil {
+			s.Log.Error("REST/SERVER shutdown error", zap.Error(shutdownErr))
+		} else {
+			s.Log.Debug("REST/SERVER server shut down cleanly")
+		}
+	})
 }
 
 // Generic route handler with middleware support
@@ -146,12 +242,7 @@ func (s *RestServer) handle(method, path string, handler HandlerFunc, mws []func
 		}
 
 		if err := handler(ctx); err != nil {
-			var httpErr HTTPError
-			if errors.As(err, &httpErr) {
-				_ = ctx.Error(httpErr.Code, Message(httpErr.Message), nil)
-			} else {
-				_ = ctx.Error(http.StatusInternalServerError, MsgInternalError, err.Error())
-			}
+			_ = ctx.Respond(nil, err)
 		}
 	})
 
@@ -162,6 +253,8 @@ func (s *RestServer) handle(method, path string, handler HandlerFunc, mws []func
 	if len(name) > 0 {
 		route.Name(name[0])
 	}
+
+	s.recordRoute(method, path, route.GetName(), mws)
 }
 
 // Shorthand route registration
@@ -237,3 +330,10 @@ func handlerName(fn any) string {
 	parts := strings.Split(full, "/")
 	return parts[len(parts)-1]
 }
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
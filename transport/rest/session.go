@@ -0,0 +1,362 @@
+package rest
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/logistics-id/engine/ds/redis"
+	"go.uber.org/zap"
+)
+
+type sessionContextKey struct{}
+
+// SessionStore persists server-side session values; the cookie then only carries the
+// encrypted session ID.
+type SessionStore interface {
+	Load(ctx context.Context, id string) (map[string]any, error)
+	Save(ctx context.Context, id string, values map[string]any, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// SessionConfig configures the cookie session middleware.
+type SessionConfig struct {
+	// Secret encrypts and authenticates the cookie (AES-GCM keyed by its SHA-256). Required.
+	Secret string
+
+	CookieName string        // default "session"
+	Path       string        // cookie path, default "/"
+	Domain     string        // cookie domain
+	Secure     bool          // send the cookie over HTTPS only
+	SameSite   http.SameSite // default http.SameSiteLaxMode
+	MaxAge     time.Duration // session lifetime, default 24h
+
+	// Store keeps values server-side (e.g. RedisSessionStore). When nil the values
+	// themselves are encrypted into the cookie, which must then stay under ~4KB.
+	Store SessionStore
+
+	// Logger reports sessions that could not be persisted, whose changes are then
+	// lost; default no logging.
+	Logger *zap.Logger
+}
+
+func (cfg *SessionConfig) setDefault() *SessionConfig {
+	c := *cfg
+	if c.CookieName == "" {
+		c.CookieName = "session"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 24 * time.Hour
+	}
+	if c.Logger == nil {
+		c.Logger = zap.NewNop()
+	}
+	return &c
+}
+
+// Session holds the values of a browser session, see Context.Session.
+type Session struct {
+	ID string
+
+	mu        sync.RWMutex
+	values    map[string]any
+	changed   bool
+	destroyed bool
+	staleID   string // the ID replaced by Regenerate, deleted from the store on save
+}
+
+// Get returns the value stored under key, nil when absent.
+func (s *Session) Get(key string) any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.values[key]
+}
+
+// GetString returns the value stored under key as a string.
+func (s *Session) GetString(key string) string {
+	v, _ := s.Get(key).(string)
+	return v
+}
+
+// Set stores value under key; it must be JSON encodable.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+	s.changed = true
+}
+
+// Delete removes key from the session.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+	s.changed = true
+}
+
+// Regenerate moves the session values to a new ID and deletes the store entry of the
+// old one, so an ID planted or observed before does not carry over. Call it on login
+// and on any privilege change to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.staleID == "" {
+		s.staleID = s.ID
+	}
+	s.ID = ""
+	s.changed = true
+}
+
+// Destroy clears the session and expires its cookie, e.g. on logout.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = map[string]any{}
+	s.destroyed = true
+}
+
+// Session returns the request session loaded by SessionMiddleware. Without the
+// middleware it returns an empty session that is never persisted.
+func (c *Context) Session() *Session {
+	if c.Request != nil {
+		if s, ok := c.Request.Context().Value(sessionContextKey{}).(*Session); ok {
+			return s
+		}
+	}
+	return &Session{values: map[string]any{}}
+}
+
+// SessionMiddleware loads the session from an encrypted cookie before the handler
+// runs and writes it back, together with the store entry, once the response starts.
+func SessionMiddleware(cfg *SessionConfig) func(http.Handler) http.Handler {
+	cfg = cfg.setDefault()
+	if cfg.Secret == "" {
+		panic("rest: SessionConfig.Secret is required")
+	}
+
+	key := sha256.Sum256([]byte(cfg.Secret))
+	block, _ := aes.NewCipher(key[:])
+	aead, _ := cipher.NewGCM(block)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess := cfg.load(r, aead)
+
+			sw := &sessionWriter{ResponseWriter: w}
+			sw.commit = func() { cfg.save(r, w, aead, sess) }
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sess)))
+			sw.once.Do(sw.commit)
+		})
+	}
+}
+
+type sessionCookie struct {
+	ID      string         `json:"i,omitempty"`
+	Values  map[string]any `json:"v,omitempty"`
+	Expires int64          `json:"e"`
+}
+
+func (cfg *SessionConfig) load(r *http.Request, aead cipher.AEAD) *Session {
+	sess := &Session{values: map[string]any{}}
+
+	cookie, err := r.Cookie(cfg.CookieName)
+	if err != nil {
+		return sess
+	}
+
+	var sc sessionCookie
+	if err := openSession(aead, cookie.Value, &sc); err != nil || time.Now().Unix() > sc.Expires {
+		return sess
+	}
+
+	if cfg.Store == nil {
+		if sc.Values != nil {
+			sess.values = sc.Values
+		}
+		return sess
+	}
+
+	if values, err := cfg.Store.Load(r.Context(), sc.ID); err == nil && values != nil {
+		sess.ID, sess.values = sc.ID, values
+	}
+	return sess
+}
+
+func (cfg *SessionConfig) save(r *http.Request, w http.ResponseWriter, aead cipher.AEAD, sess *Session) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.destroyed {
+		cfg.delete(r, sess.ID)
+		cfg.delete(r, sess.staleID)
+		cfg.setCookie(w, "", -1)
+		return
+	}
+
+	if !sess.changed {
+		return
+	}
+
+	sc := sessionCookie{Expires: time.Now().Add(cfg.MaxAge).Unix()}
+	if cfg.Store == nil {
+		sc.Values = sess.values
+	} else {
+		cfg.delete(r, sess.staleID)
+		if sess.ID == "" {
+			sess.ID = randomToken()
+		}
+		if err := cfg.Store.Save(r.Context(), sess.ID, sess.values, cfg.MaxAge); err != nil {
+			cfg.logFailure(r, "REST/SESSION SAVE FAILED", err)
+			return
+		}
+		sc.ID = sess.ID
+	}
+
+	value, err := sealSession(aead, sc)
+	if err != nil {
+		cfg.logFailure(r, "REST/SESSION SAVE FAILED", err)
+		return
+	}
+	cfg.setCookie(w, value, int(cfg.MaxAge.Seconds()))
+}
+
+// delete removes the store entry of id, if any.
+func (cfg *SessionConfig) delete(r *http.Request, id string) {
+	if cfg.Store == nil || id == "" {
+		return
+	}
+	if err := cfg.Store.Delete(r.Context(), id); err != nil {
+		cfg.logFailure(r, "REST/SESSION DELETE FAILED", err)
+	}
+}
+
+func (cfg *SessionConfig) logFailure(r *http.Request, msg string, err error) {
+	cfg.Logger.Error(msg,
+		zap.String("path", r.URL.Path),
+		zap.String("request_id", common.GetContextRequestID(r.Context())),
+		zap.Error(err),
+	)
+}
+
+func (cfg *SessionConfig) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     cfg.Path,
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+		SameSite: cfg.SameSite,
+	})
+}
+
+// sealSession encrypts v as JSON into a URL-safe nonce|ciphertext string.
+func sealSession(aead cipher.AEAD, v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+// openSession reverses sealSession, failing on tampered or foreign cookies.
+func openSession(aead cipher.AEAD, value string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(raw) < aead.NonceSize() {
+		return errors.New("session: malformed cookie")
+	}
+
+	plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sessionWriter persists the session right before the response header is written,
+// since cookies cannot be set afterwards.
+type sessionWriter struct {
+	http.ResponseWriter
+	once   sync.Once
+	commit func()
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.once.Do(w.commit)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.once.Do(w.commit)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RedisSessionStore keeps session values in ds/redis under prefix:id.
+// Call redis.NewConnection before serving requests.
+func RedisSessionStore(prefix string) SessionStore {
+	if prefix == "" {
+		prefix = "session"
+	}
+	return redisSessionStore{prefix: prefix}
+}
+
+type redisSessionStore struct {
+	prefix string
+}
+
+func (s redisSessionStore) Load(ctx context.Context, id string) (map[string]any, error) {
+	var values map[string]any
+	if err := redis.Read(ctx, s.prefix+":"+id, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s redisSessionStore) Save(ctx context.Context, id string, values map[string]any, ttl time.Duration) error {
+	return redis.SaveTTL(ctx, s.prefix+":"+id, values, ttl)
+}
+
+func (s redisSessionStore) Delete(ctx context.Context, id string) error {
+	return redis.Delete(ctx, s.prefix+":"+id)
+}
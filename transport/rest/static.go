@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// StaticCacheControl is sent with files served by Static.
+	StaticCacheControl = "public, max-age=3600"
+
+	// assetCacheControl is sent with SPA assets, which bundlers fingerprint by content.
+	assetCacheControl = "public, max-age=31536000, immutable"
+)
+
+// Static serves files from dir under the URL prefix with cache headers, matching
+// paths below prefix only, so /assets does not serve /assetsfoo. Directory listings
+// are not exposed.
+func (s *RestServer) Static(prefix, dir string) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	fs := http.FileServer(noListFS{http.Dir(dir)})
+
+	s.Router.PathPrefix(strings.TrimSuffix(prefix, "/")+"/").Methods(http.MethodGet, http.MethodHead).Handler(
+		http.StripPrefix(strings.TrimSuffix(prefix, "/"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", StaticCacheControl)
+			fs.ServeHTTP(w, r)
+		})),
+	)
+}
+
+// SPA serves a single-page application from dir: existing files are served with
+// long-lived cache headers and any other path without a file extension falls back
+// to index.html (never cached) so client-side routing works on reload.
+// Register it after all API routes since it matches every remaining path.
+func (s *RestServer) SPA(dir string) {
+	index := filepath.Join(dir, "index.html")
+	fs := http.FileServer(noListFS{http.Dir(dir)})
+
+	s.Router.PathPrefix("/").Methods(http.MethodGet, http.MethodHead).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+
+		if name != "/" && name != "/index.html" {
+			if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && !info.IsDir() {
+				w.Header().Set("Cache-Control", assetCacheControl)
+				fs.ServeHTTP(w, r)
+				return
+			}
+
+			// Missing assets are real 404s, only routes fall back to index.html
+			if path.Ext(name) != "" {
+				ctx := &Context{Context: r.Context(), Request: r, Response: w}
+				_ = ctx.Error(http.StatusNotFound, MsgNotFound, nil)
+				return
+			}
+		}
+
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFile(w, r, index)
+	})
+}
+
+// noListFS hides directories without an index.html from http.FileServer.
+type noListFS struct {
+	fs http.FileSystem
+}
+
+func (n noListFS) Open(name string) (http.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		index, err := n.fs.Open(strings.TrimSuffix(name, "/") + "/index.html")
+		if err != nil {
+			f.Close()
+			return nil, os.ErrNotExist
+		}
+		index.Close()
+	}
+
+	return f, nil
+}
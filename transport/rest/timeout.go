@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// timeoutWriter buffers the handler output so nothing reaches the client once the deadline fired.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	body   bytes.Buffer
+	code   int
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// TimeoutMiddleware bounds the handler with context.WithTimeout and answers 504 with the
+// standard ResponseBody when it is exceeded. Handlers should pass ctx to upstream calls so
+// they are cancelled too. Apply it globally via s.Router.Use, or per route / route group.
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p) // let RecoveryMiddleware handle it
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				for k, v := range tw.header {
+					w.Header()[k] = v
+				}
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.body.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return // client went away
+				}
+
+				c := &Context{
+					Context:  r.Context(),
+					Request:  r,
+					Response: w,
+				}
+				_ = c.Error(http.StatusGatewayTimeout, MsgGatewayTimeout, nil)
+			}
+		})
+	}
+}
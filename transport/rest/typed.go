@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"net/http"
+)
+
+// Handle registers a typed handler: the request is bound and validated into Req
+// (query/path params for GET, JSON body and path params otherwise), and the returned
+// Resp or error is written with Context.Respond.
+//
+//	rest.Handle(s, http.MethodPost, "/orders", func(ctx *rest.Context, req CreateOrderRequest) (*Order, error) {
+//		return orderUsecase.Create(ctx, req)
+//	}, s.Restricted("order:create"))
+func Handle[Req, Resp any](s *RestServer, method, path string, fn func(*Context, Req) (Resp, error), mws []func(http.Handler) http.Handler) {
+	h := func(ctx *Context) error {
+		var req Req
+		if err := ctx.Bind(&req); err != nil {
+			return ctx.Respond(nil, err)
+		}
+
+		// Bind only validates request bodies
+		if ctx.Request.Method == http.MethodGet {
+			if err := ctx.Validate(&req); !err.Valid {
+				return ctx.Respond(nil, err)
+			}
+		}
+
+		resp, err := fn(ctx, req)
+		return ctx.Respond(resp, err)
+	}
+
+	s.handle(method, path, h, mws, handlerName(fn))
+}
@@ -2,7 +2,6 @@ package rest
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,7 +11,7 @@ import (
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
-	body       *bytes.Buffer
+	body       *cappedBuffer // nil unless body logging is enabled
 }
 
 func (rw *responseRecorder) WriteHeader(code int) {
@@ -21,7 +20,9 @@ func (rw *responseRecorder) WriteHeader(code int) {
 }
 
 func (rw *responseRecorder) Write(b []byte) (int, error) {
-	rw.body.Write(b) // capture for log
+	if rw.body != nil {
+		rw.body.Write(b) // capture for log
+	}
 	return rw.ResponseWriter.Write(b)
 }
 
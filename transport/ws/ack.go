@@ -15,19 +15,70 @@ type AckStore struct {
 	TTL    time.Duration
 	Prefix string // e.g., "ws:ack"
 	Logger *zap.Logger
+
+	// OnAck is called once a client acknowledges a message, on the pod of the connection.
+	OnAck func(userID, msgID string)
+	// OnExpire is called when an unacked message is dropped past its ExpiresAt.
+	OnExpire func(userID string, env Envelope)
+
+	metrics *Metrics
+}
+
+func (a *AckStore) key(userID, msgID string) string {
+	return a.Prefix + ":" + userID + ":" + msgID
+}
+
+// indexKey is the sorted set of the user's pending message IDs scored by the epoch
+// millis at which they expire.
+func (a *AckStore) indexKey(userID string) string {
+	return a.Prefix + "-index:" + userID
+}
+
+// usersKey is the set of users with an index, walked by Cleanup.
+func (a *AckStore) usersKey() string {
+	return a.Prefix + "-users"
 }
 
-// Save stores a message that needs to be acknowledged.
+// Save stores a message that needs to be acknowledged and indexes it until its
+// ExpiresAt or the store TTL, whichever comes first.
 func (a *AckStore) Save(userID, msgID string, msg []byte) {
 	conn := a.Pool.Get()
 	defer conn.Close()
-	key := a.Prefix + ":" + userID + ":" + msgID
-	_, err := conn.Do("SETEX", key, int(a.TTL.Seconds()), msg)
+
+	ttl := int(a.TTL.Seconds())
+	deadline := time.Now().Add(a.TTL).UnixMilli()
+
+	var env struct {
+		ExpiresAt int64 `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(msg, &env); err == nil && env.ExpiresAt > 0 && env.ExpiresAt < deadline {
+		deadline = env.ExpiresAt
+	}
+
+	index := a.indexKey(userID)
+	_ = conn.Send("MULTI")
+	_ = conn.Send("SETEX", a.key(userID, msgID), ttl, msg)
+	_ = conn.Send("ZADD", index, deadline, msgID)
+	_ = conn.Send("EXPIRE", index, ttl)
+	_ = conn.Send("SADD", a.usersKey(), userID)
+	_, err := conn.Do("EXEC")
 	if err != nil && a.Logger != nil {
 		a.Logger.Error("failed to save ack message", zap.String("userID", userID), zap.String("msgID", msgID), zap.Error(err))
 	}
 }
 
+// remove deletes a message and its index entry, reporting whether it was still stored.
+func (a *AckStore) remove(conn redis.Conn, userID, msgID string) (bool, error) {
+	_ = conn.Send("MULTI")
+	_ = conn.Send("DEL", a.key(userID, msgID))
+	_ = conn.Send("ZREM", a.indexKey(userID), msgID)
+	reply, err := redis.Ints(conn.Do("EXEC"))
+	if err != nil {
+		return false, err
+	}
+	return reply[0] > 0, nil
+}
+
 // AckHandler handles incoming ack messages.
 func (a *AckStore) AckHandler(ctx context.Context, conn *Conn, payload json.RawMessage) error {
 	var body struct {
@@ -36,16 +87,69 @@ func (a *AckStore) AckHandler(ctx context.Context, conn *Conn, payload json.RawM
 	if err := json.Unmarshal(payload, &body); err != nil {
 		return err
 	}
-	key := a.Prefix + ":" + conn.UserID + ":" + body.ID
 	c := a.Pool.Get()
 	defer c.Close()
-	_, err := c.Do("DEL", key)
+	removed, err := a.remove(c, conn.UserID, body.ID)
+	// Duplicate acks and acks of expired messages remove nothing and are not counted
+	if removed {
+		a.metrics.ack("acked")
+		if a.OnAck != nil {
+			a.OnAck(conn.UserID, body.ID)
+		}
+	}
 	if err != nil && a.Logger != nil {
 		a.Logger.Warn("failed to delete ack entry", zap.String("userID", conn.UserID), zap.String("msgID", body.ID), zap.Error(err))
 	}
 	return nil
 }
 
+// expire deletes an expired message and reports it to OnExpire once, whichever pod
+// notices first.
+func (a *AckStore) expire(conn redis.Conn, userID string, env Envelope) {
+	removed, err := a.remove(conn, userID, env.ID)
+	if err == nil && removed && a.OnExpire != nil {
+		a.OnExpire(userID, env)
+	}
+}
+
+// pending calls fn with every stored message of the user, reading the index and
+// loading the messages in MGET batches. Index entries whose message is gone (acked
+// elsewhere or past the TTL) are pruned.
+func (a *AckStore) pending(conn redis.Conn, userID string, fn func(key string, data []byte)) error {
+	index := a.indexKey(userID)
+	ids, err := redis.Strings(conn.Do("ZRANGE", index, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += scanBatch {
+		batch := ids[start:min(start+scanBatch, len(ids))]
+
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = a.key(userID, id)
+		}
+
+		values, err := redis.ByteSlices(conn.Do("MGET", args...))
+		if err != nil {
+			return err
+		}
+
+		stale := []any{index}
+		for i, data := range values {
+			if data == nil {
+				stale = append(stale, batch[i])
+				continue
+			}
+			fn(args[i].(string), data)
+		}
+		if len(stale) > 1 {
+			_, _ = conn.Do("ZREM", stale...)
+		}
+	}
+	return nil
+}
+
 func NewAckStore(pool *redis.Pool, logger *zap.Logger) *AckStore {
 	return &AckStore{
 		Pool:   pool,
@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// DefaultAckCleanupInterval is how often expired ack entries are swept when
+// Config.AckCleanupInterval is zero.
+const DefaultAckCleanupInterval = time.Minute
+
+// Cleanup removes the index entries of messages past their deadline, deleting those
+// still stored and reporting the ones past ExpiresAt to OnExpire. It returns the number
+// of entries removed. Every pod may run it; each message is reported once.
+func (a *AckStore) Cleanup() (int, error) {
+	conn := a.Pool.Get()
+	defer conn.Close()
+
+	now := time.Now().UnixMilli()
+	removed := 0
+
+	cursor := "0"
+	for {
+		reply, err := redis.Values(conn.Do("SSCAN", a.usersKey(), cursor, "COUNT", scanBatch))
+		if err != nil {
+			return removed, err
+		}
+		cursor, _ = redis.String(reply[0], nil)
+		users, _ := redis.Strings(reply[1], nil)
+
+		for _, userID := range users {
+			n, err := a.cleanupUser(conn, userID, now)
+			removed += n
+			if err != nil {
+				return removed, err
+			}
+		}
+
+		if cursor == "0" {
+			return removed, nil
+		}
+	}
+}
+
+func (a *AckStore) cleanupUser(conn redis.Conn, userID string, now int64) (int, error) {
+	index := a.indexKey(userID)
+	ids, err := redis.Strings(conn.Do("ZRANGEBYSCORE", index, "-inf", now))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		data, err := redis.Bytes(conn.Do("GET", a.key(userID, id)))
+		if err != nil {
+			// Already gone with its TTL
+			_, _ = conn.Do("ZREM", index, id)
+			continue
+		}
+
+		var env Envelope
+		if err := json.Unmarshal(data, &env); err == nil && env.ExpiresAt > 0 && env.ExpiresAt <= now {
+			a.expire(conn, userID, env)
+			continue
+		}
+		_, _ = a.remove(conn, userID, id)
+	}
+
+	if n, err := redis.Int(conn.Do("ZCARD", index)); err == nil && n == 0 {
+		_, _ = conn.Do("SREM", a.usersKey(), userID)
+	}
+	return len(ids), nil
+}
+
+// startAckCleanup sweeps the AckStore periodically until the pod shuts down.
+func (ws *WebSocket) startAckCleanup() {
+	if ws.AckStore == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(durationOr(ws.AckCleanupInterval, DefaultAckCleanupInterval))
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if ws.draining.Load() {
+				return
+			}
+
+			n, err := ws.AckStore.Cleanup()
+			if err != nil {
+				ws.Logger.Warn("ack cleanup failed", zap.Error(err))
+			} else if n > 0 {
+				ws.Logger.Debug("ack cleanup removed expired entries", zap.Int("removed", n))
+			}
+		}
+	}()
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+)
+
+// ReasonTokenExpired is the close reason of connections whose token expired without
+// an auth.refresh.
+const ReasonTokenExpired = "token_expired"
+
+// ErrInvalidToken is returned by the auth.refresh handler for a token that does not
+// decode or belongs to another user.
+var ErrInvalidToken = errors.New("ws: invalid token")
+
+type refreshPayload struct {
+	Token string `json:"token"`
+}
+
+type refreshResult struct {
+	ExpiresAt int64 `json:"expires_at,omitempty"` // epoch millis
+}
+
+// session holds the claims of a connection refreshed in-band.
+type session struct {
+	claims any
+}
+
+// authRefreshHandler validates a new JWT for the connection's user, swaps the claims
+// seen by later handlers and pushes the expiry disconnect back.
+func (ws *WebSocket) authRefreshHandler(ctx context.Context, c *Conn, raw json.RawMessage) error {
+	var req refreshPayload
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return err
+	}
+
+	claims, err := common.TokenDecode(req.Token)
+	if err != nil || claims == nil {
+		return ErrInvalidToken
+	}
+
+	base := sessionBase(claims)
+	if base == nil || base.UserID != c.UserID {
+		return fmt.Errorf("%w: user mismatch", ErrInvalidToken)
+	}
+
+	c.session.Store(&session{claims: claims})
+	expiresAt := ws.armExpiry(c, base)
+
+	ws.Logger.Info("session refreshed", append(connFields(c), zap.Time("expiresAt", expiresAt))...)
+
+	result := refreshResult{}
+	if !expiresAt.IsZero() {
+		result.ExpiresAt = expiresAt.UnixMilli()
+	}
+	payload, _ := json.Marshal(result)
+	return c.Reply(Envelope{Type: "auth.refresh", Payload: payload})
+}
+
+// armExpiry (re)schedules closing the connection when the session expires and returns
+// the expiry, zero for tokens without one.
+func (ws *WebSocket) armExpiry(c *Conn, base *common.SessionClaims) time.Time {
+	if base == nil || base.ExpiresAt == nil {
+		return time.Time{}
+	}
+	expiresAt := base.ExpiresAt.Time
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.expiry != nil {
+		c.expiry.Reset(time.Until(expiresAt))
+		return expiresAt
+	}
+	c.expiry = time.AfterFunc(time.Until(expiresAt), func() {
+		ws.Logger.Info("connection closed: token expired", connFields(c)...)
+		c.closeOnce.Do(func() {
+			closeConn(c.WS, websocket.ClosePolicyViolation, CloseReason{Reason: ReasonTokenExpired})
+		})
+	})
+	return expiresAt
+}
+
+// stopExpiry cancels the expiry disconnect of a closed connection.
+func (c *Conn) stopExpiry() {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.expiry != nil {
+		c.expiry.Stop()
+	}
+}
+
+// sessionContext returns ctx carrying the refreshed claims, if any.
+func (c *Conn) sessionContext(ctx context.Context) context.Context {
+	if s := c.session.Load(); s != nil {
+		return context.WithValue(ctx, common.ContextUserKey, s.claims)
+	}
+	return ctx
+}
+
+// sessionBase returns the SessionClaims of claims, including custom claims embedding it.
+func sessionBase(claims any) *common.SessionClaims {
+	switch v := claims.(type) {
+	case *common.SessionClaims:
+		return v
+	case interface{ GetBase() *common.SessionClaims }:
+		return v.GetBase()
+	}
+	return nil
+}
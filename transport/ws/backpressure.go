@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// BackpressurePolicy decides what happens when a connection's send buffer is full.
+type BackpressurePolicy int
+
+const (
+	// DropNewest discards the message being sent (default).
+	DropNewest BackpressurePolicy = iota
+	// DropOldest discards the oldest buffered message to make room.
+	DropOldest
+	// CloseConnection closes the slow connection so the client reconnects and restores.
+	CloseConnection
+	// BlockWithTimeout waits up to Backpressure.Timeout for room, then drops the message.
+	BlockWithTimeout
+)
+
+// DefaultSendBuffer is the per-connection send buffer size when Config.SendBuffer is zero.
+const DefaultSendBuffer = 64
+
+// CloseSlowConsumer is the close code sent by the CloseConnection policy (1013 Try Again Later).
+const CloseSlowConsumer = websocket.CloseTryAgainLater
+
+// ErrSendBufferFull is returned when a message was dropped by the backpressure policy.
+var ErrSendBufferFull = errors.New("ws: send buffer full")
+
+// Backpressure configures how a connection handles a full send buffer.
+type Backpressure struct {
+	Policy  BackpressurePolicy
+	Timeout time.Duration // BlockWithTimeout wait, default 1s
+}
+
+// backpressureFor returns the policy of a new connection: the Config.BackpressureFunc
+// override when set, otherwise the server default.
+func (ws *WebSocket) backpressureFor(r *http.Request) Backpressure {
+	if ws.BackpressureFunc != nil {
+		return ws.BackpressureFunc(r)
+	}
+	return ws.Backpressure
+}
+
+// enqueue buffers msg for the write loop, applying the connection's backpressure policy.
+func (c *Conn) enqueue(msg []byte) error {
+	select {
+	case c.Send <- msg:
+		return nil
+	default:
+	}
+
+	switch c.Backpressure.Policy {
+	case DropOldest:
+		select {
+		case <-c.Send:
+			c.dropped.Add(1)
+		default:
+		}
+		select {
+		case c.Send <- msg:
+			return nil
+		default:
+		}
+
+	case CloseConnection:
+		c.closeSlow()
+
+	case BlockWithTimeout:
+		timeout := c.Backpressure.Timeout
+		if timeout <= 0 {
+			timeout = time.Second
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case c.Send <- msg:
+			return nil
+		case <-c.Close:
+		case <-timer.C:
+		}
+	}
+
+	c.dropped.Add(1)
+	return ErrSendBufferFull
+}
+
+// closeSlow sends a close frame and closes the socket once; the read loop then
+// cleans the connection up.
+func (c *Conn) closeSlow() {
+	c.closeOnce.Do(func() {
+		if c.WS == nil {
+			return
+		}
+
+		_ = c.WS.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(CloseSlowConsumer, "slow consumer"),
+			time.Now().Add(time.Second))
+		_ = c.WS.Close()
+	})
+}
+
+// Dropped returns the number of messages dropped for this connection.
+func (c *Conn) Dropped() uint64 {
+	return c.dropped.Load()
+}
@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/logistics-id/engine/broker"
+	"go.uber.org/zap"
+)
+
+// BrokerSender delivers messages across pods through any broker.Broker,
+// so the broker can be chosen by config instead of compile-time imports.
+type BrokerSender struct {
+	PodID    string
+	Broker   broker.Broker
+	Hub      *Hub
+	Registry Registry
+	Logger   *zap.Logger
+}
+
+func (s *BrokerSender) getKey(pod string) string {
+	return fmt.Sprintf("ws.send.%s", pod)
+}
+
+func (s *BrokerSender) SendToUser(ctx context.Context, userID string, msg []byte) error {
+	pods, err := s.Registry.GetUserPods(ctx, userID)
+
+	logger := s.Logger.With(zap.String("user_id", userID))
+
+	if err != nil {
+		logger.Error("failed to get user pods", zap.Error(err))
+		return err
+	}
+
+	for _, pod := range pods {
+		log := logger.With(zap.String("pod", pod))
+
+		if pod == s.PodID {
+			log.Debug("sent to local user")
+			s.Hub.sendLocal(userID, msg)
+			continue
+		}
+
+		if err := s.Broker.Publish(ctx, s.getKey(pod), msg); err != nil {
+			log.Error("failed to publish to remote pod", zap.Error(err))
+			return err
+		}
+
+		log.Debug("published to remote pod")
+	}
+
+	return nil
+}
+
+// SendToChannel publishes a channel message to every pod holding members of it.
+func (s *BrokerSender) SendToChannel(ctx context.Context, channel string, msg []byte) error {
+	return sendToChannel(ctx, channel, msg, s.PodID, s.Hub, s.Registry, func(ctx context.Context, pod string, msg []byte) error {
+		return s.Broker.Publish(ctx, s.getKey(pod), msg)
+	}, s.Logger)
+}
+
+func NewBrokerSender(podID string, b broker.Broker, hub *Hub, registry Registry, logger *zap.Logger) *BrokerSender {
+	s := &BrokerSender{
+		PodID:    podID,
+		Broker:   b,
+		Hub:      hub,
+		Registry: registry,
+		Logger:   logger.With(zap.String("pod_id", podID)),
+	}
+
+	key := s.getKey(podID)
+	err := b.Subscribe(key, func(ctx context.Context, m *broker.Message) error {
+		var data []byte
+		if err := m.Bind(&data); err != nil {
+			s.Logger.Error("failed to unmarshal message", zap.Error(err))
+			return err
+		}
+
+		var env Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			s.Logger.Error("failed to unmarshal message", zap.Error(err))
+			return err
+		}
+
+		return hub.deliver(env, data)
+	})
+	if err != nil {
+		s.Logger.Error("Failed to subscribe to broker topic", zap.String("topic", key), zap.Error(err))
+		return nil
+	}
+
+	return s
+}
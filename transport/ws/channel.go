@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+)
+
+// ChannelRegistry is implemented by registries that track which pods have members
+// of a channel, so broadcasts only reach those pods.
+type ChannelRegistry interface {
+	JoinChannel(ctx context.Context, channel, podID string) error
+	LeaveChannel(ctx context.Context, channel, podID string) error
+	GetChannelPods(ctx context.Context, channel string) ([]string, error)
+}
+
+// ChannelSender is implemented by senders able to fan a channel message out across pods.
+type ChannelSender interface {
+	SendToChannel(ctx context.Context, channel string, msg []byte) error
+}
+
+// JoinChannel subscribes the connection to a channel (e.g. "hub:JKT01") until it
+// leaves or disconnects.
+func (ws *WebSocket) JoinChannel(conn *Conn, channel string) error {
+	if !ws.Hub.Join(channel, conn) {
+		return nil
+	}
+
+	if cr, ok := ws.Registry.(ChannelRegistry); ok {
+		if err := cr.JoinChannel(context.Background(), channel, ws.PodID); err != nil {
+			ws.Logger.Error("failed to register channel", zap.String("channel", channel), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// LeaveChannel unsubscribes the connection from a channel.
+func (ws *WebSocket) LeaveChannel(conn *Conn, channel string) error {
+	if !ws.Hub.Leave(channel, conn) {
+		return nil
+	}
+
+	return ws.unregisterChannel(context.Background(), channel)
+}
+
+// BroadcastChannel delivers the envelope to every member of the channel across the
+// cluster. Without a ChannelSender only local members receive it.
+func (ws *WebSocket) BroadcastChannel(ctx context.Context, channel string, payload Envelope) error {
+	payload.Channel = channel
+
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		ws.Logger.Error("failed to marshal message", zap.Error(err))
+		return err
+	}
+	if ws.History != nil {
+		ws.History.AppendChannel(channel, msg)
+	}
+
+	if cs, ok := ws.Sender.(ChannelSender); ok {
+		return cs.SendToChannel(ctx, channel, msg)
+	}
+	return ws.Hub.SendChannel(channel, msg)
+}
+
+// leaveChannels drops every channel membership of a closing connection.
+func (ws *WebSocket) leaveChannels(ctx context.Context, conn *Conn) {
+	for _, channel := range ws.Hub.LeaveAll(conn) {
+		_ = ws.unregisterChannel(ctx, channel)
+	}
+}
+
+func (ws *WebSocket) unregisterChannel(ctx context.Context, channel string) error {
+	cr, ok := ws.Registry.(ChannelRegistry)
+	if !ok {
+		return nil
+	}
+
+	if err := cr.LeaveChannel(ctx, channel, ws.PodID); err != nil {
+		ws.Logger.Warn("failed to unregister channel", zap.String("channel", channel), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// sendToChannel delivers msg to the pods holding members of channel, locally through
+// the hub and remotely through publish.
+func sendToChannel(ctx context.Context, channel string, msg []byte, podID string, hub *Hub, registry Registry,
+	publish func(ctx context.Context, pod string, msg []byte) error, logger *zap.Logger) error {
+	cr, ok := registry.(ChannelRegistry)
+	if !ok {
+		return hub.SendChannel(channel, msg)
+	}
+
+	pods, err := cr.GetChannelPods(ctx, channel)
+	if err != nil {
+		logger.Error("failed to get channel pods", zap.String("channel", channel), zap.Error(err))
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod == podID {
+			_ = hub.SendChannel(channel, msg)
+			continue
+		}
+
+		if err := publish(ctx, pod, msg); err != nil {
+			logger.Error("failed to publish to remote pod", zap.String("channel", channel), zap.String("pod", pod), zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,431 @@
+// Package client dials the ws server from Go services and E2E tests. It authenticates
+// with a JWT, reconnects with jittered backoff, acknowledges RequiresAck messages,
+// resumes from the last seen sequence after a reconnect and dispatches messages by type
+// like the server router.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/logistics-id/engine/transport/ws"
+	"go.uber.org/zap"
+)
+
+// ErrClosed is returned by Send once the client is closed.
+var ErrClosed = errors.New("ws/client: closed")
+
+// HandlerFunc handles a message type received from the server.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Config configures a Client.
+type Config struct {
+	URL string // e.g. "wss://api.logistics.id/ws"
+
+	// Token is sent as "Authorization: Bearer"; TokenFunc, when set, is called before
+	// every dial instead so reconnects use a fresh token.
+	Token     string
+	TokenFunc func(ctx context.Context) (string, error)
+
+	DeviceID string      // sent as ws.DeviceIDHeader
+	Header   http.Header // extra upgrade headers
+
+	// MinBackoff and MaxBackoff bound the jittered reconnect delay, default 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RedeliveryWindow is how long a message whose handler failed is awaited for
+	// redelivery, default 10m like the server ack TTL. Past it, a late redelivery
+	// below the last sequence is taken as a duplicate.
+	RedeliveryWindow time.Duration
+
+	Dialer *websocket.Dialer // default websocket.DefaultDialer
+	Logger *zap.Logger
+}
+
+// Client is a reconnecting WebSocket connection to the server.
+type Client struct {
+	cfg    Config
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	conn     *websocket.Conn
+
+	writeMu sync.Mutex
+	seqMu   sync.Mutex
+	lastSeq atomic.Int64
+	pending map[int64]time.Time // sequences whose handler failed, by failure time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// New creates a client; call Connect to dial it.
+func New(cfg Config) *Client {
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.RedeliveryWindow <= 0 {
+		cfg.RedeliveryWindow = 10 * time.Minute
+	}
+	if cfg.Dialer == nil {
+		cfg.Dialer = websocket.DefaultDialer
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+
+	return &Client{
+		cfg:      cfg,
+		logger:   cfg.Logger.With(zap.String("component", "ws-client")),
+		handlers: map[string]HandlerFunc{},
+		pending:  map[int64]time.Time{},
+		done:     make(chan struct{}),
+	}
+}
+
+// Dial creates a client and connects it, see Connect.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	c := New(cfg)
+	if err := c.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// On registers the handler of a message type. Register handlers before Connect.
+func (c *Client) On(msgType string, handler HandlerFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[msgType] = handler
+}
+
+// OnTyped registers a handler receiving the payload decoded into T, mirroring ws.OnTyped.
+func OnTyped[T any](c *Client, msgType string, handler func(ctx context.Context, payload T) error) {
+	c.On(msgType, func(ctx context.Context, raw json.RawMessage) error {
+		payload, err := ws.Bind[T](raw)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// Connect dials the server and keeps the connection alive in the background until
+// Close, ctx cancellation or a close the server does not want retried; ctx bounds the
+// lifetime of the client and is passed to handlers. Only the first dial error is
+// returned.
+func (c *Client) Connect(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	c.setConn(conn)
+	go c.run(conn)
+	return nil
+}
+
+// Send sends an envelope of msgType with payload marshalled as JSON.
+func (c *Client) Send(ctx context.Context, msgType string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.SendEnvelope(ctx, ws.Envelope{Type: msgType, Payload: raw})
+}
+
+// SendEnvelope sends env as is, e.g. with an ID to correlate error replies.
+func (c *Client) SendEnvelope(ctx context.Context, env ws.Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn == nil {
+		return ErrClosed
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	} else {
+		_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// LastSeq returns the highest sequence number handled.
+func (c *Client) LastSeq() int64 {
+	return c.lastSeq.Load()
+}
+
+// Done is closed when the client stopped for good; Err then tells why.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the client stopped, nil after Close or ctx cancellation.
+func (c *Client) Err() error {
+	<-c.done
+	return c.err
+}
+
+// Close sends a normal close frame and stops reconnecting.
+func (c *Client) Close() error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn != nil {
+		c.writeMu.Lock()
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		c.writeMu.Unlock()
+		_ = conn.Close()
+	}
+
+	<-c.done
+	return nil
+}
+
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	header := http.Header{}
+	for k, v := range c.cfg.Header {
+		header[k] = v
+	}
+
+	token := c.cfg.Token
+	if c.cfg.TokenFunc != nil {
+		var err error
+		if token, err = c.cfg.TokenFunc(ctx); err != nil {
+			return nil, fmt.Errorf("ws/client: token: %w", err)
+		}
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if c.cfg.DeviceID != "" {
+		header.Set(ws.DeviceIDHeader, c.cfg.DeviceID)
+	}
+
+	dialer := *c.cfg.Dialer
+	dialer.Subprotocols = []string{ws.JSONCodec.Name()}
+
+	conn, resp, err := dialer.DialContext(ctx, c.cfg.URL, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("ws/client: dial %s: %w (status %d)", c.cfg.URL, err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("ws/client: dial %s: %w", c.cfg.URL, err)
+	}
+	return conn, nil
+}
+
+// run reads the connection and reconnects until the client stops.
+func (c *Client) run(conn *websocket.Conn) {
+	defer close(c.done)
+
+	for {
+		err := c.readLoop(conn)
+		c.setConn(nil)
+
+		if c.ctx.Err() != nil {
+			return
+		}
+		if !c.retryable(err) {
+			c.logger.Warn("connection closed by server, not reconnecting", zap.Error(err))
+			c.err = err
+			return
+		}
+
+		c.logger.Info("connection lost, reconnecting", zap.Error(err))
+		if conn = c.reconnect(); conn == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials with jittered exponential backoff and resumes from the last
+// sequence; nil means the client stopped meanwhile.
+func (c *Client) reconnect() *websocket.Conn {
+	delay := c.cfg.MinBackoff
+	for {
+		wait := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+
+		conn, err := c.dial(c.ctx)
+		if err == nil {
+			c.setConn(conn)
+			if seq := c.lastSeq.Load(); seq > 0 {
+				if err := c.Send(c.ctx, "resume", map[string]int64{"last_seq": seq}); err != nil {
+					c.logger.Warn("resume failed", zap.Error(err))
+				}
+			}
+			c.logger.Info("reconnected", zap.Int64("lastSeq", c.lastSeq.Load()))
+			return conn
+		}
+
+		c.logger.Warn("reconnect failed", zap.Duration("retryIn", delay), zap.Error(err))
+		delay = min(delay*2, c.cfg.MaxBackoff)
+	}
+}
+
+func (c *Client) readLoop(conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var env ws.Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			c.logger.Warn("invalid message", zap.Error(err))
+			continue
+		}
+		c.handle(env)
+	}
+}
+
+// handle dispatches a message, skipping sequences already handled, and acknowledges
+// it when required and handled without error. The sequence is only recorded once
+// handled, so a message whose handler failed is handled again when redelivered.
+func (c *Client) handle(env ws.Envelope) {
+	if env.Seq > 0 && c.seen(env.Seq) {
+		c.ack(env)
+		return
+	}
+
+	c.mu.RLock()
+	handler, ok := c.handlers[env.Type]
+	c.mu.RUnlock()
+
+	if !ok {
+		c.logger.Debug("no handler for message", zap.String("type", env.Type))
+		c.handled(env.Seq)
+		c.ack(env)
+		return
+	}
+
+	if err := handler(c.ctx, env.Payload); err != nil {
+		c.logger.Warn("handler failed", zap.String("type", env.Type), zap.Error(err))
+		c.failed(env.Seq)
+		return
+	}
+	c.handled(env.Seq)
+	c.ack(env)
+}
+
+// seen reports whether seq was already handled: it is not above the last sequence
+// and its handler did not fail within the redelivery window.
+func (c *Client) seen(seq int64) bool {
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+
+	at, pending := c.pending[seq]
+	pending = pending && time.Since(at) <= c.cfg.RedeliveryWindow
+	return seq <= c.lastSeq.Load() && !pending
+}
+
+// handled records seq as handled, advancing the last sequence.
+func (c *Client) handled(seq int64) {
+	if seq <= 0 {
+		return
+	}
+
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+
+	delete(c.pending, seq)
+	if seq > c.lastSeq.Load() {
+		c.lastSeq.Store(seq)
+	}
+}
+
+// failed records seq as pending redelivery, so it is handled again even once later
+// sequences advanced the last one past it. Sequences pending for longer than the
+// redelivery window are dropped, so the ones never redelivered do not pile up.
+func (c *Client) failed(seq int64) {
+	if seq <= 0 {
+		return
+	}
+
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+
+	now := time.Now()
+	for pending, at := range c.pending {
+		if now.Sub(at) > c.cfg.RedeliveryWindow {
+			delete(c.pending, pending)
+		}
+	}
+	c.pending[seq] = now
+}
+
+func (c *Client) ack(env ws.Envelope) {
+	if !env.RequiresAck || env.ID == "" {
+		return
+	}
+	if err := c.Send(c.ctx, "ack", map[string]string{"id": env.ID}); err != nil {
+		c.logger.Warn("ack failed", zap.String("id", env.ID), zap.Error(err))
+	}
+}
+
+func (c *Client) setConn(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+}
+
+// retryable reports whether a read error warrants reconnecting: policy closes (forced
+// disconnect, user limit) are final unless the server asks to reconnect, and an expired
+// token is only retried when TokenFunc can supply a new one.
+func (c *Client) retryable(err error) bool {
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) {
+		return true
+	}
+
+	var reason ws.CloseReason
+	_ = json.Unmarshal([]byte(ce.Text), &reason)
+
+	switch {
+	case reason.Reconnect:
+		return true
+	case reason.Reason == ws.ReasonTokenExpired:
+		return c.cfg.TokenFunc != nil
+	case ce.Code == websocket.CloseNormalClosure, ce.Code == websocket.ClosePolicyViolation:
+		return false
+	}
+	return true
+}
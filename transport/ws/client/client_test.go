@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/logistics-id/engine/transport/ws"
+	"github.com/logistics-id/engine/transport/ws/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve starts a server sending envs to the client one at a time, each after the
+// previous one was acknowledged when ackAfter says so, and returns the ids acked.
+func serve(t *testing.T, envs []ws.Envelope, ackAfter func(i int) bool) (string, <-chan string) {
+	t.Helper()
+
+	acks := make(chan string, len(envs))
+	upgrader := websocket.Upgrader{Subprotocols: []string{ws.JSONCodec.Name()}}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for i, env := range envs {
+			if err := conn.WriteJSON(env); err != nil {
+				return
+			}
+			if !ackAfter(i) {
+				continue
+			}
+
+			var ack ws.Envelope
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+			var body struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal(ack.Payload, &body)
+			acks <- body.ID
+		}
+
+		// keep the connection open until the client closes it
+		_, _, _ = conn.ReadMessage()
+	}))
+	t.Cleanup(srv.Close)
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), acks
+}
+
+func receive(t *testing.T, acks <-chan string) string {
+	t.Helper()
+
+	select {
+	case id := <-acks:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("no ack received")
+		return ""
+	}
+}
+
+func TestClient_RedeliveryAfterFailedHandler(t *testing.T) {
+	t.Parallel()
+
+	env := ws.Envelope{Type: "shipment.updated", ID: "m1", Seq: 1, RequiresAck: true, Payload: json.RawMessage(`{}`)}
+
+	// the first delivery is not acked, the server redelivers it
+	url, acks := serve(t, []ws.Envelope{env, env}, func(i int) bool { return i == 1 })
+
+	var calls atomic.Int32
+	c := client.New(client.Config{URL: url})
+	c.On("shipment.updated", func(ctx context.Context, payload json.RawMessage) error {
+		if calls.Add(1) == 1 {
+			return errors.New("index unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, c.Connect(context.Background()))
+	defer c.Close()
+
+	assert.Equal(t, "m1", receive(t, acks))
+	assert.Equal(t, int32(2), calls.Load(), "the redelivered message is handled again")
+	assert.Equal(t, int64(1), c.LastSeq())
+}
+
+func TestClient_DuplicateSuppressed(t *testing.T) {
+	t.Parallel()
+
+	env := ws.Envelope{Type: "shipment.updated", ID: "m1", Seq: 1, RequiresAck: true, Payload: json.RawMessage(`{}`)}
+
+	url, acks := serve(t, []ws.Envelope{env, env}, func(int) bool { return true })
+
+	var calls atomic.Int32
+	c := client.New(client.Config{URL: url})
+	c.On("shipment.updated", func(ctx context.Context, payload json.RawMessage) error {
+		calls.Add(1)
+		return nil
+	})
+	require.NoError(t, c.Connect(context.Background()))
+	defer c.Close()
+
+	assert.Equal(t, "m1", receive(t, acks))
+	assert.Equal(t, "m1", receive(t, acks), "the duplicate is acked again")
+	assert.Equal(t, int32(1), calls.Load(), "the duplicate is not handled")
+}
+
+func TestClient_LateRedeliverySuppressed(t *testing.T) {
+	t.Parallel()
+
+	m1 := ws.Envelope{Type: "shipment.updated", ID: "m1", Seq: 1, RequiresAck: true, Payload: json.RawMessage(`{}`)}
+	m2 := ws.Envelope{Type: "shipment.updated", ID: "m2", Seq: 2, RequiresAck: true, Payload: json.RawMessage(`{}`)}
+
+	// m1 fails and is only redelivered after m2, past the redelivery window
+	url, acks := serve(t, []ws.Envelope{m1, m2, m1}, func(i int) bool { return i > 0 })
+
+	var calls atomic.Int32
+	c := client.New(client.Config{URL: url, RedeliveryWindow: time.Nanosecond})
+	c.On("shipment.updated", func(ctx context.Context, payload json.RawMessage) error {
+		if calls.Add(1) == 1 {
+			return errors.New("index unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, c.Connect(context.Background()))
+	defer c.Close()
+
+	assert.Equal(t, "m2", receive(t, acks))
+	assert.Equal(t, "m1", receive(t, acks), "the late redelivery is acked")
+	assert.Equal(t, int32(2), calls.Load(), "the late redelivery is not handled")
+}
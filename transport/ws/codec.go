@@ -0,0 +1,267 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Codec encodes envelopes on the wire of a connection. It is negotiated through the
+// WebSocket subprotocol matching Name; connections without a match use JSON.
+//
+// Messages travel between pods as JSON, so a codec only converts at the socket edge.
+type Codec interface {
+	Name() string
+	FrameType() int // websocket.TextMessage or websocket.BinaryMessage
+	Encode(env Envelope) ([]byte, error)
+	Decode(data []byte) (Envelope, error)
+}
+
+var (
+	// JSONCodec is the default text codec ("json" subprotocol).
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec encodes envelopes and payloads as MessagePack ("msgpack" subprotocol).
+	MsgpackCodec Codec = msgpackCodec{}
+	// ProtobufCodec encodes envelopes as protobuf ("protobuf" subprotocol), see protobufCodec.
+	ProtobufCodec Codec = protobufCodec{}
+
+	// DefaultCodecs are offered when Config.Codecs is empty, in server preference order.
+	DefaultCodecs = []Codec{MsgpackCodec, ProtobufCodec, JSONCodec}
+)
+
+// subprotocols lists the codec names offered during the upgrade.
+func subprotocols(codecs []Codec) []string {
+	names := make([]string, len(codecs))
+	for i, c := range codecs {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// codecFor returns the codec of the negotiated subprotocol, JSON otherwise.
+func codecFor(codecs []Codec, subprotocol string) Codec {
+	for _, c := range codecs {
+		if c.Name() == subprotocol {
+			return c
+		}
+	}
+	return JSONCodec
+}
+
+// encodeFrame converts a JSON message from the send buffer to the connection codec.
+// Messages that are not envelopes are sent as JSON text.
+func encodeFrame(codec Codec, msg []byte) (int, []byte) {
+	if codec == nil || codec == JSONCodec {
+		return websocket.TextMessage, msg
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(msg, &env); err != nil || env.Type == "" {
+		return websocket.TextMessage, msg
+	}
+
+	data, err := codec.Encode(env)
+	if err != nil {
+		return websocket.TextMessage, msg
+	}
+	return codec.FrameType(), data
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string   { return "json" }
+func (jsonCodec) FrameType() int { return websocket.TextMessage }
+
+func (jsonCodec) Encode(env Envelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func (jsonCodec) Decode(data []byte) (Envelope, error) {
+	var env Envelope
+	err := json.Unmarshal(data, &env)
+	return env, err
+}
+
+// msgpackEnvelope mirrors Envelope with the payload as a native MessagePack value.
+type msgpackEnvelope struct {
+	UserID      string `msgpack:"user_id,omitempty"`
+	Channel     string `msgpack:"channel,omitempty"`
+	DeviceID    string `msgpack:"device_id,omitempty"`
+	Type        string `msgpack:"type"`
+	Payload     any    `msgpack:"payload"`
+	ID          string `msgpack:"id,omitempty"`
+	RequiresAck bool   `msgpack:"requiresAck,omitempty"`
+	ExpiresAt   int64  `msgpack:"expiresAt,omitempty"`
+	Seq         int64  `msgpack:"seq,omitempty"`
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string   { return "msgpack" }
+func (msgpackCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (msgpackCodec) Encode(env Envelope) ([]byte, error) {
+	m := msgpackEnvelope{
+		UserID:      env.UserID,
+		Channel:     env.Channel,
+		DeviceID:    env.DeviceID,
+		Type:        env.Type,
+		ID:          env.ID,
+		RequiresAck: env.RequiresAck,
+		ExpiresAt:   env.ExpiresAt,
+		Seq:         env.Seq,
+	}
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, &m.Payload); err != nil {
+			return nil, err
+		}
+	}
+	return msgpack.Marshal(m)
+}
+
+func (msgpackCodec) Decode(data []byte) (Envelope, error) {
+	var m msgpackEnvelope
+	if err := msgpack.Unmarshal(data, &m); err != nil {
+		return Envelope{}, err
+	}
+
+	payload, err := json.Marshal(m.Payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		UserID:      m.UserID,
+		Channel:     m.Channel,
+		DeviceID:    m.DeviceID,
+		Type:        m.Type,
+		Payload:     payload,
+		ID:          m.ID,
+		RequiresAck: m.RequiresAck,
+		ExpiresAt:   m.ExpiresAt,
+		Seq:         m.Seq,
+	}, nil
+}
+
+// protobufCodec encodes the envelope with the following schema; the payload is the
+// JSON document as bytes, so clients only need this message definition:
+//
+//	message Envelope {
+//	  string user_id      = 1;
+//	  string type         = 2;
+//	  bytes  payload      = 3;
+//	  string id           = 4;
+//	  bool   requires_ack = 5;
+//	  int64  expires_at   = 6;
+//	  string channel      = 7;
+//	  int64  seq          = 8;
+//	  string device_id    = 9;
+//	}
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string   { return "protobuf" }
+func (protobufCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (protobufCodec) Encode(env Envelope) ([]byte, error) {
+	var b []byte
+	appendString := func(num protowire.Number, v string) {
+		if v != "" {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		}
+	}
+
+	appendString(1, env.UserID)
+	appendString(2, env.Type)
+	if len(env.Payload) > 0 {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, env.Payload)
+	}
+	appendString(4, env.ID)
+	if env.RequiresAck {
+		b = protowire.AppendTag(b, 5, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	if env.ExpiresAt != 0 {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(env.ExpiresAt))
+	}
+	appendString(7, env.Channel)
+	if env.Seq != 0 {
+		b = protowire.AppendTag(b, 8, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(env.Seq))
+	}
+	appendString(9, env.DeviceID)
+
+	return b, nil
+}
+
+func (protobufCodec) Decode(data []byte) (Envelope, error) {
+	var env Envelope
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return env, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return env, protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case 1:
+				env.UserID = string(v)
+			case 2:
+				env.Type = string(v)
+			case 3:
+				env.Payload = append(json.RawMessage(nil), v...)
+			case 4:
+				env.ID = string(v)
+			case 7:
+				env.Channel = string(v)
+			case 9:
+				env.DeviceID = string(v)
+			}
+
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return env, protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case 5:
+				env.RequiresAck = v != 0
+			case 6:
+				env.ExpiresAt = int64(v)
+			case 8:
+				env.Seq = int64(v)
+			}
+
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return env, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+
+	if env.Type == "" {
+		return env, errors.New("ws: protobuf envelope without type")
+	}
+	if env.Payload != nil && !json.Valid(env.Payload) {
+		return env, fmt.Errorf("ws: protobuf envelope %s: payload is not JSON", env.Type)
+	}
+	return env, nil
+}
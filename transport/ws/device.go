@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/logistics-id/engine/common"
+	"go.uber.org/zap"
+)
+
+// DeviceIDHeader carries the client device ID on the upgrade request; the
+// device_id query parameter is accepted for browsers that cannot set headers.
+const DeviceIDHeader = "X-Device-ID"
+
+// deviceID returns the device ID of the upgrade request.
+func (ws *WebSocket) deviceID(r *http.Request) string {
+	if ws.DeviceIDFunc != nil {
+		return ws.DeviceIDFunc(r)
+	}
+	if id := r.Header.Get(DeviceIDHeader); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("device_id")
+}
+
+// sessionID returns the JWT ID of the session, or a random ID when the token has none.
+func sessionID(uc *common.SessionClaims) string {
+	if uc != nil && uc.ID != "" {
+		return uc.ID
+	}
+	return uuid.NewString()
+}
+
+// SendToDevice delivers the envelope only to the connections of the user opened from
+// deviceID, on whichever pod they are.
+func (ws *WebSocket) SendToDevice(ctx context.Context, userID, deviceID string, payload Envelope) error {
+	payload.DeviceID = deviceID
+	return ws.SendToUser(ctx, userID, payload)
+}
+
+// SendDevice delivers msg to the local connections of the user opened from deviceID.
+func (h *Hub) SendDevice(userID, deviceID string, msg []byte) error {
+	for _, conn := range h.Conns(userID) {
+		if conn.DeviceID == deviceID {
+			h.send(conn, msg)
+		}
+	}
+	return nil
+}
+
+// Conns returns the local connections of the user.
+func (h *Hub) Conns(userID string) []*Conn {
+	s := h.shard(userID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conns := make([]*Conn, 0, len(s.sockets[userID]))
+	for conn := range s.sockets[userID] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// sendLocal delivers a message addressed to the user on this pod, honoring the
+// envelope device when set and handling disconnect requests.
+func (h *Hub) sendLocal(userID string, msg []byte) error {
+	var target struct {
+		DeviceID string          `json:"device_id"`
+		Type     string          `json:"type"`
+		Payload  json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(msg, &target); err == nil {
+		if target.Type == disconnectType {
+			return h.disconnect(Envelope{UserID: userID, Type: target.Type, Payload: target.Payload})
+		}
+		if target.DeviceID != "" {
+			return h.SendDevice(userID, target.DeviceID, msg)
+		}
+	}
+	return h.SendLocal(userID, msg)
+}
+
+func connFields(c *Conn) []zap.Field {
+	return []zap.Field{
+		zap.String("userID", c.UserID),
+		zap.String("deviceID", c.DeviceID),
+		zap.String("sessionID", c.SessionID),
+	}
+}
@@ -0,0 +1,47 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// disconnectType is the envelope type of DisconnectUser requests routed between pods;
+// it is reserved and never reaches clients.
+const disconnectType = "ws.disconnect"
+
+type disconnectPayload struct {
+	Reason string `json:"reason"`
+}
+
+// DisconnectUser closes every connection of the user on every pod with 1008 Policy
+// Violation and {"reason": reason}, e.g. "account_suspended". Clients should not
+// reconnect with the same token.
+func (ws *WebSocket) DisconnectUser(ctx context.Context, userID, reason string) error {
+	payload, _ := json.Marshal(disconnectPayload{Reason: reason})
+	msg, err := json.Marshal(Envelope{UserID: userID, Type: disconnectType, Payload: payload})
+	if err != nil {
+		return err
+	}
+
+	ws.Logger.Info("disconnecting user", zap.String("userID", userID), zap.String("reason", reason))
+	return ws.Sender.SendToUser(ctx, userID, msg)
+}
+
+// disconnect closes the local connections of the user as requested by env.
+func (h *Hub) disconnect(env Envelope) error {
+	var p disconnectPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return err
+	}
+
+	for _, c := range h.Conns(env.UserID) {
+		h.logger.Info("connection closed: disconnect requested", append(connFields(c), zap.String("reason", p.Reason))...)
+		c.closeOnce.Do(func() {
+			closeConn(c.WS, websocket.ClosePolicyViolation, CloseReason{Reason: p.Reason})
+		})
+	}
+	return nil
+}
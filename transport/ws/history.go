@@ -0,0 +1,151 @@
+package ws
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// HistoryStore records outbound envelopes in capped Redis Streams, one per user and one
+// per channel, for catch-up on resume and for inspecting what a client was sent.
+type HistoryStore struct {
+	Pool   *redis.Pool
+	Prefix string        // e.g., "ws:history"
+	MaxLen int           // approximate entries kept per stream
+	TTL    time.Duration // idle streams expire after TTL, zero keeps them
+	Logger *zap.Logger
+}
+
+// HistoryEntry is an envelope read back from a history stream.
+type HistoryEntry struct {
+	ID       string    `json:"id"` // stream entry ID
+	Time     time.Time `json:"time"`
+	Envelope Envelope  `json:"envelope"`
+}
+
+func NewHistoryStore(pool *redis.Pool, logger *zap.Logger) *HistoryStore {
+	return &HistoryStore{
+		Pool:   pool,
+		Logger: logger,
+		Prefix: "ws:history",
+		MaxLen: 1000,
+		TTL:    7 * 24 * time.Hour,
+	}
+}
+
+func (h *HistoryStore) userKey(userID string) string {
+	return h.Prefix + ":user:" + userID
+}
+
+func (h *HistoryStore) channelKey(channel string) string {
+	return h.Prefix + ":channel:" + channel
+}
+
+// AppendUser records a message sent to the user.
+func (h *HistoryStore) AppendUser(userID string, msg []byte) {
+	h.append(h.userKey(userID), msg)
+}
+
+// AppendChannel records a message broadcast to the channel.
+func (h *HistoryStore) AppendChannel(channel string, msg []byte) {
+	h.append(h.channelKey(channel), msg)
+}
+
+func (h *HistoryStore) append(key string, msg []byte) {
+	conn := h.Pool.Get()
+	defer conn.Close()
+
+	args := []any{key}
+	if h.MaxLen > 0 {
+		args = append(args, "MAXLEN", "~", h.MaxLen)
+	}
+	args = append(args, "*", "data", msg)
+
+	_ = conn.Send("XADD", args...)
+	if h.TTL > 0 {
+		_ = conn.Send("EXPIRE", key, int(h.TTL.Seconds()))
+	}
+	if _, err := conn.Do(""); err != nil && h.Logger != nil {
+		h.Logger.Error("failed to append history", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// User returns the latest limit messages sent to the user, newest first.
+func (h *HistoryStore) User(userID string, limit int) ([]HistoryEntry, error) {
+	return h.latest(h.userKey(userID), limit)
+}
+
+// Channel returns the latest limit messages broadcast to the channel, newest first.
+func (h *HistoryStore) Channel(channel string, limit int) ([]HistoryEntry, error) {
+	return h.latest(h.channelKey(channel), limit)
+}
+
+// UserSince returns the recorded messages of the user with a sequence above seq,
+// oldest first.
+func (h *HistoryStore) UserSince(userID string, seq int64) ([]HistoryEntry, error) {
+	conn := h.Pool.Get()
+	defer conn.Close()
+
+	entries, err := readStream(conn.Do("XRANGE", h.userKey(userID), "-", "+"))
+	if err != nil {
+		return nil, err
+	}
+
+	var since []HistoryEntry
+	for _, e := range entries {
+		if e.Envelope.Seq > seq {
+			since = append(since, e)
+		}
+	}
+	return since, nil
+}
+
+func (h *HistoryStore) latest(key string, limit int) ([]HistoryEntry, error) {
+	conn := h.Pool.Get()
+	defer conn.Close()
+
+	args := []any{key, "+", "-"}
+	if limit > 0 {
+		args = append(args, "COUNT", limit)
+	}
+	return readStream(conn.Do("XREVRANGE", args...))
+}
+
+// readStream parses an XRANGE/XREVRANGE reply; entries that are not envelopes are skipped.
+func readStream(reply any, err error) ([]HistoryEntry, error) {
+	items, err := redis.Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(items))
+	for _, item := range items {
+		parts, err := redis.Values(item, nil)
+		if err != nil || len(parts) != 2 {
+			continue
+		}
+		id, _ := redis.String(parts[0], nil)
+		fields, _ := redis.StringMap(parts[1], nil)
+
+		var env Envelope
+		if err := json.Unmarshal([]byte(fields["data"]), &env); err != nil {
+			continue
+		}
+		entries = append(entries, HistoryEntry{ID: id, Time: streamTime(id), Envelope: env})
+	}
+	return entries, nil
+}
+
+// streamTime returns the time encoded in a stream entry ID ("<millis>-<seq>").
+func streamTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
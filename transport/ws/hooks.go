@@ -0,0 +1,126 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultPresenceDebounce delays OnDisconnect when Config.PresenceDebounce is zero.
+const DefaultPresenceDebounce = 5 * time.Second
+
+// PresenceHook is called when a user comes online or goes offline.
+type PresenceHook func(ctx context.Context, userID string)
+
+// presenceHooks tracks the registered hooks and the pending offline timers.
+type presenceHooks struct {
+	mu           sync.Mutex
+	onConnect    []PresenceHook
+	onDisconnect []PresenceHook
+	pending      map[string]*time.Timer
+}
+
+// OnConnect registers a hook fired when a user opens a first connection while not
+// connected to any pod, e.g. to publish a "courier online" event.
+func (ws *WebSocket) OnConnect(hook PresenceHook) {
+	ws.hooks.mu.Lock()
+	defer ws.hooks.mu.Unlock()
+	ws.hooks.onConnect = append(ws.hooks.onConnect, hook)
+}
+
+// OnDisconnect registers a hook fired when a user's last connection closed and they
+// did not reconnect within Config.PresenceDebounce, on this or another pod.
+func (ws *WebSocket) OnDisconnect(hook PresenceHook) {
+	ws.hooks.mu.Lock()
+	defer ws.hooks.mu.Unlock()
+	ws.hooks.onDisconnect = append(ws.hooks.onDisconnect, hook)
+}
+
+// connected fires OnConnect for the first local connection of a user, unless it only
+// replaces a connection closed within the debounce window.
+func (ws *WebSocket) connected(userID string) {
+	h := &ws.hooks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.pending[userID]; ok {
+		t.Stop()
+		delete(h.pending, userID)
+		return
+	}
+	if len(h.onConnect) == 0 || len(ws.Hub.Conns(userID)) != 1 {
+		return
+	}
+
+	hooks := h.onConnect
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if ws.onOtherPods(ctx, userID) {
+			return
+		}
+		ws.runHooks(ctx, "connect", hooks, userID)
+	}()
+}
+
+// disconnected schedules OnDisconnect once the last local connection of a user closed.
+func (ws *WebSocket) disconnected(userID string) {
+	h := &ws.hooks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.onDisconnect) == 0 || len(ws.Hub.Conns(userID)) != 0 {
+		return
+	}
+	if h.pending == nil {
+		h.pending = map[string]*time.Timer{}
+	}
+	if t, ok := h.pending[userID]; ok {
+		t.Stop()
+	}
+
+	hooks := h.onDisconnect
+	h.pending[userID] = time.AfterFunc(durationOr(ws.PresenceDebounce, DefaultPresenceDebounce), func() {
+		h.mu.Lock()
+		delete(h.pending, userID)
+		h.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if len(ws.Hub.Conns(userID)) > 0 || ws.onOtherPods(ctx, userID) {
+			return
+		}
+		ws.runHooks(ctx, "disconnect", hooks, userID)
+	})
+}
+
+// onOtherPods reports whether the registry lists the user on another pod.
+func (ws *WebSocket) onOtherPods(ctx context.Context, userID string) bool {
+	pods, err := ws.Registry.GetUserPods(ctx, userID)
+	if err != nil {
+		ws.Logger.Warn("presence hook: registry lookup failed", zap.String("userID", userID), zap.Error(err))
+		return false
+	}
+	for _, pod := range pods {
+		if pod != ws.PodID {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws *WebSocket) runHooks(ctx context.Context, event string, hooks []PresenceHook, userID string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			ws.Logger.Error("presence hook panicked", zap.String("event", event), zap.String("userID", userID), zap.Any("error", rec))
+		}
+	}()
+
+	for _, hook := range hooks {
+		hook(ctx, userID)
+	}
+}
@@ -1,43 +1,107 @@
 package ws
 
 import (
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 )
 
-// Hub tracks user connections.
+// DefaultHubShards is the number of socket map shards of NewHub.
+const DefaultHubShards = 32
+
+// Hub tracks user connections. The user socket map is sharded by user ID so that
+// connects, disconnects and sends of different users rarely contend on one lock;
+// channel membership has its own lock.
 type Hub struct {
+	shards []*hubShard
+
+	mu       sync.RWMutex // guards channels and joined
+	channels map[string]map[*Conn]struct{}
+	joined   map[*Conn]map[string]struct{}
+
+	total   atomic.Int64
+	dropped atomic.Uint64
+	metrics *Metrics
+	logger  *zap.Logger
+}
+
+type hubShard struct {
 	mu      sync.RWMutex
 	sockets map[string]map[*Conn]struct{}
-	logger  *zap.Logger
 }
 
 func NewHub(logger *zap.Logger) *Hub {
+	return NewShardedHub(DefaultHubShards, logger)
+}
+
+// NewShardedHub creates a hub with the given number of socket map shards, see
+// Config.HubShards; n <= 0 uses DefaultHubShards.
+func NewShardedHub(n int, logger *zap.Logger) *Hub {
+	if n <= 0 {
+		n = DefaultHubShards
+	}
+
+	shards := make([]*hubShard, n)
+	for i := range shards {
+		shards[i] = &hubShard{sockets: map[string]map[*Conn]struct{}{}}
+	}
+
 	return &Hub{
-		sockets: map[string]map[*Conn]struct{}{},
-		logger:  logger,
+		shards:   shards,
+		channels: map[string]map[*Conn]struct{}{},
+		joined:   map[*Conn]map[string]struct{}{},
+		logger:   logger,
 	}
 }
 
+func (h *Hub) shard(userID string) *hubShard {
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(userID))
+	return h.shards[f.Sum32()%uint32(len(h.shards))]
+}
+
 func (h *Hub) Add(userID string, conn *Conn) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	if _, ok := h.sockets[userID]; !ok {
-		h.sockets[userID] = map[*Conn]struct{}{}
+	s := h.shard(userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.add(userID, conn) {
+		h.total.Add(1)
+		h.logger.Info("connection added", zap.String("userID", userID))
+	}
+}
+
+// add reports whether conn was not yet in the shard; the caller holds the lock.
+func (s *hubShard) add(userID string, conn *Conn) bool {
+	if _, ok := s.sockets[userID][conn]; ok {
+		return false
 	}
-	h.sockets[userID][conn] = struct{}{}
-	h.logger.Info("connection added", zap.String("userID", userID))
+	if _, ok := s.sockets[userID]; !ok {
+		s.sockets[userID] = map[*Conn]struct{}{}
+	}
+	s.sockets[userID][conn] = struct{}{}
+	return true
 }
 
 func (h *Hub) Remove(conn *Conn) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	if conns, ok := h.sockets[conn.UserID]; ok {
+	h.leaveAll(conn)
+	h.mu.Unlock()
+
+	s := h.shard(conn.UserID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conns, ok := s.sockets[conn.UserID]; ok {
+		if _, ok := conns[conn]; ok {
+			h.total.Add(-1)
+		}
 		delete(conns, conn)
 		if len(conns) == 0 {
 			h.logger.Info("last connection removed", zap.String("userID", conn.UserID))
-			delete(h.sockets, conn.UserID)
+			delete(s.sockets, conn.UserID)
 		} else {
 			h.logger.Info("connection removed", zap.String("userID", conn.UserID))
 		}
@@ -45,26 +109,151 @@ func (h *Hub) Remove(conn *Conn) {
 }
 
 func (h *Hub) SendLocal(userID string, msg []byte) error {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	for conn := range h.sockets[userID] {
-		select {
-		case conn.Send <- msg:
-		default:
-			h.logger.Warn("dropped message due to full channel", zap.String("userID", userID))
-		}
+	for _, conn := range h.Conns(userID) {
+		h.send(conn, msg)
 	}
 	return nil
 }
 
+// send enqueues outside the hub lock since a blocking backpressure policy may wait.
+func (h *Hub) send(conn *Conn, msg []byte) {
+	if err := conn.enqueue(msg); err != nil {
+		h.dropped.Add(1)
+		h.metrics.messageDropped(conn.Backpressure.Policy)
+		h.logger.Warn("dropped message due to full channel",
+			zap.String("userID", conn.UserID),
+			zap.Stringer("policy", conn.Backpressure.Policy),
+			zap.Uint64("dropped", conn.Dropped()),
+		)
+	}
+}
+
+// Dropped returns the number of messages dropped by backpressure on this pod.
+func (h *Hub) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
 // ListUserIDs returns all currently connected user IDs.
 func (h *Hub) ListUserIDs() []string {
+	ids := make([]string, 0, h.Count())
+	for _, s := range h.shards {
+		s.mu.RLock()
+		for userID := range s.sockets {
+			ids = append(ids, userID)
+		}
+		s.mu.RUnlock()
+	}
+	return ids
+}
+
+// Join adds the connection to a channel, reporting whether it is the channel's
+// first member on this pod.
+func (h *Hub) Join(channel string, conn *Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members, ok := h.channels[channel]
+	if !ok {
+		members = map[*Conn]struct{}{}
+		h.channels[channel] = members
+	}
+	members[conn] = struct{}{}
+
+	if h.joined[conn] == nil {
+		h.joined[conn] = map[string]struct{}{}
+	}
+	h.joined[conn][channel] = struct{}{}
+
+	return !ok
+}
+
+// Leave removes the connection from a channel, reporting whether the channel has
+// no members left on this pod.
+func (h *Hub) Leave(channel string, conn *Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.leave(channel, conn)
+}
+
+// LeaveAll removes the connection from all its channels and returns the channels
+// left without members on this pod.
+func (h *Hub) LeaveAll(conn *Conn) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.leaveAll(conn)
+}
+
+func (h *Hub) leaveAll(conn *Conn) []string {
+	var emptied []string
+	for channel := range h.joined[conn] {
+		if h.leave(channel, conn) {
+			emptied = append(emptied, channel)
+		}
+	}
+	return emptied
+}
+
+func (h *Hub) leave(channel string, conn *Conn) bool {
+	members, ok := h.channels[channel]
+	if !ok {
+		return false
+	}
+	if _, ok := members[conn]; !ok {
+		return false
+	}
+
+	delete(members, conn)
+	delete(h.joined[conn], channel)
+	if len(h.joined[conn]) == 0 {
+		delete(h.joined, conn)
+	}
+
+	if len(members) == 0 {
+		delete(h.channels, channel)
+		return true
+	}
+	return false
+}
+
+// SendChannel delivers msg to the local members of a channel.
+func (h *Hub) SendChannel(channel string, msg []byte) error {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.channels[channel]))
+	for conn := range h.channels[channel] {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	for _, conn := range conns {
+		h.send(conn, msg)
+	}
+	return nil
+}
+
+// deliver routes a message received from another pod to its channel, device or user.
+func (h *Hub) deliver(env Envelope, msg []byte) error {
+	if env.Type == disconnectType {
+		return h.disconnect(env)
+	}
+	if env.Channel != "" {
+		return h.SendChannel(env.Channel, msg)
+	}
+	if env.DeviceID != "" {
+		return h.SendDevice(env.UserID, env.DeviceID, msg)
+	}
+	return h.SendLocal(env.UserID, msg)
+}
+
+// ListChannels returns all channels with local members.
+func (h *Hub) ListChannels() []string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	ids := make([]string, 0, len(h.sockets))
-	for userID := range h.sockets {
-		ids = append(ids, userID)
+	channels := make([]string, 0, len(h.channels))
+	for channel := range h.channels {
+		channels = append(channels, channel)
 	}
-	return ids
+	return channels
 }
@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Close reasons sent as JSON in the close frame of rejected or evicted connections.
+const (
+	ReasonPodLimit  = "pod_connection_limit"
+	ReasonUserLimit = "user_connection_limit"
+	ReasonEvicted   = "evicted_by_newer_connection"
+)
+
+var (
+	// ErrPodLimit is returned by RegisterConn when the pod holds Config.MaxConns connections.
+	ErrPodLimit = errors.New("ws: pod connection limit reached")
+	// ErrUserLimit is returned by RegisterConn when the user holds Config.MaxConnsPerUser connections.
+	ErrUserLimit = errors.New("ws: user connection limit reached")
+)
+
+// CloseReason is the JSON close frame text of connections closed by the server.
+type CloseReason struct {
+	Reason    string `json:"reason"`
+	Limit     int    `json:"limit,omitempty"`
+	Reconnect bool   `json:"reconnect,omitempty"` // the client should reconnect immediately
+}
+
+// closeConn sends a close frame with the structured reason and closes the socket.
+func closeConn(conn *websocket.Conn, code int, reason CloseReason) {
+	text, _ := json.Marshal(reason)
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, string(text)),
+		time.Now().Add(time.Second))
+	_ = conn.Close()
+}
+
+// admit adds the connection to the hub within the configured limits. When the user
+// limit is reached the oldest connection is evicted if EvictOldest is set, otherwise
+// the new connection is closed.
+func (ws *WebSocket) admit(c *Conn) error {
+	evicted, err := ws.Hub.addLimited(c, ws.MaxConnsPerUser, ws.MaxConns, ws.EvictOldest)
+
+	switch {
+	case errors.Is(err, ErrPodLimit):
+		ws.Logger.Warn("connection rejected: pod limit", append(connFields(c), zap.Int("limit", ws.MaxConns))...)
+		closeConn(c.WS, websocket.CloseTryAgainLater, CloseReason{Reason: ReasonPodLimit, Limit: ws.MaxConns})
+		return err
+
+	case errors.Is(err, ErrUserLimit):
+		ws.Logger.Warn("connection rejected: user limit", append(connFields(c), zap.Int("limit", ws.MaxConnsPerUser))...)
+		closeConn(c.WS, websocket.ClosePolicyViolation, CloseReason{Reason: ReasonUserLimit, Limit: ws.MaxConnsPerUser})
+		return err
+	}
+
+	if evicted != nil {
+		ws.Logger.Info("connection evicted: user limit", connFields(evicted)...)
+		evicted.closeOnce.Do(func() {
+			closeConn(evicted.WS, websocket.ClosePolicyViolation, CloseReason{Reason: ReasonEvicted, Limit: ws.MaxConnsPerUser})
+		})
+	}
+	return nil
+}
+
+// addLimited adds conn unless the pod holds maxTotal connections or the user maxUser.
+// With evictOldest the user's oldest connection is returned for closing instead.
+// Zero limits are unlimited.
+func (h *Hub) addLimited(conn *Conn, maxUser, maxTotal int, evictOldest bool) (*Conn, error) {
+	s := h.shard(conn.UserID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var evict *Conn
+	if maxUser > 0 && len(s.sockets[conn.UserID]) >= maxUser {
+		if !evictOldest {
+			return nil, ErrUserLimit
+		}
+		for c := range s.sockets[conn.UserID] {
+			if evict == nil || c.LastSeen.Before(evict.LastSeen) {
+				evict = c
+			}
+		}
+	}
+
+	// An eviction frees a slot for the new connection
+	if maxTotal > 0 && evict == nil {
+		if !h.reserve(int64(maxTotal)) {
+			return nil, ErrPodLimit
+		}
+	} else {
+		h.total.Add(1)
+	}
+
+	if !s.add(conn.UserID, conn) {
+		h.total.Add(-1)
+		return nil, nil
+	}
+	h.logger.Info("connection added", zap.String("userID", conn.UserID))
+	return evict, nil
+}
+
+// reserve counts a new connection if the pod is below limit; shards add concurrently,
+// so the check and the increment are a single compare-and-swap.
+func (h *Hub) reserve(limit int64) bool {
+	for {
+		n := h.total.Load()
+		if n >= limit {
+			return false
+		}
+		if h.total.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// Count returns the number of local connections.
+func (h *Hub) Count() int {
+	return int(h.total.Load())
+}
@@ -0,0 +1,140 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors of the WebSocket layer, see Config.Metrics.
+// A nil *Metrics records nothing.
+type Metrics struct {
+	connections *prometheus.GaugeVec
+	received    *prometheus.CounterVec
+	sent        *prometheus.CounterVec
+	dropped     *prometheus.CounterVec
+	acks        *prometheus.CounterVec
+	rateLimited *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+}
+
+// NewMetrics creates the WebSocket collectors under namespace and registers them with reg.
+func NewMetrics(namespace string, reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ws_connections_active",
+			Help:      "Number of open WebSocket connections.",
+		}, []string{"pod"}),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_messages_received_total",
+			Help:      "Total number of messages received from clients.",
+		}, []string{"type"}),
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_messages_sent_total",
+			Help:      "Total number of messages written to clients.",
+		}, []string{"pod"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_messages_dropped_total",
+			Help:      "Total number of messages dropped by backpressure.",
+		}, []string{"policy"}),
+		acks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_acks_total",
+			Help:      "Ack-tracked messages: stored (requiring ack) and acked by clients; pending is their difference.",
+		}, []string{"state"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_rate_limited_total",
+			Help:      "Total number of client messages rejected by the rate limiter.",
+		}, []string{"pod"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ws_handler_duration_seconds",
+			Help:      "Message handler latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type", "status"}),
+	}
+
+	reg.MustRegister(m.connections, m.received, m.sent, m.dropped, m.acks, m.rateLimited, m.duration)
+	return m
+}
+
+// Middleware observes handler latency labeled by message type and ok/error status.
+func (m *Metrics) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, conn *Conn, payload json.RawMessage) error {
+			start := time.Now()
+			err := next(ctx, conn, payload)
+
+			status := "ok"
+			if err != nil {
+				status = "error"
+			}
+			m.duration.WithLabelValues(MessageType(ctx), status).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+func (m *Metrics) connAdded(pod string) {
+	if m != nil {
+		m.connections.WithLabelValues(pod).Inc()
+	}
+}
+
+func (m *Metrics) connRemoved(pod string) {
+	if m != nil {
+		m.connections.WithLabelValues(pod).Dec()
+	}
+}
+
+func (m *Metrics) messageReceived(msgType string) {
+	if m != nil {
+		m.received.WithLabelValues(msgType).Inc()
+	}
+}
+
+func (m *Metrics) messageSent(pod string) {
+	if m != nil {
+		m.sent.WithLabelValues(pod).Inc()
+	}
+}
+
+func (m *Metrics) messageDropped(policy BackpressurePolicy) {
+	if m != nil {
+		m.dropped.WithLabelValues(policy.String()).Inc()
+	}
+}
+
+func (m *Metrics) ack(state string) {
+	if m != nil {
+		m.acks.WithLabelValues(state).Inc()
+	}
+}
+
+func (m *Metrics) rateLimit(pod string) {
+	if m != nil {
+		m.rateLimited.WithLabelValues(pod).Inc()
+	}
+}
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop_newest"
+	case DropOldest:
+		return "drop_oldest"
+	case CloseConnection:
+		return "close_connection"
+	case BlockWithTimeout:
+		return "block_with_timeout"
+	}
+	return strconv.Itoa(int(p))
+}
@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/logistics-id/engine/common"
+	"github.com/logistics-id/engine/validate"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps a message handler, see Router.Use.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type messageTypeKey struct{}
+
+// ErrForbidden is returned by RequirePermission when the session lacks the permission.
+var ErrForbidden = errors.New("ws: forbidden")
+
+// MessageType returns the type of the message being dispatched.
+func MessageType(ctx context.Context) string {
+	t, _ := ctx.Value(messageTypeKey{}).(string)
+	return t
+}
+
+// RecoveryMiddleware turns handler panics into errors so a single bad message
+// does not tear down the connection.
+func RecoveryMiddleware(logger *zap.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, conn *Conn, payload json.RawMessage) (err error) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.Error("panic recovered",
+						zap.String("type", MessageType(ctx)),
+						zap.String("userID", conn.UserID),
+						zap.Any("error", rec),
+						zap.ByteString("stack", debug.Stack()),
+					)
+					err = fmt.Errorf("ws: panic in %s handler: %v", MessageType(ctx), rec)
+				}
+			}()
+			return next(ctx, conn, payload)
+		}
+	}
+}
+
+// LoggingMiddleware logs every dispatched message with its duration and error.
+func LoggingMiddleware(logger *zap.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, conn *Conn, payload json.RawMessage) error {
+			start := time.Now()
+			err := next(ctx, conn, payload)
+
+			logger.Info("WS/MESSAGE",
+				zap.String("type", MessageType(ctx)),
+				zap.String("userID", conn.UserID),
+				zap.Int("size", len(payload)),
+				zap.Duration("duration", time.Since(start)),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+}
+
+// RequirePermission rejects messages unless the connection session holds perm.
+func RequirePermission(perm string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, conn *Conn, payload json.RawMessage) error {
+			if !common.ValidTokenPermission(ctx, perm) {
+				return ErrForbidden
+			}
+			return next(ctx, conn, payload)
+		}
+	}
+}
+
+// errorPayload is sent to the client as an "error" envelope when a handler fails.
+type errorPayload struct {
+	Type    string            `json:"type"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// dispatchError logs a handler error and reports it to the client, echoing the
+// message ID so requests can be correlated.
+func (ws *WebSocket) dispatchError(c *Conn, env Envelope, err error) {
+	ws.Logger.Warn("handler error", zap.String("type", env.Type), zap.String("userID", c.UserID), zap.Error(err))
+
+	body := errorPayload{Type: env.Type, Message: err.Error()}
+
+	var vr *validate.Response
+	if errors.As(err, &vr) {
+		body.Message = "validation failed"
+		body.Errors = vr.GetMessages()
+	}
+
+	payload, _ := json.Marshal(body)
+	_ = c.Reply(Envelope{Type: "error", ID: env.ID, Payload: payload})
+}
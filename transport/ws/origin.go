@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// checkOrigin accepts the upgrade when Config.OriginFunc allows it, or when the Origin
+// header matches one of Config.Origins. No policy accepts every origin.
+func (ws *WebSocket) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+
+	switch {
+	case ws.OriginFunc != nil:
+		if ws.OriginFunc(r) {
+			return true
+		}
+	case len(ws.Origins) == 0:
+		return true
+	default:
+		for _, pattern := range ws.Origins {
+			if matchOrigin(pattern, origin) {
+				return true
+			}
+		}
+	}
+
+	ws.Logger.Warn("connection rejected: origin not allowed", zap.String("origin", origin))
+	return false
+}
+
+// matchOrigin reports whether origin matches pattern, case-insensitively:
+//
+//	"*"                        any origin
+//	"https://app.logistics.id" exact scheme, host and port
+//	"app.logistics.id"         any scheme
+//	"*.logistics.id"           any subdomain (not the apex), any scheme
+//	"https://*.logistics.id"   any subdomain over https
+//
+// A port must appear in both or neither.
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := pattern
+	if scheme, rest, ok := strings.Cut(pattern, "://"); ok {
+		if !strings.EqualFold(scheme, u.Scheme) {
+			return false
+		}
+		host = rest
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	if suffix, ok := strings.CutPrefix(host, "*."); ok {
+		return strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(suffix))
+	}
+	return strings.EqualFold(host, u.Host)
+}
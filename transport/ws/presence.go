@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultHeartbeatInterval is how often presence is refreshed when Config.HeartbeatInterval is zero.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// Heartbeater is implemented by registries whose presence entries expire and must be
+// refreshed periodically while connections are alive.
+type Heartbeater interface {
+	Heartbeat(ctx context.Context, podID string, userIDs, channels []string) error
+}
+
+// startHeartbeat refreshes the presence of every local user and channel on each tick so
+// long-lived connections never fall out of the registry, while entries of crashed pods
+// expire after the registry TTL.
+func (ws *WebSocket) startHeartbeat() {
+	hb, ok := ws.Registry.(Heartbeater)
+	if !ok {
+		return
+	}
+
+	interval := ws.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if ws.draining.Load() {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := hb.Heartbeat(ctx, ws.PodID, ws.Hub.ListUserIDs(), ws.Hub.ListChannels())
+			cancel()
+
+			if err != nil {
+				ws.Logger.Warn("presence heartbeat failed", zap.Error(err))
+			}
+		}
+	}()
+}
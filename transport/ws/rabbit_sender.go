@@ -37,7 +37,7 @@ func (s *RMQSender) SendToUser(ctx context.Context, userID string, msg []byte) e
 
 			if pod == s.PodID {
 				log.Debug("sent to local user")
-				s.Hub.SendLocal(userID, msg)
+				s.Hub.sendLocal(userID, msg)
 			} else {
 				log.Debug("publishing to routing key", zap.String("routingKey", s.getKey(pod)))
 
@@ -55,6 +55,13 @@ func (s *RMQSender) SendToUser(ctx context.Context, userID string, msg []byte) e
 	return nil
 }
 
+// SendToChannel publishes a channel message to every pod holding members of it.
+func (s *RMQSender) SendToChannel(ctx context.Context, channel string, msg []byte) error {
+	return sendToChannel(ctx, channel, msg, s.PodID, s.Hub, s.Registry, func(ctx context.Context, pod string, msg []byte) error {
+		return s.Broker.Publish(ctx, s.getKey(pod), msg)
+	}, s.Logger)
+}
+
 func NewRMQSender(podID string, broker *rabbitmq.Client, hub *Hub, registry Registry, logger *zap.Logger) *RMQSender {
 	key := fmt.Sprintf("ws.send.%s", podID)
 
@@ -70,7 +77,7 @@ func NewRMQSender(podID string, broker *rabbitmq.Client, hub *Hub, registry Regi
 				return err
 			}
 
-			err := hub.SendLocal(env.UserID, data)
+			err := hub.deliver(env, data)
 			if err != nil {
 				logger.Error("Failed send to local", zap.Error(err))
 				return err
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	dsredis "github.com/logistics-id/engine/ds/redis"
 	"go.uber.org/zap"
 )
 
@@ -26,8 +27,7 @@ func (r *RedisRateLimiter) Allow(ctx context.Context, userID string) bool {
 	conn := r.Pool.Get()
 	defer conn.Close()
 
-	key := r.Prefix + ":" + userID
-	count, err := redis.Int(conn.Do("INCR", key))
+	rl, err := dsredis.FixedWindow(conn, r.Prefix+":"+userID, r.Limit, r.Window)
 	if err != nil {
 		if r.Logger != nil {
 			r.Logger.Error("redis rate limit INCR failed", zap.String("userID", userID), zap.Error(err))
@@ -35,13 +35,9 @@ func (r *RedisRateLimiter) Allow(ctx context.Context, userID string) bool {
 		return true // fail-open
 	}
 
-	if count == 1 {
-		_, _ = conn.Do("EXPIRE", key, int(r.Window.Seconds()))
-	}
-
-	if count > r.Limit {
+	if !rl.Allowed {
 		if r.Logger != nil {
-			r.Logger.Warn("user rate limited", zap.String("userID", userID), zap.Int("count", count))
+			r.Logger.Warn("user rate limited", zap.String("userID", userID), zap.Int("limit", rl.Limit))
 		}
 		return false
 	}
@@ -2,14 +2,15 @@ package ws
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
 )
 
-// RedisRegistry implements Registry using redigo.
+// RedisRegistry implements Registry using redigo. Besides the user and channel sets,
+// each pod keeps a liveness key refreshed by the heartbeat; pods whose key expired
+// (e.g. after a crash) are ignored and pruned from the sets on lookup.
 type RedisRegistry struct {
 	Pool   *redis.Pool
 	TTL    time.Duration
@@ -20,6 +21,10 @@ func (r *RedisRegistry) key(userID string) string {
 	return r.Prefix + ":user:" + userID
 }
 
+func (r *RedisRegistry) podKey(podID string) string {
+	return r.Prefix + ":pod:" + podID
+}
+
 func (r *RedisRegistry) MarkOnline(ctx context.Context, userID, podID string) error {
 	conn := r.Pool.Get()
 	defer conn.Close()
@@ -30,10 +35,63 @@ func (r *RedisRegistry) MarkOnline(ctx context.Context, userID, podID string) er
 	}
 	if r.TTL > 0 {
 		_, _ = conn.Do("EXPIRE", key, int(r.TTL.Seconds()))
+		_, _ = conn.Do("SET", r.podKey(podID), time.Now().Unix(), "EX", int(r.TTL.Seconds()))
 	}
 	return nil
 }
 
+// Heartbeat refreshes the pod liveness key and re-adds the pod to the sets of its
+// connected users and joined channels, extending their TTL.
+func (r *RedisRegistry) Heartbeat(ctx context.Context, podID string, userIDs, channels []string) error {
+	if r.TTL <= 0 {
+		return nil
+	}
+
+	conn := r.Pool.Get()
+	defer conn.Close()
+
+	ttl := int(r.TTL.Seconds())
+	_ = conn.Send("SET", r.podKey(podID), time.Now().Unix(), "EX", ttl)
+	for _, userID := range userIDs {
+		_ = conn.Send("SADD", r.key(userID), podID)
+		_ = conn.Send("EXPIRE", r.key(userID), ttl)
+	}
+	for _, channel := range channels {
+		_ = conn.Send("SADD", r.channelKey(channel), podID)
+		_ = conn.Send("EXPIRE", r.channelKey(channel), ttl)
+	}
+
+	_, err := conn.Do("")
+	return err
+}
+
+// alivePods filters out pods without a liveness key and removes them from the set at key.
+func (r *RedisRegistry) alivePods(conn redis.Conn, key string, pods []string) ([]string, error) {
+	if r.TTL <= 0 || len(pods) == 0 {
+		return pods, nil
+	}
+
+	args := make([]any, len(pods))
+	for i, pod := range pods {
+		args[i] = r.podKey(pod)
+	}
+
+	values, err := redis.Values(conn.Do("MGET", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	alive := pods[:0]
+	for i, v := range values {
+		if v == nil {
+			_, _ = conn.Do("SREM", key, pods[i])
+			continue
+		}
+		alive = append(alive, pods[i])
+	}
+	return alive, nil
+}
+
 func (r *RedisRegistry) MarkOffline(ctx context.Context, userID, podID string) error {
 	conn := r.Pool.Get()
 	defer conn.Close()
@@ -44,33 +102,72 @@ func (r *RedisRegistry) MarkOffline(ctx context.Context, userID, podID string) e
 func (r *RedisRegistry) GetUserPods(ctx context.Context, userID string) ([]string, error) {
 	conn := r.Pool.Get()
 	defer conn.Close()
-	return redis.Strings(conn.Do("SMEMBERS", r.key(userID)))
+	pods, err := redis.Strings(conn.Do("SMEMBERS", r.key(userID)))
+	if err != nil {
+		return nil, err
+	}
+	return r.alivePods(conn, r.key(userID), pods)
 }
 
 func (r *RedisRegistry) GetUsers(ctx context.Context) ([]string, error) {
 	conn := r.Pool.Get()
 	defer conn.Close()
 
-	pattern := fmt.Sprintf("%s:user:*", r.Prefix)
-	keys, err := redis.Strings(conn.Do("KEYS", pattern))
+	var users []string
+	err := scanKeys(conn, r.Prefix+":user:*", func(keys []string) error {
+		for _, key := range keys {
+			users = append(users, strings.TrimPrefix(key, r.Prefix+":user:"))
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return users, nil
+}
 
-	var users []string
-	for _, key := range keys {
-		parts := strings.Split(key, ":")
-		if len(parts) >= 2 {
-			users = append(users, parts[len(parts)-1])
-		}
+func (r *RedisRegistry) channelKey(channel string) string {
+	return r.Prefix + ":channel:" + channel
+}
+
+// JoinChannel records that podID holds members of channel.
+func (r *RedisRegistry) JoinChannel(ctx context.Context, channel, podID string) error {
+	conn := r.Pool.Get()
+	defer conn.Close()
+	key := r.channelKey(channel)
+	_, err := conn.Do("SADD", key, podID)
+	if err != nil {
+		return err
 	}
-	return users, nil
+	if r.TTL > 0 {
+		_, _ = conn.Do("EXPIRE", key, int(r.TTL.Seconds()))
+	}
+	return nil
+}
+
+// LeaveChannel removes podID once its last channel member left.
+func (r *RedisRegistry) LeaveChannel(ctx context.Context, channel, podID string) error {
+	conn := r.Pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("SREM", r.channelKey(channel), podID)
+	return err
+}
+
+// GetChannelPods returns the pods holding members of channel.
+func (r *RedisRegistry) GetChannelPods(ctx context.Context, channel string) ([]string, error) {
+	conn := r.Pool.Get()
+	defer conn.Close()
+	pods, err := redis.Strings(conn.Do("SMEMBERS", r.channelKey(channel)))
+	if err != nil {
+		return nil, err
+	}
+	return r.alivePods(conn, r.channelKey(channel), pods)
 }
 
 func NewRedisRegistry(redisPool *redis.Pool) *RedisRegistry {
 	return &RedisRegistry{
 		Pool:   redisPool,
-		TTL:    24 * 60 * 60 * time.Second,
+		TTL:    2 * time.Minute,
 		Prefix: "ws",
 	}
 }
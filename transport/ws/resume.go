@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+type resumePayload struct {
+	LastSeq int64 `json:"last_seq"`
+}
+
+// resumeResult is the payload of the "resume" reply sent after the replay.
+type resumeResult struct {
+	Replayed int   `json:"replayed"`
+	LastSeq  int64 `json:"last_seq"`
+}
+
+// storedMessage is an ack-tracked message loaded from the AckStore.
+type storedMessage struct {
+	Key  string
+	Env  Envelope
+	Data []byte
+}
+
+// NextSeq returns the next sequence number of the user. The counter lives in Redis so
+// it is monotonic across pods.
+func (a *AckStore) NextSeq(userID string) (int64, error) {
+	conn := a.Pool.Get()
+	defer conn.Close()
+
+	return redis.Int64(conn.Do("INCR", a.seqKey(userID)))
+}
+
+// LastSeq returns the last sequence number assigned to the user, zero if none.
+func (a *AckStore) LastSeq(userID string) (int64, error) {
+	conn := a.Pool.Get()
+	defer conn.Close()
+
+	seq, err := redis.Int64(conn.Do("GET", a.seqKey(userID)))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// seqKey is outside the Prefix:userID:* pattern of the stored messages.
+func (a *AckStore) seqKey(userID string) string {
+	return a.Prefix + "-seq:" + userID
+}
+
+// sorted returns the stored messages of the user ordered by sequence; messages
+// stored before sequencing was enabled come first.
+func (a *AckStore) sorted(conn redis.Conn, userID string) ([]storedMessage, error) {
+	var msgs []storedMessage
+	err := a.pending(conn, userID, func(key string, data []byte) {
+		var env Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return
+		}
+		msgs = append(msgs, storedMessage{Key: key, Env: env, Data: data})
+	})
+
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].Env.Seq < msgs[j].Env.Seq
+	})
+	return msgs, err
+}
+
+// resumeHandler replays, in sequence order and to this connection only, the messages
+// the client missed after last_seq, then replies with the replay count and the user's
+// current sequence.
+func (ws *WebSocket) resumeHandler(ctx context.Context, c *Conn, raw json.RawMessage) error {
+	var req resumePayload
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return err
+	}
+
+	msgs, err := ws.missed(c.UserID, req.LastSeq)
+	if err != nil {
+		ws.Logger.Warn("resume: redis read failed", zap.Error(err))
+		return nil
+	}
+
+	now := time.Now().UnixMilli()
+	replayed := 0
+	for _, m := range msgs {
+		if m.Env.ExpiresAt > 0 && m.Env.ExpiresAt < now {
+			continue
+		}
+		if req.LastSeq > 0 && m.Env.Seq <= req.LastSeq {
+			continue
+		}
+		if err := c.enqueue(m.Data); err != nil {
+			ws.Logger.Warn("resume: replay dropped", append(connFields(c), zap.Int64("seq", m.Env.Seq), zap.Error(err))...)
+			continue
+		}
+		replayed++
+	}
+
+	lastSeq, err := ws.AckStore.LastSeq(c.UserID)
+	if err != nil {
+		ws.Logger.Warn("resume: read sequence failed", zap.Error(err))
+	}
+
+	ws.Logger.Info("resumed session", append(connFields(c), zap.Int64("since", req.LastSeq), zap.Int("replayed", replayed))...)
+	payload, _ := json.Marshal(resumeResult{Replayed: replayed, LastSeq: lastSeq})
+	return c.Reply(Envelope{Type: "resume", Payload: payload})
+}
+
+// missed returns the candidate messages of a resume in sequence order: the recorded
+// history when a HistoryStore is configured, which also covers messages sent without
+// ack, otherwise the unacked messages of the AckStore.
+func (ws *WebSocket) missed(userID string, lastSeq int64) ([]storedMessage, error) {
+	if ws.History == nil {
+		conn := ws.AckStore.Pool.Get()
+		defer conn.Close()
+
+		return ws.AckStore.sorted(conn, userID)
+	}
+
+	entries, err := ws.History.UserSince(userID, lastSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]storedMessage, 0, len(entries))
+	for _, e := range entries {
+		data, err := json.Marshal(e.Envelope)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, storedMessage{Key: e.ID, Env: e.Envelope, Data: data})
+	}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].Env.Seq < msgs[j].Env.Seq
+	})
+	return msgs, nil
+}
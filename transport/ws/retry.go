@@ -0,0 +1,141 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// AckRetry configures resending RequiresAck messages with exponential backoff while
+// the user stays online, see Config.AckRetry. Offline users get their unacked messages
+// on reconnect instead.
+type AckRetry struct {
+	Initial     time.Duration // first retry delay, default 5s
+	Max         time.Duration // backoff cap, default 1m
+	MaxAttempts int           // zero retries until the message is acked or expires
+}
+
+// ackScheduler holds the retry timers of the messages sent from this pod.
+type ackScheduler struct {
+	cfg     AckRetry
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	stopped bool
+}
+
+func newAckScheduler(cfg AckRetry) *ackScheduler {
+	if cfg.Initial <= 0 {
+		cfg.Initial = 5 * time.Second
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = time.Minute
+	}
+	return &ackScheduler{cfg: cfg, timers: map[string]*time.Timer{}}
+}
+
+// scheduleRetry arms the first retry of an ack-tracked message.
+func (ws *WebSocket) scheduleRetry(userID string, env Envelope) {
+	if ws.retries == nil {
+		return
+	}
+	ws.retries.schedule(userID+":"+env.ID, retryDelay(ws.retries.cfg.Initial, env.ExpiresAt), func() {
+		ws.retryMessage(userID, env.ID, 1, ws.retries.cfg.Initial)
+	})
+}
+
+// retryMessage resends the message if it is still unacked and the user online, then
+// reschedules it with a doubled delay. Expired messages are dropped and reported to
+// AckStore.OnExpire.
+func (ws *WebSocket) retryMessage(userID, msgID string, attempt int, delay time.Duration) {
+	key := userID + ":" + msgID
+	ws.retries.done(key)
+
+	conn := ws.AckStore.Pool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", ws.AckStore.key(userID, msgID)))
+	if err != nil {
+		// Acked, or the ack entry outlived its TTL
+		return
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return
+	}
+	if env.ExpiresAt > 0 && time.Now().UnixMilli() >= env.ExpiresAt {
+		ws.AckStore.expire(conn, userID, env)
+		return
+	}
+
+	cfg := ws.retries.cfg
+	if cfg.MaxAttempts > 0 && attempt > cfg.MaxAttempts {
+		ws.Logger.Info("ack retries exhausted", zap.String("userID", userID), zap.String("msgID", msgID), zap.Int("attempts", cfg.MaxAttempts))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pods, err := ws.Registry.GetUserPods(ctx, userID)
+	if err != nil || len(pods) == 0 {
+		return
+	}
+
+	if err := ws.Sender.SendToUser(ctx, userID, data); err != nil {
+		ws.Logger.Warn("ack retry failed", zap.String("userID", userID), zap.String("msgID", msgID), zap.Error(err))
+	} else {
+		ws.Logger.Info("resent unacked message", zap.String("userID", userID), zap.String("msgID", msgID), zap.Int("attempt", attempt))
+	}
+
+	delay = min(delay*2, cfg.Max)
+	ws.retries.schedule(key, retryDelay(delay, env.ExpiresAt), func() {
+		ws.retryMessage(userID, msgID, attempt+1, delay)
+	})
+}
+
+// retryDelay shortens delay so the timer fires when the message expires.
+func retryDelay(delay time.Duration, expiresAt int64) time.Duration {
+	if expiresAt > 0 {
+		if until := time.Until(time.UnixMilli(expiresAt)); until < delay {
+			return max(until, 0)
+		}
+	}
+	return delay
+}
+
+func (s *ackScheduler) schedule(key string, delay time.Duration, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+	if t, ok := s.timers[key]; ok {
+		t.Stop()
+	}
+	s.timers[key] = time.AfterFunc(delay, fn)
+}
+
+func (s *ackScheduler) done(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.timers, key)
+}
+
+// stop cancels every pending retry.
+func (s *ackScheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for key, t := range s.timers {
+		t.Stop()
+		delete(s.timers, key)
+	}
+}
@@ -3,14 +3,17 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"reflect"
 
+	"github.com/logistics-id/engine/validate"
 	"go.uber.org/zap"
 )
 
 // Router dispatches messages to registered handlers.
 type Router struct {
-	handlers map[string]HandlerFunc
-	logger   *zap.Logger
+	handlers    map[string]HandlerFunc
+	middlewares []Middleware
+	logger      *zap.Logger
 }
 
 func NewRouter(logger *zap.Logger) *Router {
@@ -25,9 +28,22 @@ func (r *Router) Register(msgType string, handler HandlerFunc) {
 	r.logger.Debug("handler registered", zap.String("type", msgType))
 }
 
+func (r *Router) has(msgType string) bool {
+	_, ok := r.handlers[msgType]
+	return ok
+}
+
+// Use appends middleware wrapping every dispatched handler, the first one outermost.
+func (r *Router) Use(mw ...Middleware) {
+	r.middlewares = append(r.middlewares, mw...)
+}
+
 func (r *Router) Dispatch(ctx context.Context, msgType string, payload json.RawMessage, conn *Conn) error {
 	if handler, ok := r.handlers[msgType]; ok {
-		return handler(ctx, conn, payload)
+		for i := len(r.middlewares) - 1; i >= 0; i-- {
+			handler = r.middlewares[i](handler)
+		}
+		return handler(context.WithValue(ctx, messageTypeKey{}, msgType), conn, payload)
 	}
 	if r.logger != nil {
 		r.logger.Warn("no handler for message type", zap.String("type", msgType), zap.String("userID", conn.UserID))
@@ -41,3 +57,41 @@ func Bind[T any](payload json.RawMessage) (T, error) {
 	err := json.Unmarshal(payload, &v)
 	return v, err
 }
+
+// OnTyped registers a handler whose payload is decoded into T and, for struct payloads,
+// validated with the validate package before the handler runs. Decoding and
+// validation failures are returned as the handler error (*validate.Response for the latter).
+//
+//	ws.OnTyped(wsServer, "order.update", func(ctx context.Context, c *ws.Conn, req OrderUpdate) error {
+//		return orderUsecase.Update(ctx, req)
+//	})
+func OnTyped[T any](ws *WebSocket, msgType string, handler func(ctx context.Context, conn *Conn, payload T) error) {
+	ws.Router.Register(msgType, func(ctx context.Context, conn *Conn, raw json.RawMessage) error {
+		payload, err := Bind[T](raw)
+		if err != nil {
+			return err
+		}
+
+		if res := validatePayload(&payload); res != nil && !res.Valid {
+			return res
+		}
+
+		return handler(ctx, conn, payload)
+	})
+}
+
+// validatePayload validates struct payloads, honoring validate.Request implementations.
+func validatePayload(v any) *validate.Response {
+	rt := reflect.TypeOf(v).Elem()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if vr, ok := v.(validate.Request); ok {
+		return validate.New().Request(vr)
+	}
+	return validate.New().Struct(v)
+}
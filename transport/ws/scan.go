@@ -0,0 +1,35 @@
+package ws
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// scanBatch is the SCAN COUNT hint and the MGET batch size.
+const scanBatch = 200
+
+// scanKeys iterates keys matching pattern with cursor-based SCAN, calling fn per batch,
+// so large keyspaces are walked without blocking Redis like KEYS does.
+func scanKeys(conn redis.Conn, pattern string, fn func(keys []string) error) error {
+	cursor := "0"
+	for {
+		reply, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", scanBatch))
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		if _, err := redis.Scan(reply, &cursor, &keys); err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// ReasonShutdown is the close reason of connections drained by Shutdown; the close
+// frame also sets reconnect so clients reconnect to another pod right away.
+const ReasonShutdown = "server_shutdown"
+
+// ErrShuttingDown is returned by RegisterConn once Shutdown has started.
+var ErrShuttingDown = errors.New("ws: server shutting down")
+
+// Shutdown drains the pod within Config.ShutdownTimeout: new upgrades are refused,
+// every connection flushes its send buffer and is closed with 1012 Service Restart,
+// and the read loops mark their users offline in the registry. Connections still open
+// at the deadline are closed abruptly. It is safe to call more than once and with an
+// already cancelled ctx, so it can be registered with engine.OnStop directly.
+func (ws *WebSocket) Shutdown(ctx context.Context) {
+	ws.shutdownOnce.Do(func() {
+		ws.draining.Store(true)
+		if ws.retries != nil {
+			ws.retries.stop()
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), durationOr(ws.ShutdownTimeout, 10*time.Second))
+		defer cancel()
+
+		ws.Logger.Info("draining websocket connections", zap.Int("connections", ws.Hub.Count()))
+
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			// Re-scan on every tick to catch upgrades that raced the draining flag
+			for _, c := range ws.Hub.all() {
+				c.drain()
+			}
+			if ws.open.Load() == 0 {
+				ws.Logger.Info("websocket connections drained")
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				conns := ws.Hub.all()
+				ws.Logger.Warn("websocket drain timed out, closing remaining connections", zap.Int("connections", len(conns)))
+				for _, c := range conns {
+					_ = c.WS.Close()
+				}
+				return
+			}
+		}
+	})
+}
+
+// rejectDraining refuses the upgrade while the pod shuts down so the client retries
+// against another pod.
+func (ws *WebSocket) rejectDraining(w http.ResponseWriter) error {
+	ws.Logger.Warn("connection rejected: server shutting down")
+	http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	return ErrShuttingDown
+}
+
+// drain asks the write loop to flush and close the connection.
+func (c *Conn) drain() {
+	if c.drainCh == nil {
+		return
+	}
+	c.drainOnce.Do(func() { close(c.drainCh) })
+}
+
+// flushAndClose writes the messages left in the send buffer and closes the connection
+// with the shutdown reason. It runs on the write loop, the only writer of the socket.
+func (ws *WebSocket) flushAndClose(c *Conn) {
+	for {
+		select {
+		case msg := <-c.Send:
+			frame, data := encodeFrame(c.Codec, msg)
+			c.WS.SetWriteDeadline(time.Now().Add(durationOr(ws.WriteTimeout, DefaultWriteTimeout)))
+			if err := c.WS.WriteMessage(frame, data); err != nil {
+				ws.Logger.Warn("write message error", zap.Error(err))
+				_ = c.WS.Close()
+				return
+			}
+			ws.Metrics.messageSent(ws.PodID)
+		default:
+			c.closeOnce.Do(func() {
+				closeConn(c.WS, websocket.CloseServiceRestart, CloseReason{Reason: ReasonShutdown, Reconnect: true})
+			})
+			return
+		}
+	}
+}
+
+// all returns every local connection.
+func (h *Hub) all() []*Conn {
+	conns := make([]*Conn, 0, h.Count())
+	for _, s := range h.shards {
+		s.mu.RLock()
+		for _, set := range s.sockets {
+			for conn := range set {
+				conns = append(conns, conn)
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return conns
+}
@@ -0,0 +1,25 @@
+package ws
+
+import "time"
+
+// Connection timing and size defaults, see Config.PingInterval.
+const (
+	DefaultPingInterval = 10 * time.Second
+	DefaultReadTimeout  = 30 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultReadLimit    = 64 << 10 // 64KB
+)
+
+func (ws *WebSocket) readLimit() int {
+	if ws.ReadLimit > 0 {
+		return ws.ReadLimit
+	}
+	return DefaultReadLimit
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
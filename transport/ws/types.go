@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,11 +15,24 @@ import (
 
 // Conn wraps an active WebSocket connection.
 type Conn struct {
-	UserID   string
-	WS       *websocket.Conn
-	Send     chan []byte
-	Close    chan struct{}
-	LastSeen time.Time
+	UserID       string
+	DeviceID     string            // client device, see DeviceIDHeader
+	SessionID    string            // JWT ID of the session, random when absent
+	Metadata     map[string]string // captured by Config.MetadataFunc
+	WS           *websocket.Conn
+	Send         chan []byte
+	Close        chan struct{}
+	LastSeen     time.Time
+	Backpressure Backpressure
+	Codec        Codec // negotiated wire codec, JSONCodec by default
+
+	dropped   atomic.Uint64
+	closeOnce sync.Once
+	drainCh   chan struct{}
+	drainOnce sync.Once
+	session   atomic.Pointer[session] // claims refreshed by auth.refresh
+	authMu    sync.Mutex
+	expiry    *time.Timer
 }
 
 func (c *Conn) Reply(payload any) error {
@@ -24,22 +40,23 @@ func (c *Conn) Reply(payload any) error {
 	if err != nil {
 		return err
 	}
-	select {
-	case c.Send <- msg:
-		return nil
-	default:
-		return fmt.Errorf("send buffer full for user %s", c.UserID)
+	if err := c.enqueue(msg); err != nil {
+		return fmt.Errorf("%w for user %s", err, c.UserID)
 	}
+	return nil
 }
 
 // Envelope defines the wire format for message delivery.
 type Envelope struct {
 	UserID      string          `json:"user_id,omitempty"`
+	Channel     string          `json:"channel,omitempty"`
+	DeviceID    string          `json:"device_id,omitempty"`
 	Type        string          `json:"type"`
 	Payload     json.RawMessage `json:"payload"`
 	ID          string          `json:"id,omitempty"`
 	RequiresAck bool            `json:"requiresAck,omitempty"`
 	ExpiresAt   int64           `json:"expiresAt,omitempty"` // epoch millis
+	Seq         int64           `json:"seq,omitempty"`       // per-user sequence, see AckStore.NextSeq
 }
 
 type Config struct {
@@ -48,10 +65,75 @@ type Config struct {
 	Registry    Registry
 	RateLimiter RateLimiter
 	AckStore    *AckStore
+	History     *HistoryStore // optional record of outbound envelopes
 	PodID       string
 	Logger      *zap.Logger
-	Origins     []string             // optional allowed origin list
+	Origins     []string             // optional allowed origin patterns, see matchOrigin
 	IPFilter    func(ip string) bool // optional IP filter
+
+	// OriginFunc replaces the Origins check with a custom policy.
+	OriginFunc func(r *http.Request) bool
+
+	// HubShards is the number of shards of the hub socket map when Hub is nil,
+	// default DefaultHubShards. Raise it for pods holding many connections.
+	HubShards int
+
+	// DeviceIDFunc reads the device ID of an upgrade request, default the
+	// X-Device-ID header or device_id query parameter.
+	DeviceIDFunc func(r *http.Request) string
+
+	// MetadataFunc captures arbitrary connection metadata (app version, platform, ...).
+	MetadataFunc func(r *http.Request) map[string]string
+
+	// Codecs are the wire codecs offered as subprotocols in preference order,
+	// default DefaultCodecs.
+	Codecs []Codec
+
+	// MaxConns caps the connections of this pod and MaxConnsPerUser those of a single
+	// user on it (zero is unlimited). With EvictOldest a user over the limit has the
+	// oldest connection closed instead of the new one rejected.
+	MaxConns        int
+	MaxConnsPerUser int
+	EvictOldest     bool
+
+	// Metrics records Prometheus metrics of connections, messages and handlers.
+	Metrics *Metrics
+
+	// SendBuffer is the per-connection send buffer size, default DefaultSendBuffer.
+	SendBuffer int
+
+	// Backpressure is the full-buffer policy of every connection; BackpressureFunc
+	// optionally picks one per connection, e.g. by client type.
+	Backpressure     Backpressure
+	BackpressureFunc func(r *http.Request) Backpressure
+
+	// PresenceDebounce delays OnDisconnect hooks so quick reconnects do not flap,
+	// default DefaultPresenceDebounce.
+	PresenceDebounce time.Duration
+
+	// PingInterval is how often the server pings the client and ReadTimeout how long
+	// it waits for any frame or pong before dropping the connection; keep PingInterval
+	// below ReadTimeout. WriteTimeout bounds each write and ReadLimit the size in bytes
+	// of a client message. Zero values use the Default* constants.
+	PingInterval time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	ReadLimit    int
+
+	// HeartbeatInterval refreshes registry presence, default DefaultHeartbeatInterval.
+	// Keep it well below the registry TTL.
+	HeartbeatInterval time.Duration
+
+	// AckRetry resends unacked RequiresAck messages with backoff while the user is
+	// online; nil only resends on reconnect. Requires AckStore.
+	AckRetry *AckRetry
+
+	// AckCleanupInterval is how often expired AckStore entries are swept, default
+	// DefaultAckCleanupInterval.
+	AckCleanupInterval time.Duration
+
+	// ShutdownTimeout bounds how long Shutdown waits for connections to drain, default 10s.
+	ShutdownTimeout time.Duration
 }
 
 type restorePayload struct {
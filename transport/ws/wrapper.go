@@ -35,8 +35,10 @@ func NewDefault(redisPool *redis.Pool, broker *rabbitmq.Client, logger *zap.Logg
 		Origins:     Origins,
 	}
 
+	ws.Router.Register("auth.refresh", ws.authRefreshHandler)
 	ws.Router.Register("ack", ackstore.AckHandler)
 	ws.Router.Register("restore", ws.restoreHandler)
+	ws.Router.Register("resume", ws.resumeHandler)
 
 	return ws
 }
@@ -3,12 +3,11 @@ package ws
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/websocket"
 	"github.com/logistics-id/engine/common"
 	"go.uber.org/zap"
@@ -22,28 +21,104 @@ type WebSocket struct {
 	Registry    Registry
 	RateLimiter RateLimiter
 	AckStore    *AckStore
+	History     *HistoryStore
 	PodID       string
 	Logger      *zap.Logger
 	Origins     []string
+	OriginFunc  func(r *http.Request) bool
 	IPFilter    func(ip string) bool
+
+	DeviceIDFunc     func(r *http.Request) string
+	MetadataFunc     func(r *http.Request) map[string]string
+	Codecs           []Codec
+	MaxConns         int
+	MaxConnsPerUser  int
+	EvictOldest      bool
+	Metrics          *Metrics
+	SendBuffer       int
+	Backpressure     Backpressure
+	BackpressureFunc func(r *http.Request) Backpressure
+
+	PingInterval time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	ReadLimit    int
+
+	PresenceDebounce time.Duration
+	hooks            presenceHooks
+
+	HeartbeatInterval time.Duration
+	heartbeatOnce     sync.Once
+
+	AckCleanupInterval time.Duration
+	cleanupOnce        sync.Once
+	retries            *ackScheduler
+
+	ShutdownTimeout time.Duration
+	shutdownOnce    sync.Once
+	draining        atomic.Bool
+	open            atomic.Int64 // connections whose read loop has not finished
 }
 
 func NewWebSocket(cfg Config) *WebSocket {
+	hub := cfg.Hub
+	if hub == nil {
+		hub = NewShardedHub(cfg.HubShards, cfg.Logger.With(zap.String("component", "hub"), zap.String("pod", cfg.PodID)))
+	}
+
 	ws := &WebSocket{
-		Hub:         NewHub(cfg.Logger.With(zap.String("component", "hub"), zap.String("pod", cfg.PodID))),
+		Hub:         hub,
 		Router:      NewRouter(cfg.Logger.With(zap.String("component", "router"), zap.String("pod", cfg.PodID))),
 		Sender:      cfg.Sender,
 		Registry:    cfg.Registry,
 		RateLimiter: cfg.RateLimiter,
 		AckStore:    cfg.AckStore,
+		History:     cfg.History,
 		PodID:       cfg.PodID,
 		Logger:      cfg.Logger,
 		Origins:     cfg.Origins,
+		OriginFunc:  cfg.OriginFunc,
 		IPFilter:    cfg.IPFilter,
+
+		DeviceIDFunc:     cfg.DeviceIDFunc,
+		MetadataFunc:     cfg.MetadataFunc,
+		Codecs:           cfg.Codecs,
+		MaxConns:         cfg.MaxConns,
+		MaxConnsPerUser:  cfg.MaxConnsPerUser,
+		EvictOldest:      cfg.EvictOldest,
+		Metrics:          cfg.Metrics,
+		SendBuffer:       cfg.SendBuffer,
+		Backpressure:     cfg.Backpressure,
+		BackpressureFunc: cfg.BackpressureFunc,
+
+		PingInterval: cfg.PingInterval,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		ReadLimit:    cfg.ReadLimit,
+
+		PresenceDebounce: cfg.PresenceDebounce,
+
+		HeartbeatInterval: cfg.HeartbeatInterval,
+
+		AckCleanupInterval: cfg.AckCleanupInterval,
+
+		ShutdownTimeout: cfg.ShutdownTimeout,
+	}
+	ws.Router.Register("auth.refresh", ws.authRefreshHandler)
+	if cfg.Metrics != nil {
+		hub.metrics = cfg.Metrics
+		ws.Router.Use(cfg.Metrics.Middleware())
+		if cfg.AckStore != nil {
+			cfg.AckStore.metrics = cfg.Metrics
+		}
+	}
+	if cfg.AckStore != nil && cfg.AckRetry != nil {
+		ws.retries = newAckScheduler(*cfg.AckRetry)
 	}
 	if cfg.AckStore != nil {
 		ws.Router.Register("ack", cfg.AckStore.AckHandler)
 		ws.Router.Register("restore", ws.restoreHandler)
+		ws.Router.Register("resume", ws.resumeHandler)
 
 	}
 	return ws
@@ -53,7 +128,21 @@ func (ws *WebSocket) On(msgType string, handler HandlerFunc) {
 	ws.Router.Register(msgType, handler)
 }
 
+// Use adds middleware around every message handler, see Router.Use.
+func (ws *WebSocket) Use(mw ...Middleware) {
+	ws.Router.Use(mw...)
+}
+
 func (ws *WebSocket) SendToUser(ctx context.Context, userID string, payload Envelope) error {
+	payload.UserID = userID // routes the message on remote pods
+	if ws.AckStore != nil {
+		seq, err := ws.AckStore.NextSeq(userID)
+		if err != nil {
+			ws.Logger.Warn("failed to assign sequence", zap.String("userID", userID), zap.Error(err))
+		}
+		payload.Seq = seq
+	}
+
 	msg, err := json.Marshal(payload)
 	if err != nil {
 		if ws.Logger != nil {
@@ -61,26 +150,20 @@ func (ws *WebSocket) SendToUser(ctx context.Context, userID string, payload Enve
 		}
 		return err
 	}
+	if ws.History != nil {
+		ws.History.AppendUser(userID, msg)
+	}
 	if payload.RequiresAck && ws.AckStore != nil && payload.ID != "" {
 		ws.AckStore.Save(userID, payload.ID, msg)
+		ws.Metrics.ack("stored")
+		ws.scheduleRetry(userID, payload)
 	}
 	return ws.Sender.SendToUser(ctx, userID, msg)
 }
 
 func (ws *WebSocket) RegisterConn(w http.ResponseWriter, r *http.Request, ctx context.Context) error {
-	originCheck := func(r *http.Request) bool {
-		if len(ws.Origins) == 0 {
-			return true
-		}
-		origin := r.Header.Get("Origin")
-		for _, allowed := range ws.Origins {
-			if strings.EqualFold(origin, allowed) {
-				return true
-			}
-		}
-
-		ws.Logger.Warn("connection rejected: origin not allowed", zap.String("origin", origin))
-		return false
+	if ws.draining.Load() {
+		return ws.rejectDraining(w)
 	}
 
 	ip := r.RemoteAddr
@@ -90,9 +173,15 @@ func (ws *WebSocket) RegisterConn(w http.ResponseWriter, r *http.Request, ctx co
 		return nil
 	}
 
+	codecs := ws.Codecs
+	if len(codecs) == 0 {
+		codecs = DefaultCodecs
+	}
+
 	upgrader := websocket.Upgrader{
-		CheckOrigin:       originCheck,
+		CheckOrigin:       ws.checkOrigin,
 		EnableCompression: true,
+		Subprotocols:      subprotocols(codecs),
 	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -103,18 +192,42 @@ func (ws *WebSocket) RegisterConn(w http.ResponseWriter, r *http.Request, ctx co
 	uc := common.GetContextSession(ctx)
 	userID := uc.UserID
 
+	buffer := ws.SendBuffer
+	if buffer <= 0 {
+		buffer = DefaultSendBuffer
+	}
+
+	var metadata map[string]string
+	if ws.MetadataFunc != nil {
+		metadata = ws.MetadataFunc(r)
+	}
+
 	c := &Conn{
-		UserID:   uc.UserID,
-		WS:       conn,
-		Send:     make(chan []byte, 64),
-		Close:    make(chan struct{}),
-		LastSeen: time.Now(),
+		UserID:       uc.UserID,
+		DeviceID:     ws.deviceID(r),
+		SessionID:    sessionID(uc),
+		Metadata:     metadata,
+		WS:           conn,
+		Send:         make(chan []byte, buffer),
+		Close:        make(chan struct{}),
+		LastSeen:     time.Now(),
+		Backpressure: ws.backpressureFor(r),
+		Codec:        codecFor(codecs, conn.Subprotocol()),
+		drainCh:      make(chan struct{}),
+	}
+	if err := ws.admit(c); err != nil {
+		return err
 	}
-	ws.Hub.Add(userID, c)
+	ws.open.Add(1)
+	ws.armExpiry(c, sessionBase(ctx.Value(common.ContextUserKey)))
+	ws.Metrics.connAdded(ws.PodID)
+	ws.heartbeatOnce.Do(ws.startHeartbeat)
+	ws.cleanupOnce.Do(ws.startAckCleanup)
 	err = ws.Registry.MarkOnline(ctx, userID, ws.PodID)
 	if err == nil {
-		ws.Logger.Info("user connected", zap.String("userID", userID))
+		ws.Logger.Info("user connected", connFields(c)...)
 	}
+	ws.connected(userID)
 
 	if ws.AckStore != nil {
 		go ws.retryUnacked(userID)
@@ -148,51 +261,79 @@ func (ws *WebSocket) readLoop(ctx context.Context, c *Conn) {
 	defer func() {
 		_ = c.WS.Close()
 		close(c.Close)
+		c.stopExpiry()
+		ws.leaveChannels(ctx, c)
 		ws.Hub.Remove(c)
-		_ = ws.Registry.MarkOffline(ctx, c.UserID, ws.PodID)
-		ws.Logger.Info("user disconnected", zap.String("userID", c.UserID))
+		ws.Metrics.connRemoved(ws.PodID)
+		// the user's other devices may still be connected to this pod
+		if len(ws.Hub.Conns(c.UserID)) == 0 {
+			_ = ws.Registry.MarkOffline(ctx, c.UserID, ws.PodID)
+		}
+		ws.disconnected(c.UserID)
+		ws.Logger.Info("user disconnected", connFields(c)...)
+		ws.open.Add(-1)
 	}()
-	c.WS.SetReadLimit(65536)
-	c.WS.SetReadDeadline(time.Now().Add(30 * time.Second))
+	readTimeout := durationOr(ws.ReadTimeout, DefaultReadTimeout)
+	c.WS.SetReadLimit(int64(ws.readLimit()))
+	c.WS.SetReadDeadline(time.Now().Add(readTimeout))
 	c.WS.SetPongHandler(func(string) error {
-		c.WS.SetReadDeadline(time.Now().Add(30 * time.Second))
+		c.WS.SetReadDeadline(time.Now().Add(readTimeout))
 		return nil
 	})
 	for {
-		_, msg, err := c.WS.ReadMessage()
+		frame, msg, err := c.WS.ReadMessage()
 		if err != nil {
 			ws.Logger.Warn("read message error", zap.Error(err))
 			return
 		}
 		if ws.RateLimiter != nil && !ws.RateLimiter.Allow(ctx, c.UserID) {
 			ws.Logger.Warn("rate limit exceeded", zap.String("userID", c.UserID))
+			ws.Metrics.rateLimit(ws.PodID)
 			continue
 		}
-		var env Envelope
-		if err := json.Unmarshal(msg, &env); err != nil {
-			ws.Logger.Warn("invalid JSON payload", zap.Error(err))
+		codec := JSONCodec
+		if frame == websocket.BinaryMessage && c.Codec != nil {
+			codec = c.Codec
+		}
+		env, err := codec.Decode(msg)
+		if err != nil {
+			ws.Logger.Warn("invalid payload", zap.String("codec", codec.Name()), zap.Error(err))
 			continue
 		}
-		_ = ws.Router.Dispatch(ctx, env.Type, env.Payload, c)
+		if ws.Router.has(env.Type) {
+			ws.Metrics.messageReceived(env.Type)
+		} else {
+			ws.Metrics.messageReceived("unknown")
+		}
+		if err := ws.Router.Dispatch(c.sessionContext(ctx), env.Type, env.Payload, c); err != nil {
+			ws.dispatchError(c, env, err)
+		}
 	}
 }
 
 func (ws *WebSocket) writeLoop(c *Conn) {
-	ping := time.NewTicker(10 * time.Second)
+	ping := time.NewTicker(durationOr(ws.PingInterval, DefaultPingInterval))
 	defer ping.Stop()
+	writeTimeout := durationOr(ws.WriteTimeout, DefaultWriteTimeout)
+
 	for {
 		select {
 		case msg := <-c.Send:
-			c.WS.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.WS.WriteMessage(websocket.TextMessage, msg); err != nil {
+			frame, data := encodeFrame(c.Codec, msg)
+			c.WS.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.WS.WriteMessage(frame, data); err != nil {
 				ws.Logger.Warn("write message error", zap.Error(err))
 				return
 			}
+			ws.Metrics.messageSent(ws.PodID)
 		case <-ping.C:
-			c.WS.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.WS.SetWriteDeadline(time.Now().Add(writeTimeout))
 			if err := c.WS.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+		case <-c.drainCh:
+			ws.flushAndClose(c)
+			return
 		case <-c.Close:
 			return
 		}
@@ -202,29 +343,21 @@ func (ws *WebSocket) writeLoop(c *Conn) {
 func (ws *WebSocket) retryUnacked(userID string) {
 	conn := ws.AckStore.Pool.Get()
 	defer conn.Close()
-	pattern := fmt.Sprintf("%s:%s:*", ws.AckStore.Prefix, userID)
-	replies, err := redis.Values(conn.Do("KEYS", pattern))
+
+	msgs, err := ws.AckStore.sorted(conn, userID)
 	if err != nil {
 		ws.Logger.Warn("failed to scan for unacked messages", zap.String("userID", userID), zap.Error(err))
-		return
 	}
-	for _, key := range replies {
-		keyStr, _ := redis.String(key, nil)
-		data, err := redis.Bytes(conn.Do("GET", keyStr))
-		if err != nil {
-			continue
-		}
-		var env Envelope
-		if err := json.Unmarshal(data, &env); err != nil {
-			continue
-		}
-		if env.ExpiresAt > 0 && time.Now().UnixMilli() > env.ExpiresAt {
-			ws.Logger.Info("skipped expired message", zap.String("userID", userID), zap.String("msgID", env.ID))
-			_, _ = conn.Do("DEL", keyStr) // clean up expired
+
+	now := time.Now().UnixMilli()
+	for _, m := range msgs {
+		if m.Env.ExpiresAt > 0 && now > m.Env.ExpiresAt {
+			ws.Logger.Info("skipped expired message", zap.String("userID", userID), zap.String("msgID", m.Env.ID))
+			ws.AckStore.expire(conn, userID, m.Env) // clean up expired
 			continue
 		}
-		_ = ws.Hub.SendLocal(userID, data)
-		ws.Logger.Info("resent unacked message", zap.String("userID", userID), zap.String("key", keyStr))
+		_ = ws.Hub.SendLocal(userID, m.Data)
+		ws.Logger.Info("resent unacked message", zap.String("userID", userID), zap.String("key", m.Key))
 	}
 }
 
@@ -244,47 +377,34 @@ func (ws *WebSocket) restoreHandler(ctx context.Context, c *Conn, raw json.RawMe
 	conn := ws.AckStore.Pool.Get()
 	defer conn.Close()
 
-	pattern := fmt.Sprintf("%s:%s:*", ws.AckStore.Prefix, c.UserID)
-	replies, err := redis.Values(conn.Do("KEYS", pattern))
+	msgs, err := ws.AckStore.sorted(conn, c.UserID)
 	if err != nil {
 		ws.Logger.Warn("restore: redis scan failed", zap.Error(err))
 		return nil
 	}
 
-	if len(replies) == 0 {
-		msg := Envelope{
-			Type:    "restore",
-			Payload: json.RawMessage(`"no message"`),
-		}
-
-		data, _ := json.Marshal(msg)
-		c.Send <- data
-		return nil
-	}
-
-	ws.Logger.Debug("restoring messages", zap.Any("s", replies))
-
 	now := time.Now().UnixMilli()
-	for _, key := range replies {
-		keyStr, _ := redis.String(key, nil)
-		data, err := redis.Bytes(conn.Do("GET", keyStr))
-		if err != nil {
-			ws.Logger.Debug("theres no pending messages")
-			continue
-		}
-
-		var env Envelope
-		if err := json.Unmarshal(data, &env); err != nil {
+	restored := 0
+	for _, m := range msgs {
+		if m.Env.ExpiresAt > 0 && m.Env.ExpiresAt < now {
 			continue
 		}
-		if env.ExpiresAt > 0 && env.ExpiresAt < now {
+		if req.Since > 0 && m.Env.ExpiresAt > 0 && m.Env.ExpiresAt < req.Since {
 			continue
 		}
-		if req.Since > 0 && env.ExpiresAt > 0 && env.ExpiresAt < req.Since {
-			continue
+		_ = ws.Hub.SendLocal(c.UserID, m.Data)
+		restored++
+		ws.Logger.Info("restored message", zap.String("userID", c.UserID), zap.String("msgID", m.Env.ID))
+	}
+
+	if restored == 0 {
+		msg := Envelope{
+			Type:    "restore",
+			Payload: json.RawMessage(`"no message"`),
 		}
-		_ = ws.Hub.SendLocal(c.UserID, data)
-		ws.Logger.Info("restored message", zap.String("userID", c.UserID), zap.String("msgID", env.ID))
+
+		data, _ := json.Marshal(msg)
+		_ = c.enqueue(data)
 	}
 
 	return nil
@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"strings"
+	"sync"
+)
+
+// translations holds per-language message templates keyed by tag name.
+// Templates use %s for the field name, :param for the raw tag parameter and
+// :min / :max for the two parts of a range parameter.
+var (
+	translationsMu sync.RWMutex
+	translations   = map[string]map[string]string{
+		"id": {
+			"required":        "Kolom %s wajib diisi",
+			"numeric":         "Kolom %s harus berupa angka",
+			"alpha":           "Kolom %s hanya boleh berisi huruf",
+			"alpha_num":       "Kolom %s hanya boleh berisi huruf dan angka",
+			"alpha_num_space": "Kolom %s hanya boleh berisi huruf, angka, dan spasi",
+			"alpha_space":     "Kolom %s hanya boleh berisi huruf dan spasi",
+			"email":           "Kolom %s harus berupa alamat email yang valid",
+			"latitude":        "Kolom %s harus berupa latitude yang valid",
+			"longitude":       "Kolom %s harus berupa longitude yang valid",
+			"url":             "Format %s tidak valid",
+			"json":            "Kolom %s harus berupa JSON yang valid",
+			"lte":             "Kolom %s tidak boleh lebih dari :param",
+			"gte":             "Kolom %s minimal :param",
+			"lt":              "Kolom %s harus kurang dari :param",
+			"gt":              "Kolom %s harus lebih dari :param",
+			"range":           "Kolom %s harus di antara :min dan :max",
+			"contains":        "Format %s tidak valid",
+			"match":           "Format %s tidak valid",
+			"same":            "Format %s tidak valid",
+			"in":              "Pilihan %s tidak valid",
+			"not_in":          "Pilihan %s tidak valid",
+			"uuid":            "Kolom %s harus berupa UUID yang valid",
+			"password":        "Kolom %s minimal 8 karakter dan mengandung huruf besar, huruf kecil, angka, dan karakter khusus. Kata sandi umum tidak diperbolehkan",
+		},
+	}
+)
+
+// RegisterTranslation adds or overrides message templates for a language.
+func RegisterTranslation(lang string, messages map[string]string) {
+	translationsMu.Lock()
+	defer translationsMu.Unlock()
+
+	if translations[lang] == nil {
+		translations[lang] = map[string]string{}
+	}
+	for tag, m := range messages {
+		translations[lang][tag] = m
+	}
+}
+
+// HasTranslation reports whether messages are registered for lang.
+func HasTranslation(lang string) bool {
+	translationsMu.RLock()
+	defer translationsMu.RUnlock()
+
+	_, ok := translations[lang]
+	return ok
+}
+
+// translate returns the localized template of the failed tag, or fallback
+// when the validator language has no message for it.
+func (v *Validator) translate(t validatorTag, fallback string) string {
+	if v.Language == "" {
+		return fallback
+	}
+
+	translationsMu.RLock()
+	m, ok := translations[v.Language][t.Name]
+	translationsMu.RUnlock()
+
+	if !ok {
+		return fallback
+	}
+
+	r := []string{":param", t.Param}
+	if p := strings.SplitN(t.Param, ",", 2); len(p) == 2 {
+		r = append(r, ":min", p[0], ":max", p[1])
+	}
+
+	return strings.NewReplacer(r...).Replace(m)
+}
@@ -13,6 +13,7 @@ type (
 	Validator struct {
 		TagName      string
 		ValidatorFns map[string]validatorFn
+		Language     string // language of the failure messages, see RegisterTranslation
 	}
 
 	validatorTag struct {
@@ -80,7 +81,7 @@ func (v *Validator) Field(value interface{}, tag string) (res *Response) {
 	var e string
 	for _, t := range tags {
 		if res.Valid, e = t.Fn(value, t.Param); !res.Valid {
-			res.SetError(t.Name, e)
+			res.SetError(t.Name, v.translate(t, e))
 			break
 		}
 	}
@@ -336,3 +336,24 @@ func TestResponse_Error(t *testing.T) {
 	res.SetError("username", "required")
 	assert.Equal(t, `{"message":"Please fix your input.","error":{"username":"required"}}`, res.Error())
 }
+
+func TestValidator_Language(t *testing.T) {
+	t.Parallel()
+
+	type shipment struct {
+		Receiver string `json:"receiver" valid:"required"`
+		Weight   int    `json:"weight" valid:"range:1,50"`
+	}
+
+	v := validate.New()
+	v.Language = "id"
+
+	r := v.Struct(shipment{Weight: 70})
+	assert.False(t, r.Valid)
+	assert.Equal(t, "Kolom receiver wajib diisi", r.GetMessages()["receiver"])
+	assert.Equal(t, "Kolom weight harus di antara 1 dan 50", r.GetMessages()["weight"])
+
+	v.Language = "fr"
+	r = v.Struct(shipment{Weight: 70})
+	assert.Equal(t, "The receiver field is required", r.GetMessages()["receiver"])
+}